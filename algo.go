@@ -3,6 +3,7 @@ package glay
 import (
 	"errors"
 	"math"
+	"unsafe"
 )
 
 const (
@@ -18,11 +19,15 @@ var (
 
 type Config struct {
 	Layout Dimensions
+	// DisableWarnings disables collection of [Warning]s in Context.Warnings.
+	DisableWarnings bool
 }
 
 const (
 	defaultMaxElementCount              = 8192
 	defaultMaxMeasureTextWordCacheCount = 16384
+	defaultMeasureTextCacheMaxAge       = 2
+	maxWarningCount                     = 100
 )
 
 func (context *Context) Initialize(cfg Config) error {
@@ -32,16 +37,18 @@ func (context *Context) Initialize(cfg Config) error {
 	if context.MaxMeasureTextCacheWordCount == 0 {
 		context.MaxMeasureTextCacheWordCount = defaultMaxMeasureTextWordCacheCount
 	}
-	if context.GoHash == nil {
-		context.GoHash = make(map[uintn]*LayoutElementHashMapItem)
-	}
 	context.LayoutDimensions = cfg.Layout
+	context.WarningsEnabled = !cfg.DisableWarnings
+	context.PointerInfo = MousePointerData{Position: Vector2{X: -1, Y: -1}, State: PointerReleased}
 	var arena _Arena
 	context.initializePersistentMemory(&arena)
 	context.initializeEphemeralMemory(&arena)
 	// arrmemset(context.LayoutElementHashMap[:cap(context.LayoutElementHashMap)], -1)
-	arrmemset(context.measureTextHashMap[:cap(context.measureTextHashMap)], 0)
-	context.measureTextHashMap = context.measureTextHashMap[:1] // Reserve the 0 value to mean "no next element"
+	context.measureTextHashMap = context.measureTextHashMap[:cap(context.measureTextHashMap)] // Hash buckets are accessed directly.
+	context.textRunWidths = context.textRunWidths[:cap(context.textRunWidths)]
+	context.ResetMeasureTextCache()
+	context.layoutElementHashMap = context.layoutElementHashMap[:cap(context.layoutElementHashMap)] // Index slots are accessed directly.
+	arrmemset(context.layoutElementHashMap, 0)
 	return nil
 }
 
@@ -51,39 +58,62 @@ func (context *Context) Clay(decl ElementDeclaration, declChildren ...func(conte
 		return err
 	}
 	err = context.configureOpenElement(decl)
-	if err != nil {
-		return err
-	}
-	for _, decl := range declChildren {
-		err = decl(context)
-		if err != nil {
-			return err
+	if err == nil {
+		for _, decl := range declChildren {
+			err = decl(context)
+			if err != nil {
+				break
+			}
 		}
 	}
-	return context.closeElement()
+	// The element is closed even on error so that the layout tree stays valid.
+	closeErr := context.closeElement()
+	if err == nil {
+		err = closeErr
+	}
+	return err
 }
 
 func (context *Context) initializePersistentMemory(arena *_Arena) {
 	maxElemCount := context.MaxElementCount
 	maxMeasureTextCacheWordCount := context.MaxMeasureTextCacheWordCount
 	alloc(arena, &context.scrollContainerDatas, 100)
-	// alloc(arena, &context.layoutElementHashMapInternal, maxElemCount)
-	// alloc(arena, &context.LayoutElementHashMap, maxElemCount)
+	// Items of the previous layout are retained while the next one is declared, hence twice the element count.
+	alloc(arena, &context.layoutElementHashMapInternal, 2*maxElemCount)
+	alloc(arena, &context.layoutElementHashMapFreelist, 2*maxElemCount)
+	// Index is kept at most half full so probe sequences stay short.
+	alloc(arena, &context.layoutElementHashMap, nextPowerOfTwo(4*maxElemCount))
 	alloc(arena, &context.measureTextHashMapInternal, maxElemCount)
+	alloc(arena, &context.MeasureTextHashMapInternalFreelist, maxElemCount)
 	alloc(arena, &context.measuredWordsFreeList, maxMeasureTextCacheWordCount)
-	alloc(arena, &context.measureTextHashMap, maxElemCount)
+	alloc(arena, &context.measureTextHashMap, maxMeasureTextCacheWordCount/32)
 	alloc(arena, &context.measuredWords, maxMeasureTextCacheWordCount)
 	alloc(arena, &context.PointerOverIDs, maxElemCount)
-	alloc(arena, &context.debugElementData, maxElemCount)
 	alloc(arena, &context.renderCommands, maxElemCount)
+	alloc(arena, &context.rectangleRenderData, maxElemCount)
+	alloc(arena, &context.borderRenderData, maxElemCount)
+	alloc(arena, &context.textRenderData, maxElemCount)
+	alloc(arena, &context.textRuns, maxElemCount)
+	alloc(arena, &context.textRunWidths, nextPowerOfTwo(maxElemCount))
+	alloc(arena, &context.imageRenderData, maxElemCount)
+	alloc(arena, &context.clipRenderData, maxElemCount)
+	alloc(arena, &context.customRenderData, maxElemCount)
 }
 
 func (context *Context) initializeEphemeralMemory(arena *_Arena) {
 	maxElementCount := context.MaxElementCount
-	clear(context.GoHash)
+	// Hash map items persist for one frame so that bounding boxes and debug data
+	// of the previous layout are available while declaring the next one.
+	for i := range context.layoutElementHashMapInternal {
+		item := &context.layoutElementHashMapInternal[i]
+		if item.Generation != 0 && item.Generation <= context.Generation {
+			context.removeHashMapItem(item.ElementID.ID)
+		}
+	}
 	alloc(arena, &context.LayoutElementChildrenBuffer, maxElementCount)
 	alloc(arena, &context.LayoutElements, maxElementCount)
-	// alloc(arena, &context.Warnings, 100)
+	alloc(arena, &context.Warnings, maxWarningCount)
+	context.booleanWarnings = booleanWarnings{}
 
 	alloc(arena, &context.LayoutConfigs, maxElementCount)
 	alloc(arena, &context.ElementConfigs, maxElementCount)
@@ -96,8 +126,13 @@ func (context *Context) initializeEphemeralMemory(arena *_Arena) {
 	alloc(arena, &context.BorderElementConfigs, maxElementCount)
 	alloc(arena, &context.SharedElementConfigs, maxElementCount)
 
+	alloc(arena, &context.TextElementData, maxElementCount)
 	alloc(arena, &context.LayoutElementIDStrings, maxElementCount)
 	alloc(arena, &context.WrappedTextLines, maxElementCount)
+	alloc(arena, &context.wrappedTextFragments, maxElementCount)
+	alloc(arena, &context.textSpans, maxElementCount)
+	alloc(arena, &context.gridTracks, maxElementCount)
+	alloc(arena, &context.gridFreeRows, maxElementCount)
 	alloc(arena, &context.LayoutElementTreeNodes1, maxElementCount)
 	alloc(arena, &context.LayoutElementTreeRoots, maxElementCount)
 	alloc(arena, &context.TreeNodeVisited, maxElementCount)
@@ -114,15 +149,245 @@ func (context *Context) initializeEphemeralMemory(arena *_Arena) {
 
 type _Arena struct{}
 
+// elementBaseline returns the distance from the top of an element to the baseline of its first line
+// of text: that of a text element, or that of the child a container places first, where the
+// positioning pass places it. Elements without text have their baseline at their bottom edge.
+func (context *Context) elementBaseline(element *LayoutElement) floatn {
+	if element.textElementData != nil {
+		return element.textElementData.baseline
+	}
+	children := element.Children()
+	if len(children) == 0 {
+		return element.Dimensions.Height
+	}
+	layoutConfig := element.LayoutConfig
+	end := arrlen(children)
+	if layoutConfig.wraps() {
+		end, _, _ = context.wrapLineEnd(element, 0)
+	}
+	child := &context.LayoutElements[children[element.placedChild(0, 0, end)]]
+	var top floatn
+	switch layoutConfig.LayoutDirection {
+	case Grid:
+		y, height := context.gridCell(element, child, false)
+		top = context.gridChildY(element, child, y, height)
+	case LeftToRight:
+		lineTop, lineSize := floatn(layoutConfig.Padding.Top), element.Dimensions.Height-floatn(layoutConfig.Padding.Vertical())
+		if layoutConfig.wraps() {
+			lineTop += context.wrapLinesOffset(element)
+			_, _, lineSize = context.wrapLineEnd(element, 0)
+		}
+		var baseline floatn
+		if layoutConfig.ChildAlignment.Y == AlignYBaseline {
+			baseline = context.childrenBaseline(children[:end])
+		}
+		top = context.rowChildY(element, child, lineTop, lineSize, baseline)
+	default:
+		offset, _ := context.alignedSpace(element, 0, end)
+		top = floatn(layoutConfig.Padding.Top) + offset + floatn(child.LayoutConfig.Margin.Top)
+	}
+	return top + context.elementBaseline(child)
+}
+
+// rowChildY returns the offset from the top of a LeftToRight element of its child in the line of
+// children starting at top and size high, whose baseline is baseline below top.
+func (context *Context) rowChildY(element, child *LayoutElement, top, size, baseline floatn) floatn {
+	alignment := child.LayoutConfig.Self.alignY(element.LayoutConfig.ChildAlignment.Y)
+	if alignment == AlignYBaseline {
+		return top + baseline - context.elementBaseline(child)
+	}
+	return top + floatn(child.LayoutConfig.Margin.Top) + alignedOffset(alignment, size-child.outerSize(false))
+}
+
+// gridChildY returns the offset from the top of a Grid element of its child in the rows starting at
+// y and height high.
+func (context *Context) gridChildY(element, child *LayoutElement, y, height floatn) floatn {
+	alignment := child.LayoutConfig.Self.alignY(element.LayoutConfig.ChildAlignment.Y)
+	return floatn(element.LayoutConfig.Padding.Top) + y + floatn(child.LayoutConfig.Margin.Top) + alignedOffset(alignment, height-child.outerSize(false))
+}
+
+// alignedOffset returns the offset of an element aligned within space left around it.
+// Baseline alignment is top alignment.
+func alignedOffset(alignment LayoutAlignmentY, space floatn) floatn {
+	switch alignment {
+	case AlignYCenter:
+		return space / 2
+	case AlignYBottom:
+		return space
+	}
+	return 0
+}
+
+// wrapLinesOffset returns the offset across the layout axis of the first line of children of a
+// wrapping element from its padding, which aligns the block of its lines.
+func (context *Context) wrapLinesOffset(element *LayoutElement) floatn {
+	layoutConfig := element.LayoutConfig
+	xaxis := layoutConfig.LayoutDirection == LeftToRight
+	_, crossSize := context.wrapContentSize(element)
+	extraSpace := element.Dimensions.SizeAxis(!xaxis) - layoutConfig.Padding.SizeAxis(!xaxis) - crossSize
+	if xaxis {
+		extraSpace = alignedOffset(layoutConfig.ChildAlignment.Y, extraSpace)
+	} else {
+		switch layoutConfig.ChildAlignment.X {
+		case AlignXLeft:
+			extraSpace = 0
+		case AlignXCenter:
+			extraSpace /= 2
+		}
+	}
+	return max(0, extraSpace)
+}
+
+// childrenBaseline returns the distance from the top of the line of children aligned on their
+// baselines to their baseline. Children aligned with Self are not aligned on their baselines.
+func (context *Context) childrenBaseline(children []intn) (baseline floatn) {
+	for _, child := range children {
+		childElement := &context.LayoutElements[child]
+		if childElement.LayoutConfig.Self == AlignSelfAuto {
+			baseline = max(baseline, floatn(childElement.LayoutConfig.Margin.Top)+context.elementBaseline(childElement))
+		}
+	}
+	return baseline
+}
+
+// baselineHeight returns the height of children aligned on their baselines, margins included.
+func (context *Context) baselineHeight(children []intn) floatn {
+	var above, below floatn
+	for _, child := range children {
+		childElement := &context.LayoutElements[child]
+		if childElement.LayoutConfig.Self != AlignSelfAuto {
+			continue
+		}
+		margin := childElement.LayoutConfig.Margin
+		baseline := context.elementBaseline(childElement)
+		above = max(above, floatn(margin.Top)+baseline)
+		below = max(below, childElement.Dimensions.Height-baseline+floatn(margin.Bottom))
+	}
+	return above + below
+}
+
+// breakWrapLines breaks the children of a wrapping element into lines no longer than size along
+// its layout axis, marking the first child of every line but the first.
+func (context *Context) breakWrapLines(element *LayoutElement, size floatn) {
+	xaxis := element.LayoutConfig.LayoutDirection == LeftToRight
+	childGap := floatn(element.LayoutConfig.ChildGap)
+	var lineSize floatn
+	for i, child := range element.Children() {
+		childElement := &context.LayoutElements[child]
+		childSize := childElement.outerSize(xaxis)
+		childElement.wrapBreak = i > 0 && lineSize+childGap+childSize > size+eps
+		if i == 0 || childElement.wrapBreak {
+			lineSize = childSize
+		} else {
+			lineSize += childGap + childSize
+		}
+	}
+}
+
+// wrapLineEnd returns the end of the line of children of a wrapping element starting at the child
+// with index start, and the size of the line along the layout axis and across it.
+func (context *Context) wrapLineEnd(element *LayoutElement, start intn) (end intn, size, crossSize floatn) {
+	layoutConfig := element.LayoutConfig
+	xaxis := layoutConfig.LayoutDirection == LeftToRight
+	children := element.Children()
+	for end = start; end < arrlen(children); end++ {
+		childElement := &context.LayoutElements[children[end]]
+		if end > start {
+			if childElement.wrapBreak {
+				break
+			}
+			size += floatn(layoutConfig.ChildGap)
+		}
+		size += childElement.outerSize(xaxis)
+		crossSize = max(crossSize, childElement.outerSize(!xaxis))
+	}
+	if xaxis && layoutConfig.ChildAlignment.Y == AlignYBaseline {
+		crossSize = max(crossSize, context.baselineHeight(children[start:end]))
+	}
+	return end, size, crossSize
+}
+
+// wrapContentSize returns the size of the longest line of children of a wrapping element and the
+// size of all of its lines across the layout axis, gaps included.
+func (context *Context) wrapContentSize(element *LayoutElement) (size, crossSize floatn) {
+	for start := intn(0); start < arrlen(element.Children()); {
+		end, lineSize, lineCrossSize := context.wrapLineEnd(element, start)
+		if start > 0 {
+			crossSize += floatn(element.LayoutConfig.ChildGap)
+		}
+		size = max(size, lineSize)
+		crossSize += lineCrossSize
+		start = end
+	}
+	return size, crossSize
+}
+
+// alignedSpace returns the offset along the layout axis of an element of the first of its children
+// in [start, end) and the gap between them, as spread by distributedSpace or aligned by ChildAlignment.
+func (context *Context) alignedSpace(element *LayoutElement, start, end intn) (offset, gap floatn) {
+	layoutConfig := element.LayoutConfig
+	xaxis := layoutConfig.LayoutDirection == LeftToRight
+	extraSpace := element.Dimensions.SizeAxis(xaxis) - layoutConfig.Padding.SizeAxis(xaxis) - floatn(layoutConfig.ChildGap)*floatn(max(end-start-1, 0))
+	for _, child := range element.Children()[start:end] {
+		extraSpace -= context.LayoutElements[child].outerSize(xaxis)
+	}
+	if layoutConfig.Distribute != DistributeNone && extraSpace > 0 {
+		return context.distributedSpace(element, start, end)
+	}
+	center := (xaxis && layoutConfig.alignX() == AlignXCenter) || (!xaxis && layoutConfig.alignY() == AlignYCenter)
+	atEnd := (xaxis && layoutConfig.alignX() == AlignXRight) || (!xaxis && layoutConfig.alignY() == AlignYBottom)
+	switch {
+	case center:
+		extraSpace /= 2
+	case !atEnd:
+		extraSpace = 0
+	}
+	return max(0, extraSpace), floatn(layoutConfig.ChildGap)
+}
+
+// distributedSpace returns the offset along the layout axis of an element of the first of its
+// children in [start, end) and the gap between them, which spread the space left by the children
+// according to the Distribute mode of the element. The gap is never less than ChildGap.
+func (context *Context) distributedSpace(element *LayoutElement, start, end intn) (offset, gap floatn) {
+	layoutConfig := element.LayoutConfig
+	xaxis := layoutConfig.LayoutDirection == LeftToRight
+	gap = floatn(layoutConfig.ChildGap)
+	count := end - start
+	extraSpace := element.Dimensions.SizeAxis(xaxis) - layoutConfig.Padding.SizeAxis(xaxis) - gap*floatn(max(count-1, 0))
+	for _, child := range element.Children()[start:end] {
+		extraSpace -= context.LayoutElements[child].outerSize(xaxis)
+	}
+	if extraSpace <= 0 || count == 0 {
+		return 0, gap
+	}
+	switch layoutConfig.Distribute {
+	case DistributeBetween:
+		if count > 1 {
+			return 0, gap + extraSpace/floatn(count-1)
+		}
+	case DistributeAround:
+		return extraSpace / floatn(2*count), gap + extraSpace/floatn(count)
+	case DistributeEvenly:
+		return extraSpace / floatn(count+1), gap + extraSpace/floatn(count+1)
+	}
+	return 0, gap
+}
+
 func alloc[T any](_ *_Arena, dst *[]T, n intn) {
 	if cap(*dst) >= int(n) {
-		*dst = (*dst)[:0] // Enoguh capacity, reslice.
+		*dst = (*dst)[:0] // Enough capacity, reslice.
+		return
 	}
 	*dst = make([]T, n)[:0] // need a new slice.
 }
 
 func (context *Context) storeLayoutConfig(layout LayoutConfig) *LayoutConfig {
-	return &layout
+	if arrfree(context.LayoutConfigs) == 0 {
+		context.elementsCapacityExceeded()
+		return &defaultLayoutConfig
+	}
+	context.LayoutConfigs = arradd(context.LayoutConfigs, layout)
+	return arrlast(context.LayoutConfigs)
 }
 
 func (context *Context) BeginLayout() error {
@@ -132,6 +397,9 @@ func (context *Context) BeginLayout() error {
 	context.DynamicElementIndex = 0
 	// Setup root container that covers entire window.
 	rootDimensions := context.LayoutDimensions
+	if context.DebugModeEnabled {
+		rootDimensions.Width -= debugViewWidth
+	}
 	if len(context.LayoutElements) != 0 {
 		return errors.New("expect no elements on call to BeginLayout")
 	}
@@ -141,7 +409,7 @@ func (context *Context) BeginLayout() error {
 		Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, rootDimensions.Width), Height: NewSizingAxis(SizingFixed, rootDimensions.Height)}},
 	})
 	context.OpenLayoutElementStack = arradd(context.OpenLayoutElementStack, 0)
-	context.LayoutElementTreeRoots = append(context.LayoutElementTreeRoots, layoutElementTreeRoot{LayoutElementIndex: 0})
+	context.LayoutElementTreeRoots = arradd(context.LayoutElementTreeRoots, layoutElementTreeRoot{LayoutElementIndex: 0})
 	return nil
 }
 
@@ -150,55 +418,93 @@ func (context *Context) EndLayout() ([]RenderCommand, error) {
 	if err != nil {
 		return nil, err
 	}
-	elementsExceededBeforeDebugView := context.warnMaxElementsExceeded()
-	if context.DebugModeEnabled && !elementsExceededBeforeDebugView {
-		context.WarningsEnabled = false
-		context.renderDebugView()
-		context.WarningsEnabled = true
-	}
-	if context.warnMaxElementsExceeded() {
-		if !elementsExceededBeforeDebugView {
-			err = errors.New("max elements exceeded after adding debug view")
-		} else {
-			err = errors.New("max elements exceeded")
+	if context.DebugModeEnabled && !context.warnMaxElementsExceeded() {
+		warningsEnabled, mirrored := context.WarningsEnabled, context.Mirrored
+		context.WarningsEnabled, context.Mirrored = false, false
+		err = context.renderDebugView()
+		context.WarningsEnabled, context.Mirrored = warningsEnabled, mirrored
+		if err != nil && err != ErrElementsCapacityExceeded {
+			return nil, err
 		}
-	} else {
-		err = context.calculateFinalLayout()
+	}
+	// Elements beyond capacity were never added so the layout is still calculated
+	// and a truncated but valid list of render commands is returned.
+	err = context.calculateFinalLayout()
+	if err != nil {
+		return context.renderCommands, err
+	}
+	switch {
+	case context.booleanWarnings.maxElementsExceeded, context.booleanWarnings.maxRenderCommandsExceeded:
+		err = ErrElementsCapacityExceeded
+	case context.booleanWarnings.maxTextMeasureCacheExceeded:
+		err = ErrTextMeasurementCapacityExceeded
 	}
 	return context.renderCommands, err
 }
 
-func (context *Context) renderDebugView() {
-
+// warnMaxElementsExceeded returns true if an element was discarded in the current layout for lack of capacity.
+func (context *Context) warnMaxElementsExceeded() bool {
+	return context.booleanWarnings.maxElementsExceeded
 }
 
-func (context *Context) warnMaxElementsExceeded() bool {
-	return false // TODO
+// elementsCapacityExceeded records that an element or its data was discarded for lack of capacity.
+func (context *Context) elementsCapacityExceeded() {
+	if !context.booleanWarnings.maxElementsExceeded {
+		context.addWarning(WarningElementsCapacityExceeded, "")
+	}
+	context.booleanWarnings.maxElementsExceeded = true
 }
 
 // openElement creates a LayoutElement and opens it, making it the currently open layout element.
 func (context *Context) openElement() error {
-	if arrfree(context.LayoutElements) == 0 {
-		return errors.New("max elements exceeded")
+	if arrfree(context.LayoutElements) == 0 || context.warnMaxElementsExceeded() {
+		context.elementsCapacityExceeded()
+		return ErrElementsCapacityExceeded
 	}
 	elemIdx := arrlen(context.LayoutElements)
 	context.LayoutElements = arradd(context.LayoutElements, LayoutElement{})
 	context.OpenLayoutElementStack = arradd(context.OpenLayoutElementStack, elemIdx)
 	if len(context.openClipElementStack) > 0 {
-		context.LayoutElementClipElementIDs[elemIdx] = context.openClipElementStack[len(context.openClipElementStack)-1]
+		context.LayoutElementClipElementIDs = arradd(context.LayoutElementClipElementIDs, context.openClipElementStack[len(context.openClipElementStack)-1])
 	} else {
-		arr := context.LayoutElementClipElementIDs[:1]
-		arr[0] = 0
+		context.LayoutElementClipElementIDs = arradd(context.LayoutElementClipElementIDs, 0)
 	}
 	return nil
 }
 
+// mirror swaps the left and right of the declaration for a mirrored layout.
+func (decl *ElementDeclaration) mirror() {
+	layout := &decl.Layout
+	layout.Padding.Left, layout.Padding.Right = layout.Padding.Right, layout.Padding.Left
+	layout.Margin.Left, layout.Margin.Right = layout.Margin.Right, layout.Margin.Left
+	if layout.LayoutDirection == LeftToRight {
+		layout.Reverse = !layout.Reverse // Reverses the X alignment too.
+	} else {
+		layout.ChildAlignment.X = layout.ChildAlignment.X.mirrored()
+	}
+	if layout.LayoutDirection == Grid {
+		layout.Reverse = !layout.Reverse
+	}
+	decl.Border.Width.Left, decl.Border.Width.Right = decl.Border.Width.Right, decl.Border.Width.Left
+	radius := &decl.CornerRadius
+	radius.TopLeft, radius.TopRight = radius.TopRight, radius.TopLeft
+	radius.BottomLeft, radius.BottomRight = radius.BottomRight, radius.BottomLeft
+	decl.Floating.AttachPoints.Element = decl.Floating.AttachPoints.Element.mirrored()
+	decl.Floating.AttachPoints.Parent = decl.Floating.AttachPoints.Parent.mirrored()
+	decl.Floating.Offset.X = -decl.Floating.Offset.X
+}
+
 func (context *Context) configureOpenElement(decl ElementDeclaration) error {
+	if context.Mirrored {
+		decl.mirror()
+	}
 	openLayoutElement := context.openLayoutElement()
 	openLayoutElement.LayoutConfig = context.storeLayoutConfig(decl.Layout)
+	openLayoutElement.mirrored = context.Mirrored
 	if (decl.Layout.Sizing.Width.Type == SizingPercent && decl.Layout.Sizing.Width.Percent > 1) ||
 		(decl.Layout.Sizing.Height.Type == SizingPercent && decl.Layout.Sizing.Height.Percent > 1) {
-		return errors.New("invalid sizing percent. Expect in range 0..1, i.e: 20% is 0.2")
+		context.addWarning(WarningPercentageOver1, decl.ID.StringID)
+		return ErrPercentageOver1
 	}
 
 	openLayoutElementID := decl.ID
@@ -223,8 +529,9 @@ func (context *Context) configureOpenElement(decl ElementDeclaration) error {
 	}
 	if decl.AspectRatio.AspectRatio > 0 {
 		context.AspectRatioElementConfigs = arradd(context.AspectRatioElementConfigs, decl.AspectRatio)
-		context.rawAttachElementConfig(openLayoutElement, arrlast(context.AspectRatioElementConfigs))
-		context.AspectRatioElementIndexes = arradd(context.AspectRatioElementIndexes, arrlen(context.LayoutElements)-1)
+		if context.rawAttachElementConfig(openLayoutElement, arrlast(context.AspectRatioElementConfigs)) != nil {
+			context.AspectRatioElementIndexes = arradd(context.AspectRatioElementIndexes, arrlen(context.LayoutElements)-1)
+		}
 	}
 	if decl.Floating.AttachTo != AttachToNone && len(context.OpenLayoutElementStack) >= 2 {
 		floatingConfig := decl.Floating
@@ -240,26 +547,38 @@ func (context *Context) configureOpenElement(decl ElementDeclaration) error {
 		case AttachToElementWithID:
 			parentItem := context.HashMapItem(floatingConfig.ParentID)
 			if parentItem == nil {
-				return errors.New("a parent item was declared with a parentID but no element with that ID was found")
+				context.addWarning(WarningFloatingParentNotFound, decl.ID.StringID)
+				return ErrFloatingContainerParentNotFound
+			}
+			// Only a parent declared earlier in this layout has a valid clip ancestry.
+			if parentItem = context.declaredHashMapItem(floatingConfig.ParentID); parentItem != nil {
+				if parentItem.LayoutElement.GetConfig(ElementConfigTypeClip) != nil {
+					clipElementID = parentItem.LayoutElement.ID
+				} else {
+					clipElementID = uintn(context.LayoutElementClipElementIDs[context.layoutElementIndex(parentItem.LayoutElement)])
+				}
 			}
-			// clipElementID = uintn(context.LayoutElementClipElementIDs[parentItem.LayoutElement])
 		case AttachToRoot:
 			floatingConfig.ParentID = hashString(rootContainerStr, 0, 0).ID
 		}
 		if floatingConfig.ClipTo == ClipToNone {
 			clipElementID = 0
 		}
-		if openLayoutElement.ID == 0 {
+		if openLayoutElementID.ID == 0 {
 			openLayoutElementID = hashString("Clay__FloatingContainer", uintn(arrlen(context.LayoutElementTreeRoots)), 0)
 		}
-		context.LayoutElementTreeRoots = arradd(context.LayoutElementTreeRoots, layoutElementTreeRoot{
-			LayoutElementIndex: context.OpenLayoutElementStack[len(context.OpenLayoutElementStack)-1],
-			ParentID:           floatingConfig.ParentID,
-			ClipElementID:      clipElementID,
-			Zindex:             floatingConfig.Zindex,
-		})
 		context.FloatingElementConfigs = arradd(context.FloatingElementConfigs, floatingConfig)
-		context.rawAttachElementConfig(openLayoutElement, arrlast(context.FloatingElementConfigs))
+		if context.rawAttachElementConfig(openLayoutElement, arrlast(context.FloatingElementConfigs)) != nil {
+			// Floating elements escape the clip of their hierarchical parent, their contents are clipped by ClipTo instead.
+			context.LayoutElementClipElementIDs[context.OpenLayoutElementStack[len(context.OpenLayoutElementStack)-1]] = intn(clipElementID)
+			context.openClipElementStack = arradd(context.openClipElementStack, intn(clipElementID))
+			context.LayoutElementTreeRoots = arradd(context.LayoutElementTreeRoots, layoutElementTreeRoot{
+				LayoutElementIndex: context.OpenLayoutElementStack[len(context.OpenLayoutElementStack)-1],
+				ParentID:           floatingConfig.ParentID,
+				ClipElementID:      clipElementID,
+				Zindex:             floatingConfig.Zindex,
+			})
+		}
 	}
 	if openLayoutElementID.ID != 0 {
 		context.attachID(openLayoutElementID)
@@ -269,7 +588,10 @@ func (context *Context) configureOpenElement(decl ElementDeclaration) error {
 
 	if decl.Clip.Horizontal || decl.Clip.Vertical {
 		context.ClipElementConfigs = arradd(context.ClipElementConfigs, decl.Clip)
-		context.rawAttachElementConfig(openLayoutElement, arrlast(context.ClipElementConfigs))
+		if context.rawAttachElementConfig(openLayoutElement, arrlast(context.ClipElementConfigs)) == nil {
+			return ErrElementsCapacityExceeded
+		}
+		context.openClipElementStack = arradd(context.openClipElementStack, intn(openLayoutElement.ID))
 		var scrollOffset *scrollContainerDataInternal
 		for i := intn(0); i < arrlen(context.scrollContainerDatas); i++ {
 			mapping := &context.scrollContainerDatas[i]
@@ -280,7 +602,10 @@ func (context *Context) configureOpenElement(decl ElementDeclaration) error {
 				break
 			}
 		}
-		if scrollOffset == nil {
+		if scrollOffset == nil && arrfree(context.scrollContainerDatas) == 0 {
+			context.elementsCapacityExceeded()
+			return ErrElementsCapacityExceeded
+		} else if scrollOffset == nil {
 			context.scrollContainerDatas = arradd(context.scrollContainerDatas, scrollContainerDataInternal{
 				LayoutElement: openLayoutElement,
 				ScrollOrigin:  Vector2{-1, -1},
@@ -293,10 +618,17 @@ func (context *Context) configureOpenElement(decl ElementDeclaration) error {
 			scrollOffset.ScrollPosition = context.queryScrollOffset(scrollOffset.ElementID)
 		}
 	}
+	if decl.Custom.CustomData != nil {
+		context.CustomElementConfigs = arradd(context.CustomElementConfigs, decl.Custom)
+		context.rawAttachElementConfig(openLayoutElement, arrlast(context.CustomElementConfigs))
+	}
 	if decl.Border.Width != (BorderWidth{}) {
 		context.BorderElementConfigs = arradd(context.BorderElementConfigs, decl.Border)
 		context.rawAttachElementConfig(openLayoutElement, arrlast(context.BorderElementConfigs))
 	}
+	if context.warnMaxElementsExceeded() {
+		return ErrElementsCapacityExceeded
+	}
 	return nil
 }
 
@@ -310,7 +642,7 @@ func (context *Context) queryScrollOffset(elementID uintn) Vector2 {
 func (context *Context) generateIDForAnonElement(openLayoutElement *LayoutElement) ElementID {
 	parentElement := context.openParentLayoutElement()
 
-	elementID := hashNumber(uintn(len(parentElement.Children())), parentElement.ID)
+	elementID := hashNumber(uintn(parentElement.childCount), parentElement.ID)
 	openLayoutElement.ID = elementID.ID
 	context.AddHashMapItem(elementID, openLayoutElement, 0)
 	context.LayoutElementIDStrings = arradd(context.LayoutElementIDStrings, elementID.StringID)
@@ -329,10 +661,13 @@ func (context *Context) closeElement() error {
 		elementHasScrollVertical = config.Vertical
 		context.openClipElementStack, _ = arrpop(context.openClipElementStack)
 	}
+	if openLayoutElement.GetConfig(ElementConfigTypeFloating) != nil {
+		context.openClipElementStack, _ = arrpop(context.openClipElementStack)
+	}
 
 	// Attach uninitialized children to the current open element.
 	// They will be initialized during loop.
-	openLayoutElement.ChildrenOrTextContent = context.LayoutElementChildren[len(context.LayoutElementChildren) : len(context.LayoutElementChildren)+len(openLayoutElement.Children())]
+	openLayoutElement.children = context.LayoutElementChildren[len(context.LayoutElementChildren) : len(context.LayoutElementChildren)+int(openLayoutElement.childCount)]
 	children := openLayoutElement.Children()
 	childGap := floatn(max(len(children)-1, 0)) * floatn(layoutConfig.ChildGap)
 	if layoutConfig.LayoutDirection == LeftToRight {
@@ -340,42 +675,67 @@ func (context *Context) closeElement() error {
 		for i := intn(0); i < arrlen(children); i++ {
 			childIndex := context.LayoutElementChildrenBuffer[len(context.LayoutElementChildrenBuffer)-len(children)+int(i)]
 			child := &context.LayoutElements[childIndex]
-			openLayoutElement.Dimensions.Width += child.Dimensions.Width
-			openLayoutElement.Dimensions.Height = max(openLayoutElement.Dimensions.Height, child.Dimensions.Height+floatn(layoutConfig.Padding.Vertical()))
+			childDimensions, childMinDimensions := child.outerDimensions()
+			openLayoutElement.Dimensions.Width += childDimensions.Width
+			openLayoutElement.Dimensions.Height = max(openLayoutElement.Dimensions.Height, childDimensions.Height+floatn(layoutConfig.Padding.Vertical()))
 			// Minimum size of child elements doesn't matter to scroll containers as they can shrink and hide their contents
-			if !elementHasScrollHorizontal {
-				openLayoutElement.MinDimensions.Width += child.MinDimensions.Width
+			if !elementHasScrollHorizontal && layoutConfig.wraps() {
+				// Wrapping elements can be as narrow as their widest child.
+				openLayoutElement.MinDimensions.Width = max(openLayoutElement.MinDimensions.Width, childMinDimensions.Width+floatn(layoutConfig.Padding.Horizontal()))
+			} else if !elementHasScrollHorizontal {
+				openLayoutElement.MinDimensions.Width += childMinDimensions.Width
 			}
 			if !elementHasScrollVertical {
-				openLayoutElement.MinDimensions.Height = max(openLayoutElement.MinDimensions.Height, child.MinDimensions.Height+floatn(layoutConfig.Padding.Vertical()))
+				openLayoutElement.MinDimensions.Height = max(openLayoutElement.MinDimensions.Height, childMinDimensions.Height+floatn(layoutConfig.Padding.Vertical()))
 			}
 			context.LayoutElementChildren = arradd(context.LayoutElementChildren, childIndex)
 		}
 		openLayoutElement.Dimensions.Width += childGap
-		if !elementHasScrollHorizontal {
+		if !elementHasScrollHorizontal && !layoutConfig.wraps() {
 			openLayoutElement.MinDimensions.Width += childGap
 		}
+		if layoutConfig.ChildAlignment.Y == AlignYBaseline {
+			// Children shifted down to align their baselines extend below the tallest child.
+			openLayoutElement.Dimensions.Height = max(openLayoutElement.Dimensions.Height, context.baselineHeight(children)+floatn(layoutConfig.Padding.Vertical()))
+		}
 	} else if layoutConfig.LayoutDirection == TopToBottom {
 
 		openLayoutElement.Dimensions.Height = floatn(layoutConfig.Padding.Vertical())
 		for i := intn(0); i < arrlen(children); i++ {
 			childIndex := context.LayoutElementChildrenBuffer[len(context.LayoutElementChildrenBuffer)-len(children)+int(i)]
 			child := &context.LayoutElements[childIndex]
-			openLayoutElement.Dimensions.Height += child.Dimensions.Height
-			openLayoutElement.Dimensions.Width = max(openLayoutElement.Dimensions.Width, child.Dimensions.Width+floatn(layoutConfig.Padding.Horizontal()))
+			childDimensions, childMinDimensions := child.outerDimensions()
+			openLayoutElement.Dimensions.Height += childDimensions.Height
+			openLayoutElement.Dimensions.Width = max(openLayoutElement.Dimensions.Width, childDimensions.Width+floatn(layoutConfig.Padding.Horizontal()))
 			// Minimum size of child elements doesn't matter to scroll containers as they can shrink and hide their contents
-			if !elementHasScrollVertical {
-				openLayoutElement.MinDimensions.Height += child.MinDimensions.Height
+			if !elementHasScrollVertical && layoutConfig.wraps() {
+				openLayoutElement.MinDimensions.Height = max(openLayoutElement.MinDimensions.Height, childMinDimensions.Height+floatn(layoutConfig.Padding.Vertical()))
+			} else if !elementHasScrollVertical {
+				openLayoutElement.MinDimensions.Height += childMinDimensions.Height
 			}
 			if !elementHasScrollHorizontal {
-				openLayoutElement.MinDimensions.Width = max(openLayoutElement.MinDimensions.Width, child.MinDimensions.Width+floatn(layoutConfig.Padding.Horizontal()))
+				openLayoutElement.MinDimensions.Width = max(openLayoutElement.MinDimensions.Width, childMinDimensions.Width+floatn(layoutConfig.Padding.Horizontal()))
 			}
 			context.LayoutElementChildren = arradd(context.LayoutElementChildren, childIndex)
 		}
 		openLayoutElement.Dimensions.Height += childGap
-		if !elementHasScrollVertical {
+		if !elementHasScrollVertical && !layoutConfig.wraps() {
 			openLayoutElement.MinDimensions.Height += childGap
 		}
+	} else if layoutConfig.LayoutDirection == Grid {
+		for i := intn(0); i < arrlen(children); i++ {
+			childIndex := context.LayoutElementChildrenBuffer[len(context.LayoutElementChildrenBuffer)-len(children)+int(i)]
+			context.LayoutElementChildren = arradd(context.LayoutElementChildren, childIndex)
+		}
+		context.placeGridChildren(openLayoutElement)
+		if !elementHasScrollHorizontal {
+			openLayoutElement.MinDimensions.Width = context.sizeGridTracks(openLayoutElement, true, -1, true) + floatn(layoutConfig.Padding.Horizontal())
+		}
+		if !elementHasScrollVertical {
+			openLayoutElement.MinDimensions.Height = context.sizeGridTracks(openLayoutElement, false, -1, true) + floatn(layoutConfig.Padding.Vertical())
+		}
+		openLayoutElement.Dimensions.Width = context.sizeGridTracks(openLayoutElement, true, -1, false) + floatn(layoutConfig.Padding.Horizontal())
+		openLayoutElement.Dimensions.Height = context.sizeGridTracks(openLayoutElement, false, -1, false) + floatn(layoutConfig.Padding.Vertical())
 	}
 	context.LayoutElementChildrenBuffer = context.LayoutElementChildrenBuffer[:len(context.LayoutElementChildrenBuffer)-len(children)]
 
@@ -409,9 +769,7 @@ func (context *Context) closeElement() error {
 	context.OpenLayoutElementStack, closingElementIndex = arrpop(context.OpenLayoutElementStack)
 	openLayoutElement = context.openLayoutElement()
 	if !elementIsFloating && len(context.OpenLayoutElementStack) > 1 {
-		children := openLayoutElement.Children()
-		openLayoutElement.ChildrenOrTextContent = append(children, -1) // Extend with bogus data and allocate buffer.
-
+		openLayoutElement.childCount++
 		context.LayoutElementChildrenBuffer = arradd(context.LayoutElementChildrenBuffer, closingElementIndex)
 	}
 	return nil
@@ -432,10 +790,16 @@ func (context *Context) calculateFinalLayout() error {
 		containerElement := &context.LayoutElements[textElementData.ElementIndex]
 		// Guaranteed to be text.
 		textConfig := containerElement.GetConfig(ElementConfigTypeText).(*TextElementConfig)
-		mtci := context.measureTextCached(textElementData.Text, textConfig)
+		if len(textElementData.spans) > 0 {
+			context.wrapRichText(textElementData, containerElement, textConfig)
+			continue
+		}
+		mtci := context.lookupTextCached(textElementData.Text, textConfig)
+		if mtci == nil {
+			continue // Measurement cache exhausted, the text is not rendered.
+		}
 		if !mtci.containsNewlines && textElementData.PreferredDimensions.Width <= containerElement.Dimensions.Width {
-			context.WrappedTextLines = arradd(context.WrappedTextLines, WrappedTextLine{Dimensions: containerElement.Dimensions, Line: textElementData.Text})
-			textElementData.WrappedLines = textElementData.WrappedLines[:len(textElementData.WrappedLines)+1]
+			context.addWrappedTextLine(textElementData, WrappedTextLine{Dimensions: containerElement.Dimensions, Line: textElementData.Text})
 			continue
 		}
 		var lineWidth, lineHeight floatn = 0, textElementData.PreferredDimensions.Height
@@ -443,52 +807,73 @@ func (context *Context) calculateFinalLayout() error {
 			lineHeight = floatn(textConfig.LineHeight)
 		}
 		var lineLengthChars, lineStartOffset intn
-		spaceWidth := context.measureSpaceWidth(textConfig)
+		var lineLastWord *measuredWord
+		var lineSpaces intn // Runs of breaking spaces between the words of the line.
+		wrapWidth := containerElement.Dimensions.Width
+		if textConfig.WrapMode != TextWrapWords {
+			wrapWidth = maxfloat // Only shrunk to be shortened by Overflow, break at newlines only.
+		}
 		wordIndex := mtci.measureWordsStartIndex
 		for wordIndex != -1 {
 			if arrlen(context.WrappedTextLines) > arrcap(context.WrappedTextLines)-1 {
 				break
 			}
 			measuredWord := &context.measuredWords[wordIndex]
-			if lineLengthChars == 0 && lineWidth+measuredWord.Width > containerElement.Dimensions.Width {
+			if lineLengthChars == 0 && lineWidth+measuredWord.Width > wrapWidth {
 				// Only word on the line is too large, render it anyway.
-				context.WrappedTextLines = arradd(context.WrappedTextLines, WrappedTextLine{Dimensions: Dimensions{measuredWord.Width, lineHeight}, Line: textElementData.Text[measuredWord.StartOffset : measuredWord.StartOffset+measuredWord.Length]})
-				textElementData.WrappedLines = arrextend(textElementData.WrappedLines, 1)
+				context.addWrappedTextLine(textElementData, WrappedTextLine{
+					Dimensions: Dimensions{measuredWord.Width, lineHeight},
+					Line:       textElementData.Text[measuredWord.StartOffset : measuredWord.StartOffset+measuredWord.Length],
+					offset:     measuredWord.StartOffset,
+				})
 				wordIndex = measuredWord.Next
 				lineStartOffset = measuredWord.StartOffset + measuredWord.Length
-			} else if measuredWord.Length == 0 || lineWidth+measuredWord.Width > containerElement.Dimensions.Width {
+			} else if measuredWord.Length == 0 || lineWidth+measuredWord.Width > wrapWidth {
 				// Wrapped text lines list has overflowed, just render out the line.
-				finalCharIsSpace := textElementData.Text[max(lineStartOffset+lineLengthChars-1, 0)] == ' '
 				width := lineWidth
 				line := textElementData.Text[lineStartOffset : lineStartOffset+lineLengthChars]
-				if finalCharIsSpace {
-					width -= spaceWidth
-					line = line[:len(line)-1]
+				if lineLastWord != nil && lineLengthChars > 0 {
+					// Trailing space of the last word on the line is not rendered.
+					width -= lineLastWord.SpaceWidth
+					line = line[:len(line)-int(lineLastWord.SpaceLength)]
 				}
-				context.WrappedTextLines = arradd(context.WrappedTextLines, WrappedTextLine{
-					Dimensions: Dimensions{Width: width, Height: lineHeight},
-					Line:       line,
+				var wordSpacing floatn
+				if textConfig.TextAlignment == TextAlignJustify && measuredWord.Length != 0 && lineSpaces > 0 && width < wrapWidth {
+					// Line was broken to fit, stretch its spaces to fill the container.
+					wordSpacing = (wrapWidth - width) / floatn(lineSpaces)
+					width = wrapWidth
+				}
+				context.addWrappedTextLine(textElementData, WrappedTextLine{
+					Dimensions:  Dimensions{Width: width, Height: lineHeight},
+					Line:        line,
+					WordSpacing: wordSpacing,
+					offset:      lineStartOffset,
 				})
-				textElementData.WrappedLines = arrextend(textElementData.WrappedLines, 1)
 				if lineLengthChars == 0 || measuredWord.Length == 0 {
 					wordIndex = measuredWord.Next
 				}
 				lineWidth = 0
 				lineLengthChars = 0
+				lineSpaces = 0
 				lineStartOffset = measuredWord.StartOffset
 			} else {
+				if lineLengthChars > 0 && lineLastWord.SpaceLength > 0 {
+					lineSpaces++
+				}
 				lineWidth += measuredWord.Width + floatn(textConfig.LetterSpacing)
 				lineLengthChars += measuredWord.Length
+				lineLastWord = measuredWord
 				wordIndex = measuredWord.Next
 			}
 		}
 		if lineLengthChars > 0 {
-			context.WrappedTextLines = arradd(context.WrappedTextLines, WrappedTextLine{
+			context.addWrappedTextLine(textElementData, WrappedTextLine{
 				Dimensions: Dimensions{Width: lineWidth - floatn(textConfig.LetterSpacing), Height: lineHeight},
 				Line:       textElementData.Text[lineStartOffset : lineStartOffset+lineLengthChars],
+				offset:     lineStartOffset,
 			})
-			textElementData.WrappedLines = arrextend(textElementData.WrappedLines, 1)
 		}
+		context.truncateWrappedLines(textElementData, textConfig, containerElement.Dimensions.Width)
 		containerElement.Dimensions.Height = lineHeight * floatn(arrlen(textElementData.WrappedLines))
 	}
 
@@ -528,19 +913,35 @@ func (context *Context) calculateFinalLayout() error {
 
 		// DFS node has been visited, this is on the way back up to the root.
 		layoutConfig := currentElement.LayoutConfig
-		if layoutConfig.LayoutDirection == LeftToRight {
+		if layoutConfig.LayoutDirection == Grid {
+			// Rows fit the heights of their children.
+			contentHeight := context.sizeGridTracks(currentElement, false, -1, false) + floatn(layoutConfig.Padding.Vertical())
+			currentElement.Dimensions.Height = layoutConfig.Sizing.ClampHeight(contentHeight)
+		} else if layoutConfig.LayoutDirection == LeftToRight {
 			// Resize any parent containers that have grown in height along their non layout axis
 			for j := intn(0); j < arrlen(children); j++ {
 				childElement := &context.LayoutElements[children[j]]
-				childHeightWithPadding := max(childElement.Dimensions.Height+floatn(layoutConfig.Padding.Vertical()), currentElement.Dimensions.Height)
+				childHeightWithPadding := max(childElement.outerSize(false)+floatn(layoutConfig.Padding.Vertical()), currentElement.Dimensions.Height)
 				currentElement.Dimensions.Height = layoutConfig.Sizing.ClampHeight(childHeightWithPadding)
 			}
+			if layoutConfig.wraps() {
+				// Wrapped lines of children stack up across the layout axis.
+				_, crossSize := context.wrapContentSize(currentElement)
+				contentHeight := layoutConfig.Sizing.ClampHeight(crossSize + floatn(layoutConfig.Padding.Vertical()))
+				currentElement.Dimensions.Height = max(contentHeight, currentElement.Dimensions.Height)
+				if clipConfig, _ := currentElement.GetConfig(ElementConfigTypeClip).(*ClipElementConfig); clipConfig == nil || !clipConfig.Vertical {
+					currentElement.MinDimensions.Height = max(contentHeight, currentElement.MinDimensions.Height)
+				}
+			} else if layoutConfig.ChildAlignment.Y == AlignYBaseline {
+				contentHeight := context.baselineHeight(children) + floatn(layoutConfig.Padding.Vertical())
+				currentElement.Dimensions.Height = layoutConfig.Sizing.ClampHeight(max(contentHeight, currentElement.Dimensions.Height))
+			}
 		} else if layoutConfig.LayoutDirection == TopToBottom {
 			// Resizing along the layout axis.
 			contentHeight := floatn(layoutConfig.Padding.Vertical())
 			for j := intn(0); j < arrlen(children); j++ {
 				childElement := &context.LayoutElements[children[j]]
-				contentHeight += childElement.Dimensions.Height
+				contentHeight += childElement.outerSize(false)
 			}
 			contentHeight += max(floatn(len(children)-1), 0) * floatn(layoutConfig.ChildGap)
 			currentElement.Dimensions.Height = layoutConfig.Sizing.ClampHeight(contentHeight)
@@ -573,6 +974,13 @@ func (context *Context) calculateFinalLayout() error {
 
 	// Calculate final positions and generate render commands.
 	context.renderCommands = context.renderCommands[:0]
+	context.renderCommandScissorDepth = 0
+	context.rectangleRenderData = context.rectangleRenderData[:0]
+	context.borderRenderData = context.borderRenderData[:0]
+	context.textRenderData = context.textRenderData[:0]
+	context.imageRenderData = context.imageRenderData[:0]
+	context.clipRenderData = context.clipRenderData[:0]
+	context.customRenderData = context.customRenderData[:0]
 	dfsBuffer = dfsBuffer[:0]
 	for rootIndex := intn(0); rootIndex < arrlen(context.LayoutElementTreeRoots); rootIndex++ {
 		dfsBuffer = dfsBuffer[:0]
@@ -641,7 +1049,7 @@ func (context *Context) calculateFinalLayout() error {
 					}
 				}
 				context.addRenderCommand(RenderCommand{
-					BoundingBox: clipHashMapItem.BoundingBox,
+					BoundingBox: context.clipRect(root.ClipElementID),
 					UserData:    nil,
 					ID:          hashNumber(rootElement.ID, uintn(len(rootElement.Children())+10)).ID,
 					Zindex:      root.Zindex,
@@ -658,6 +1066,7 @@ func (context *Context) calculateFinalLayout() error {
 			children := currentElement.Children()
 			layoutConfig := currentElement.LayoutConfig
 			scrollOffset := Vector2{}
+			childGap := floatn(layoutConfig.ChildGap) // Between children along the layout axis, spread by Distribute.
 
 			// This will only be run a single time for each element in downwards DFS order
 			if !context.TreeNodeVisited[len(dfsBuffer)-1] {
@@ -740,25 +1149,30 @@ func (context *Context) calculateFinalLayout() error {
 						shouldRender = false
 					case ElementConfigTypeClip:
 						renderCommand.CommandType = RenderCommandTypeScissorStart
-						renderCommand.RenderData = &ClipRenderData{
+						renderCommand.BoundingBox = context.clipRect(currentElement.ID)
+						renderCommand.RenderData = storeRenderData(&context.clipRenderData, ClipRenderData{
 							Horizontal: elementConfig.Config.(*ClipElementConfig).Horizontal,
 							Vertical:   elementConfig.Config.(*ClipElementConfig).Vertical,
-						}
+						})
 					case ElementConfigTypeImage:
 						renderCommand.CommandType = RenderCommandTypeImage
-						renderCommand.RenderData = &ImageRenderData{
+						renderCommand.RenderData = storeRenderData(&context.imageRenderData, ImageRenderData{
 							BackgroundColor:  sharedConfig.BackgroundColor,
 							CornerRadius:     sharedConfig.CornerRadius,
 							SourceDimensions: elementConfig.Config.(*ImageElementConfig).SourceDimensions,
 							ImageData:        elementConfig.Config.(*ImageElementConfig).ImageData,
-						}
+						})
 					case ElementConfigTypeText:
 						if !shouldRender {
 							break
 						}
 						shouldRender = false
 						textElementConfig := elementConfig.Config.(*TextElementConfig)
-						textElementData := currentElement.ChildrenOrTextContent.(*TextElementData)
+						textElementData := currentElement.textElementData
+						if len(textElementData.spans) > 0 {
+							context.addRichTextRenderCommands(currentElement, currentElementBoundingBox, textElementConfig, root.Zindex)
+							break
+						}
 						naturalLineHeight := textElementData.PreferredDimensions.Height
 						var finalLineHeight floatn
 						if textElementConfig.LineHeight > 0 {
@@ -768,37 +1182,43 @@ func (context *Context) calculateFinalLayout() error {
 						}
 						lineHeightOffset := (finalLineHeight - naturalLineHeight) / 2
 						yPosition := lineHeightOffset
+						tl := textLayout{data: textElementData, config: textElementConfig, boundingBox: currentElementBoundingBox}
+						tl.bidi = context.resolveBidi(textElementData)
 						for lineIndex := intn(0); lineIndex < arrlen(textElementData.WrappedLines); lineIndex++ {
 							wrappedLine := &textElementData.WrappedLines[lineIndex]
-							if len(wrappedLine.Line) == 0 {
+							if len(wrappedLine.Line) == 0 && !wrappedLine.ellipsis {
 								yPosition += finalLineHeight
 								continue
 							}
-							textOffset := currentElementBoundingBox.Width - wrappedLine.Dimensions.Width
-							if textElementConfig.TextAlignment == TextAlignLeft {
-								textOffset = 0
-							}
-							if textElementConfig.TextAlignment == TextAlignCenter {
-								textOffset /= 2
+							lineX := context.lineX(&tl, wrappedLine)
+							lineID := hashNumber(uintn(lineIndex), currentElement.ID).ID
+							for i, run := range context.textLineRuns(&tl, wrappedLine) {
+								// Lines of bidirectional text are split into runs of a single direction.
+								id := lineID
+								if i > 0 {
+									id = hashNumber(uintn(i), lineID).ID
+								}
+								context.addRenderCommand(RenderCommand{
+									BoundingBox: BoundingBox{
+										Vector2:    Vector2{X: lineX + run.x, Y: currentElementBoundingBox.Y + yPosition},
+										Dimensions: Dimensions{Width: run.width, Height: wrappedLine.Dimensions.Height},
+									},
+									RenderData: storeRenderData(&context.textRenderData, TextRenderData{
+										Contents:      unsafeStrslice(run.text),
+										TextColor:     textElementConfig.TextColor,
+										FontID:        textElementConfig.FontID,
+										FontSize:      textElementConfig.FontSize,
+										LetterSpacing: textElementConfig.LetterSpacing,
+										LineHeight:    textElementConfig.LineHeight,
+										WordSpacing:   wrappedLine.WordSpacing,
+										RightToLeft:   run.rightToLeft,
+									}),
+									UserData:    sharedConfig.UserData,
+									ID:          id,
+									Zindex:      root.Zindex,
+									CommandType: RenderCommandTypeText,
+								})
 							}
-							context.addRenderCommand(RenderCommand{
-								BoundingBox: BoundingBox{
-									Vector2:    Vector2{X: currentElementBoundingBox.X + textOffset, Y: currentElementBoundingBox.Y + yPosition},
-									Dimensions: Dimensions{Width: wrappedLine.Dimensions.Width, Height: wrappedLine.Dimensions.Height},
-								},
-								RenderData: &TextRenderData{
-									Contents:      []byte(wrappedLine.Line),
-									TextColor:     textElementConfig.TextColor,
-									FontID:        textElementConfig.FontID,
-									FontSize:      textElementConfig.FontSize,
-									LetterSpacing: textElementConfig.LetterSpacing,
-									LineHeight:    textElementConfig.LineHeight,
-								},
-								UserData:    sharedConfig.UserData,
-								ID:          hashNumber(uintn(lineIndex), currentElement.ID).ID,
-								Zindex:      root.Zindex,
-								CommandType: RenderCommandTypeText,
-							})
 							yPosition += finalLineHeight
 							if !context.DisableCulling && (currentElementBoundingBox.Y+yPosition > context.LayoutDimensions.Height) {
 								break
@@ -806,11 +1226,12 @@ func (context *Context) calculateFinalLayout() error {
 						}
 					case ElementConfigTypeCustom:
 						renderCommand.CommandType = RenderCommandTypeCustom
-						renderCommand.RenderData = CustomRenderData{
+						renderCommand.RenderData = storeRenderData(&context.customRenderData, CustomRenderData{
 							BackgroundColor: sharedConfig.BackgroundColor,
 							CornerRadius:    sharedConfig.CornerRadius,
-							CustomData:      elementConfig.Config,
-						}
+							CustomData:      elementConfig.Config.(*CustomElementConfig).CustomData,
+						})
+						emitRectangle = false // Background is drawn by the custom renderer.
 					default:
 						return errors.New("unknown command")
 					}
@@ -826,10 +1247,10 @@ func (context *Context) calculateFinalLayout() error {
 				if emitRectangle {
 					context.addRenderCommand(RenderCommand{
 						BoundingBox: currentElementBoundingBox,
-						RenderData: &RectangleRenderData{
+						RenderData: storeRenderData(&context.rectangleRenderData, RectangleRenderData{
 							BackgroundColor: sharedConfig.BackgroundColor,
 							CornerRadius:    sharedConfig.CornerRadius,
-						},
+						}),
 						UserData:    sharedConfig.UserData,
 						ID:          currentElement.ID,
 						Zindex:      root.Zindex,
@@ -841,37 +1262,51 @@ func (context *Context) calculateFinalLayout() error {
 				textconfig, _ := currentElementTreeNode.layoutElement.GetConfig(ElementConfigTypeText).(*TextElementConfig)
 				if textconfig == nil {
 					var contentSize Dimensions
-					if layoutConfig.LayoutDirection == LeftToRight {
+					if layoutConfig.LayoutDirection == Grid {
+						// Children are aligned within their cells as they are positioned.
+						columns, _ := currentElement.gridAxisTracks(true)
+						rows, _ := currentElement.gridAxisTracks(false)
+						contentSize = Dimensions{Width: gridTracksSize(columns, floatn(layoutConfig.ChildGap)), Height: gridTracksSize(rows, floatn(layoutConfig.ChildGap))}
+					} else if layoutConfig.wraps() {
+						// Children are aligned along the layout axis line by line as they are positioned,
+						// the block of lines is aligned across it here.
+						size, crossSize := context.wrapContentSize(currentElement)
+						if layoutConfig.LayoutDirection == LeftToRight {
+							contentSize = Dimensions{Width: size, Height: crossSize}
+							currentElementTreeNode.NextChildOffset.Y += context.wrapLinesOffset(currentElement)
+						} else {
+							contentSize = Dimensions{Width: crossSize, Height: size}
+							currentElementTreeNode.NextChildOffset.X += context.wrapLinesOffset(currentElement)
+						}
+					} else if layoutConfig.LayoutDirection == LeftToRight {
 						for i := intn(0); i < arrlen(children); i++ {
 							childElement := &context.LayoutElements[children[i]]
-							contentSize.Width += childElement.Dimensions.Width
-							contentSize.Height = max(contentSize.Height, childElement.Dimensions.Height)
+							contentSize.Width += childElement.outerSize(true)
+							contentSize.Height = max(contentSize.Height, childElement.outerSize(false))
 						}
 						contentSize.Width += max(floatn(len(children)-1), 0) * floatn(layoutConfig.ChildGap)
 						extraSpace := currentElement.Dimensions.Width - floatn(layoutConfig.Padding.Horizontal()) - contentSize.Width
-						switch layoutConfig.ChildAlignment.X {
-						case AlignXLeft:
-							extraSpace = 0
-						case AlignXCenter:
-							extraSpace /= 2
+						if layoutConfig.Distribute != DistributeNone && extraSpace > 0 {
+							extraSpace, childGap = context.distributedSpace(currentElement, 0, arrlen(children))
+						} else {
+							switch layoutConfig.alignX() {
+							case AlignXLeft:
+								extraSpace = 0
+							case AlignXCenter:
+								extraSpace /= 2
+							}
 						}
 						currentElementTreeNode.NextChildOffset.X += extraSpace
 						extraSpace = max(0, extraSpace)
 					} else {
 						for i := intn(0); i < arrlen(children); i++ {
-							childElement := context.LayoutElements[children[i]]
-							contentSize.Width = max(contentSize.Width, childElement.Dimensions.Width)
-							contentSize.Height += childElement.Dimensions.Height
+							childElement := &context.LayoutElements[children[i]]
+							contentSize.Width = max(contentSize.Width, childElement.outerSize(true))
+							contentSize.Height += childElement.outerSize(false)
 						}
 						contentSize.Height += max(floatn(len(children)-1), 0) * floatn(layoutConfig.ChildGap)
-						extraSpace := currentElement.Dimensions.Height - floatn(layoutConfig.Padding.Vertical()) - contentSize.Height
-						switch layoutConfig.ChildAlignment.Y {
-						case AlignYTop:
-							extraSpace = 0
-						case AlignYCenter:
-							extraSpace /= 2
-						}
-						extraSpace = max(0, extraSpace)
+						var extraSpace floatn
+						extraSpace, childGap = context.alignedSpace(currentElement, 0, arrlen(children))
 						currentElementTreeNode.NextChildOffset.Y += extraSpace
 					}
 					if scrollContainerData != nil {
@@ -886,7 +1321,10 @@ func (context *Context) calculateFinalLayout() error {
 				closeScrollElement := false
 				scrollConfig, _ := currentElement.GetConfig(ElementConfigTypeClip).(*ClipElementConfig)
 				if scrollConfig != nil {
-					closeScrollElement = true
+					// The ScissorStart of offscreen clip elements is culled, so is its ScissorEnd.
+					if hashMapItem := context.HashMapItem(currentElement.ID); hashMapItem != nil {
+						closeScrollElement = !context.IsOffscreen(&hashMapItem.BoundingBox)
+					}
 					for i := intn(0); i < arrlen(context.scrollContainerDatas); i++ {
 						mapping := &context.scrollContainerDatas[i]
 						if mapping.LayoutElement == currentElement {
@@ -908,11 +1346,11 @@ func (context *Context) calculateFinalLayout() error {
 						sharedConfig, _ := currentElement.GetSharedConfig()
 						renderCommand := RenderCommand{
 							BoundingBox: currentElementBoundingBox,
-							RenderData: &BorderRenderData{
+							RenderData: storeRenderData(&context.borderRenderData, BorderRenderData{
 								Color:        borderConfig.Color,
 								CornerRadius: sharedConfig.CornerRadius,
 								Width:        borderConfig.Width,
-							},
+							}),
 							UserData:    sharedConfig.UserData,
 							ID:          hashNumber(currentElement.ID, uintn(len(children))).ID,
 							CommandType: RenderCommandTypeBorder,
@@ -921,43 +1359,121 @@ func (context *Context) calculateFinalLayout() error {
 						if borderConfig.Width.BetweenChildren > 0 && borderConfig.Color.A > 0 {
 							halfGap := floatn(layoutConfig.ChildGap) / 2
 							borderOffset := Vector2{X: floatn(layoutConfig.Padding.Left) - halfGap, Y: floatn(layoutConfig.Padding.Top) - halfGap}
-							if layoutConfig.LayoutDirection == LeftToRight {
+							// Borders of wrapping elements only separate the children of a line and span its size.
+							lineFirst, lineEnd := intn(0), arrlen(children)
+							if layoutConfig.wraps() {
+								lineEnd = 0
+							}
+							var lineOffset, lineSize floatn
+							if layoutConfig.LayoutDirection == Grid {
+								// Borders run between all tracks of grids.
+								columns, _ := currentElement.gridAxisTracks(true)
+								rows, _ := currentElement.gridAxisTracks(false)
+								for i := intn(1); i < arrlen(columns); i++ {
+									x := gridTrackOffset(columns, i, floatn(layoutConfig.ChildGap))
+									if layoutConfig.Reverse {
+										x = gridTracksSize(columns, floatn(layoutConfig.ChildGap)) + floatn(layoutConfig.ChildGap) - x
+									}
+									context.addRenderCommand(RenderCommand{
+										BoundingBox: BoundingBox{
+											Vector2:    Vector2{X: currentElementBoundingBox.X + borderOffset.X + x + scrollOffset.X, Y: currentElementBoundingBox.Y + scrollOffset.Y},
+											Dimensions: Dimensions{Width: floatn(borderConfig.Width.BetweenChildren), Height: currentElement.Dimensions.Height},
+										},
+										RenderData: storeRenderData(&context.rectangleRenderData, RectangleRenderData{
+											BackgroundColor: borderConfig.Color,
+										}),
+										UserData:    sharedConfig.UserData,
+										ID:          hashNumber(currentElement.ID, uintn(arrlen(children)+1+i)).ID,
+										CommandType: RenderCommandTypeRectangle,
+									})
+								}
+								for i := intn(1); i < arrlen(rows); i++ {
+									context.addRenderCommand(RenderCommand{
+										BoundingBox: BoundingBox{
+											Vector2:    Vector2{X: currentElementBoundingBox.X + scrollOffset.X, Y: currentElementBoundingBox.Y + borderOffset.Y + gridTrackOffset(rows, i, floatn(layoutConfig.ChildGap)) + scrollOffset.Y},
+											Dimensions: Dimensions{Width: currentElement.Dimensions.Width, Height: floatn(borderConfig.Width.BetweenChildren)},
+										},
+										RenderData: storeRenderData(&context.rectangleRenderData, RectangleRenderData{
+											BackgroundColor: borderConfig.Color,
+										}),
+										UserData:    sharedConfig.UserData,
+										ID:          hashNumber(currentElement.ID, uintn(arrlen(children)+arrlen(columns)+i)).ID,
+										CommandType: RenderCommandTypeRectangle,
+									})
+								}
+							} else if layoutConfig.LayoutDirection == LeftToRight {
+								offset, gap := context.alignedSpace(currentElement, 0, arrlen(children))
+								borderOffset.X = floatn(layoutConfig.Padding.Left) + offset - gap/2
 								for i := intn(0); i < arrlen(children); i++ {
-									childElement := &context.LayoutElements[children[i]]
-									if i > 0 {
+									lineStart := layoutConfig.wraps() && i == lineEnd
+									if lineStart {
+										lineFirst = i
+										if i > 0 {
+											lineOffset += lineSize + floatn(layoutConfig.ChildGap)
+										} else {
+											lineOffset = floatn(layoutConfig.Padding.Top)
+										}
+										lineEnd, _, lineSize = context.wrapLineEnd(currentElement, i)
+										offset, gap = context.alignedSpace(currentElement, i, lineEnd)
+										borderOffset.X = floatn(layoutConfig.Padding.Left) + offset - gap/2
+									}
+									childElement := &context.LayoutElements[children[currentElement.placedChild(i, lineFirst, lineEnd)]]
+									if i > 0 && !lineStart {
+										y, height := floatn(0), currentElement.Dimensions.Height
+										if layoutConfig.wraps() {
+											y, height = lineOffset, lineSize
+										}
 										context.addRenderCommand(RenderCommand{
 											BoundingBox: BoundingBox{
-												Vector2:    Vector2{X: currentElementBoundingBox.X + borderOffset.X + scrollOffset.X, Y: currentElementBoundingBox.Y + scrollOffset.Y},
-												Dimensions: Dimensions{Width: floatn(borderConfig.Width.BetweenChildren), Height: currentElement.Dimensions.Height},
+												Vector2:    Vector2{X: currentElementBoundingBox.X + borderOffset.X + scrollOffset.X, Y: currentElementBoundingBox.Y + y + scrollOffset.Y},
+												Dimensions: Dimensions{Width: floatn(borderConfig.Width.BetweenChildren), Height: height},
 											},
-											RenderData: &RectangleRenderData{
+											RenderData: storeRenderData(&context.rectangleRenderData, RectangleRenderData{
 												BackgroundColor: borderConfig.Color,
-											},
+											}),
 											UserData:    sharedConfig.UserData,
 											ID:          hashNumber(currentElement.ID, uintn(arrlen(children)+1+i)).ID,
 											CommandType: RenderCommandTypeRectangle,
 										})
 									}
-									borderOffset.X += childElement.Dimensions.Width + floatn(layoutConfig.ChildGap)
+									borderOffset.X += childElement.outerSize(true) + gap
 								}
 							} else {
+								offset, gap := context.alignedSpace(currentElement, 0, arrlen(children))
+								borderOffset.Y = floatn(layoutConfig.Padding.Top) + offset - gap/2
 								for i := intn(0); i < arrlen(children); i++ {
-									childElement := &context.LayoutElements[children[i]]
-									if i > 0 {
+									lineStart := layoutConfig.wraps() && i == lineEnd
+									if lineStart {
+										lineFirst = i
+										if i > 0 {
+											lineOffset += lineSize + floatn(layoutConfig.ChildGap)
+										} else {
+											lineOffset = floatn(layoutConfig.Padding.Left)
+										}
+										lineEnd, _, lineSize = context.wrapLineEnd(currentElement, i)
+										offset, gap = context.alignedSpace(currentElement, i, lineEnd)
+										borderOffset.Y = floatn(layoutConfig.Padding.Top) + offset - gap/2
+									}
+									childElement := &context.LayoutElements[children[currentElement.placedChild(i, lineFirst, lineEnd)]]
+									if i > 0 && !lineStart {
+										x, width := floatn(0), currentElement.Dimensions.Width
+										if layoutConfig.wraps() {
+											x, width = lineOffset, lineSize
+										}
 										context.addRenderCommand(RenderCommand{
 											BoundingBox: BoundingBox{
-												Vector2:    Vector2{X: currentElementBoundingBox.X + scrollOffset.X, Y: currentElementBoundingBox.Y + borderOffset.Y + scrollOffset.Y},
-												Dimensions: Dimensions{Width: currentElement.Dimensions.Width, Height: floatn(borderConfig.Width.BetweenChildren)},
+												Vector2:    Vector2{X: currentElementBoundingBox.X + x + scrollOffset.X, Y: currentElementBoundingBox.Y + borderOffset.Y + scrollOffset.Y},
+												Dimensions: Dimensions{Width: width, Height: floatn(borderConfig.Width.BetweenChildren)},
 											},
-											RenderData: &RectangleRenderData{
+											RenderData: storeRenderData(&context.rectangleRenderData, RectangleRenderData{
 												BackgroundColor: borderConfig.Color,
-											},
+											}),
 											UserData:    sharedConfig.UserData,
 											ID:          hashNumber(currentElement.ID, uintn(arrlen(children)+1+i)).ID,
 											CommandType: RenderCommandTypeRectangle,
 										})
 									}
-									borderOffset.Y += childElement.Dimensions.Height + floatn(layoutConfig.ChildGap)
+									borderOffset.Y += childElement.outerSize(false) + gap
 								}
 							}
 						}
@@ -966,6 +1482,7 @@ func (context *Context) calculateFinalLayout() error {
 				// This exists because the scissor needs to end _after_ borders between elements
 				if closeScrollElement {
 					context.addRenderCommand(RenderCommand{
+						BoundingBox: context.clipRect(currentElement.ID),
 						ID:          hashNumber(currentElement.ID, uintn(arrlen(children)+11)).ID,
 						CommandType: RenderCommandTypeScissorEnd,
 					})
@@ -978,26 +1495,90 @@ func (context *Context) calculateFinalLayout() error {
 			// Add children to the DFS buffer.
 			textConfig := currentElement.GetConfig(ElementConfigTypeText)
 			if textConfig == nil {
+				var baseline floatn
+				if layoutConfig.LayoutDirection == LeftToRight && layoutConfig.ChildAlignment.Y == AlignYBaseline {
+					baseline = context.childrenBaseline(children)
+				}
+				// Offset and size across the layout axis of the current line of children of a wrapping element.
+				lineFirst, lineEnd := intn(0), arrlen(children)
+				var lineOffset, lineCrossSize floatn
+				if layoutConfig.LayoutDirection == LeftToRight {
+					lineOffset = currentElementTreeNode.NextChildOffset.Y
+				} else {
+					lineOffset = currentElementTreeNode.NextChildOffset.X
+				}
+				if layoutConfig.wraps() {
+					lineEnd = 0
+				}
 				dfsBuffer = dfsBuffer[:len(dfsBuffer)+len(children)]
+				// Children are positioned in the order they are placed along the layout axis.
 				for i := intn(0); i < arrlen(children); i++ {
-					childElement := &context.LayoutElements[children[i]]
+					if layoutConfig.wraps() && i == lineEnd {
+						// Start a new line, aligned along the layout axis on its own.
+						if i > 0 {
+							lineOffset += lineCrossSize + floatn(layoutConfig.ChildGap)
+						}
+						var lineSize floatn
+						lineFirst = i
+						lineEnd, lineSize, lineCrossSize = context.wrapLineEnd(currentElement, i)
+						if layoutConfig.LayoutDirection == LeftToRight {
+							extraSpace := currentElement.Dimensions.Width - floatn(layoutConfig.Padding.Horizontal()) - lineSize
+							childGap = floatn(layoutConfig.ChildGap)
+							if layoutConfig.Distribute != DistributeNone && extraSpace > 0 {
+								extraSpace, childGap = context.distributedSpace(currentElement, i, lineEnd)
+							} else {
+								switch layoutConfig.alignX() {
+								case AlignXLeft:
+									extraSpace = 0
+								case AlignXCenter:
+									extraSpace /= 2
+								}
+							}
+							currentElementTreeNode.NextChildOffset.X = floatn(layoutConfig.Padding.Left) + extraSpace
+							if layoutConfig.ChildAlignment.Y == AlignYBaseline {
+								baseline = context.childrenBaseline(children[i:lineEnd])
+							}
+						} else {
+							var extraSpace floatn
+							extraSpace, childGap = context.alignedSpace(currentElement, i, lineEnd)
+							currentElementTreeNode.NextChildOffset.Y = floatn(layoutConfig.Padding.Top) + extraSpace
+						}
+					}
+					childIndex := currentElement.placedChild(i, lineFirst, lineEnd)
+					childElement := &context.LayoutElements[children[childIndex]]
 					// Alignment along non-layout axis.
-					if layoutConfig.LayoutDirection == LeftToRight {
-						currentElementTreeNode.NextChildOffset.Y = floatn(currentElement.LayoutConfig.Padding.Top)
-						whiteSpaceAroundChild := currentElement.Dimensions.Height - floatn(layoutConfig.Padding.Vertical()) - childElement.Dimensions.Height
-						switch layoutConfig.ChildAlignment.Y {
-						case AlignYTop:
-						case AlignYCenter:
-							currentElementTreeNode.NextChildOffset.Y += whiteSpaceAroundChild / 2
-						case AlignYBottom:
-							currentElementTreeNode.NextChildOffset.Y += whiteSpaceAroundChild
-						default:
-							panic("invalid Y alignment")
+					margin := childElement.LayoutConfig.Margin
+					if layoutConfig.LayoutDirection == Grid {
+						x, width := context.gridCell(currentElement, childElement, true)
+						y, height := context.gridCell(currentElement, childElement, false)
+						if columns, _ := currentElement.gridAxisTracks(true); layoutConfig.Reverse && columns != nil {
+							x = gridTracksSize(columns, floatn(layoutConfig.ChildGap)) - x - width
 						}
+						currentElementTreeNode.NextChildOffset = Vector2{
+							X: floatn(layoutConfig.Padding.Left) + x + floatn(margin.Left),
+							Y: context.gridChildY(currentElement, childElement, y, height),
+						}
+						switch childElement.LayoutConfig.Self.alignX(layoutConfig.ChildAlignment.X, childElement.mirrored) {
+						case AlignXCenter:
+							currentElementTreeNode.NextChildOffset.X += (width - childElement.outerSize(true)) / 2
+						case AlignXRight:
+							currentElementTreeNode.NextChildOffset.X += width - childElement.outerSize(true)
+						}
+					} else if layoutConfig.LayoutDirection == LeftToRight {
+						lineTop, lineSize := floatn(layoutConfig.Padding.Top), currentElement.Dimensions.Height-floatn(layoutConfig.Padding.Vertical())
+						if layoutConfig.wraps() {
+							lineTop, lineSize = lineOffset, lineCrossSize
+						}
+						currentElementTreeNode.NextChildOffset.Y = context.rowChildY(currentElement, childElement, lineTop, lineSize, baseline)
 					} else {
 						currentElementTreeNode.NextChildOffset.X = floatn(currentElement.LayoutConfig.Padding.Left)
-						whiteSpaceAroundChild := currentElement.Dimensions.Width - floatn(layoutConfig.Padding.Horizontal()) - childElement.Dimensions.Width
-						switch layoutConfig.ChildAlignment.X {
+						whiteSpaceAroundChild := currentElement.Dimensions.Width - floatn(layoutConfig.Padding.Horizontal()) - childElement.outerSize(true)
+						if layoutConfig.wraps() {
+							currentElementTreeNode.NextChildOffset.X = lineOffset
+							whiteSpaceAroundChild = lineCrossSize - childElement.outerSize(true)
+						}
+						currentElementTreeNode.NextChildOffset.X += floatn(margin.Left)
+						switch childElement.LayoutConfig.Self.alignX(layoutConfig.ChildAlignment.X, childElement.mirrored) {
 						case AlignXLeft:
 						case AlignXCenter:
 							currentElementTreeNode.NextChildOffset.X += whiteSpaceAroundChild / 2
@@ -1007,13 +1588,19 @@ func (context *Context) calculateFinalLayout() error {
 							panic("invalid X alignment")
 						}
 					}
+					// Margin before the child along the layout axis.
+					if layoutConfig.LayoutDirection == LeftToRight {
+						currentElementTreeNode.NextChildOffset.X += floatn(margin.Left)
+					} else if layoutConfig.LayoutDirection == TopToBottom {
+						currentElementTreeNode.NextChildOffset.Y += floatn(margin.Top)
+					}
 					childPosition := Vector2{
 						X: currentElementTreeNode.position.X + currentElementTreeNode.NextChildOffset.X + scrollOffset.X,
 						Y: currentElementTreeNode.position.Y + currentElementTreeNode.NextChildOffset.Y + scrollOffset.Y,
 					}
 
 					// DFS buffer elements need to be added in reverse because stack traversal happens backwards
-					newNodeIndex := uintn(arrlen(dfsBuffer) - 1 - i)
+					newNodeIndex := uintn(arrlen(dfsBuffer) - 1 - childIndex)
 					dfsBuffer[newNodeIndex] = layoutElementTreeNode{
 						layoutElement:   childElement,
 						position:        childPosition,
@@ -1023,17 +1610,18 @@ func (context *Context) calculateFinalLayout() error {
 
 					// Update parent offsets.
 					if layoutConfig.LayoutDirection == LeftToRight {
-						currentElementTreeNode.NextChildOffset.X += childElement.Dimensions.Width + floatn(layoutConfig.ChildGap)
+						currentElementTreeNode.NextChildOffset.X += childElement.Dimensions.Width + floatn(margin.Right) + childGap
 					} else {
-						currentElementTreeNode.NextChildOffset.Y += childElement.Dimensions.Height + floatn(layoutConfig.ChildGap)
+						currentElementTreeNode.NextChildOffset.Y += childElement.Dimensions.Height + floatn(margin.Bottom) + childGap
 					}
 				}
 			}
 		}
 
-		if root.ClipElementID != 0 {
+		if root.ClipElementID != 0 && context.HashMapItem(root.ClipElementID) != nil {
 			rootChildren := rootElement.Children()
 			context.addRenderCommand(RenderCommand{
+				BoundingBox: context.clipRect(root.ClipElementID),
 				ID:          hashNumber(rootElement.ID, uintn(len(rootChildren)+11)).ID,
 				CommandType: RenderCommandTypeScissorEnd,
 			})
@@ -1042,13 +1630,48 @@ func (context *Context) calculateFinalLayout() error {
 	return nil
 }
 
-func (context *Context) addRenderCommand(cmd RenderCommand) error {
-	if arrfree(context.renderCommands) > 0 {
-		context.renderCommands = arradd(context.renderCommands, cmd)
-	} else {
-		panic("clay ran out of capacity for commands; try setMaxElementCount with higher value")
+// storeRenderData stores v in pool for the current layout and returns a pointer to it.
+// Pools have the capacity of the render command list so a full pool implies
+// addRenderCommand discards the command the data is meant for.
+func storeRenderData[T any](pool *[]T, v T) *T {
+	if arrfree(*pool) == 0 {
+		return nil
 	}
-	return nil
+	*pool = arradd(*pool, v)
+	return arrlast(*pool)
+}
+
+// addWrappedTextLine appends line to the wrapped lines of a text element. Lines beyond capacity are discarded.
+func (context *Context) addWrappedTextLine(textElementData *TextElementData, line WrappedTextLine) {
+	if arrfree(context.WrappedTextLines) == 0 {
+		context.elementsCapacityExceeded()
+		return
+	}
+	context.WrappedTextLines = arradd(context.WrappedTextLines, line)
+	textElementData.WrappedLines = arrextend(textElementData.WrappedLines, 1)
+}
+
+// addRenderCommand appends cmd to the render commands. Once capacity runs out further commands are discarded,
+// except for ScissorEnd commands which always have room reserved so that every ScissorStart is matched.
+func (context *Context) addRenderCommand(cmd RenderCommand) {
+	switch {
+	case cmd.CommandType == RenderCommandTypeScissorEnd:
+		if context.renderCommandScissorDepth == 0 {
+			return // Matching ScissorStart was discarded.
+		}
+		context.renderCommandScissorDepth--
+	case context.booleanWarnings.maxRenderCommandsExceeded ||
+		(cmd.CommandType == RenderCommandTypeScissorStart && arrfree(context.renderCommands) < context.renderCommandScissorDepth+2) ||
+		arrfree(context.renderCommands) <= context.renderCommandScissorDepth:
+		if !context.booleanWarnings.maxRenderCommandsExceeded {
+			context.addWarning(WarningElementsCapacityExceeded, "")
+		}
+		context.booleanWarnings.maxRenderCommandsExceeded = true
+		return
+	case cmd.CommandType == RenderCommandTypeScissorStart:
+		context.renderCommandScissorDepth++
+	}
+	context.renderCommands = arradd(context.renderCommands, cmd)
 }
 
 func (context *Context) sizeContainersAlongAxis(xaxis bool) error {
@@ -1062,8 +1685,8 @@ func (context *Context) sizeContainersAlongAxis(xaxis bool) error {
 
 		if floatingCfg, _ := rootElement.GetConfig(ElementConfigTypeFloating).(*FloatingElementConfig); floatingCfg != nil {
 			// Size floating containers to their parents.
-			parentItem := context.HashMapItem(floatingCfg.ParentID)
-			if parentItem != nil && !parentItem.isdefault() {
+			parentItem := context.declaredHashMapItem(floatingCfg.ParentID)
+			if parentItem != nil {
 				parentLayoutElement := parentItem.LayoutElement
 				switch rootElement.LayoutConfig.Sizing.Width.Type {
 				case SizingGrow:
@@ -1098,16 +1721,13 @@ func (context *Context) sizeContainersAlongAxis(xaxis bool) error {
 				childElementIndex := children[childOffset]
 				childElement := &context.LayoutElements[childElementIndex]
 				childSizing := childElement.LayoutConfig.Sizing.SizingAxis(xaxis)
-				childSize := childElement.Dimensions.SizeAxis(xaxis)
-				textcfg, hasTxt := childElement.GetConfig(ElementConfigTypeText).(*TextElementConfig)
+				childSize := childElement.outerSize(xaxis)
+				_, hasTxt := childElement.GetConfig(ElementConfigTypeText).(*TextElementConfig)
 				if !hasTxt && len(childElement.Children()) > 0 {
 					// Child is not text element with 1+ children.
 					bfs = arradd(bfs, childElementIndex)
 				}
-				if childSizing.Type != SizingPercent &&
-					childSizing.Type != SizingFixed &&
-					(textcfg == nil || textcfg.WrapMode == TextWrapWords) &&
-					(xaxis || childElement.GetConfig(ElementConfigTypeImage) == nil) {
+				if childElement.resizableAlongAxis(xaxis) {
 					resizableContainerBuffer = arradd(resizableContainerBuffer, childElementIndex)
 				}
 
@@ -1121,7 +1741,10 @@ func (context *Context) sizeContainersAlongAxis(xaxis bool) error {
 					if childOffset > 0 {
 						// For children after index 0, the childAxisOffset is the gap from the previous child
 						innerContentSize += parentChildGap
-						totalPaddingAndChildGaps += parentChildGap
+						if !parentStyleConfig.wraps() {
+							// Percentages of the children of wrapping elements are of a whole line.
+							totalPaddingAndChildGaps += parentChildGap
+						}
 					}
 				} else {
 					innerContentSize = max(childSize, innerContentSize)
@@ -1135,131 +1758,88 @@ func (context *Context) sizeContainersAlongAxis(xaxis bool) error {
 				childSizing := childElement.LayoutConfig.Sizing.SizingAxis(xaxis)
 				childSize := childElement.Dimensions.SizeAxisPtr(xaxis)
 				if childSizing.Type == SizingPercent {
-					*childSize = (parentSize - totalPaddingAndChildGaps) * childSizing.Percent
+					*childSize = (parentSize - totalPaddingAndChildGaps - childElement.LayoutConfig.Margin.SizeAxis(xaxis)) * childSizing.Percent
 					if sizingAlongAxis {
-						innerContentSize += *childSize
+						innerContentSize += childElement.outerSize(xaxis)
 					}
 					childElement.UpdateAspectRatioBox()
 				}
 			}
 
-			if sizingAlongAxis {
+			scrollCfg, _ := parent.GetConfig(ElementConfigTypeClip).(*ClipElementConfig)
+			// If the parent can scroll in the axis direction, don't compress children, just leave them alone.
+			scrollsAlongAxis := scrollCfg != nil && ((xaxis && scrollCfg.Horizontal) || (!xaxis && scrollCfg.Vertical))
+			if parentStyleConfig.LayoutDirection == Grid {
+				context.sizeGrid(parent, xaxis)
+			} else if sizingAlongAxis && parentStyleConfig.wraps() {
+				// Size each line of children as if it were the only one.
+				context.breakWrapLines(parent, parentSize-parentPadding)
+				for start := intn(0); start < arrlen(children); {
+					end, lineSize, _ := context.wrapLineEnd(parent, start)
+					resizableContainerBuffer = resizableContainerBuffer[:0]
+					for _, child := range children[start:end] {
+						if context.LayoutElements[child].resizableAlongAxis(xaxis) {
+							resizableContainerBuffer = arradd(resizableContainerBuffer, child)
+						}
+					}
+					sizeToDistribute := parentSize - parentPadding - lineSize
+					if sizeToDistribute < 0 && !scrollsAlongAxis {
+						context.compressContainers(resizableContainerBuffer, sizeToDistribute, xaxis)
+					} else if sizeToDistribute > 0 {
+						context.growContainers(resizableContainerBuffer, sizeToDistribute, xaxis)
+					}
+					start = end
+				}
+				if !xaxis && parentStyleConfig.Sizing.Width.Type != SizingPercent {
+					// Columns of children are known once heights are, widen the element to fit them.
+					_, crossSize := context.wrapContentSize(parent)
+					contentWidth := parentStyleConfig.Sizing.ClampWidth(crossSize + floatn(parentStyleConfig.Padding.Horizontal()))
+					parent.Dimensions.Width = max(parent.Dimensions.Width, contentWidth)
+					if scrollCfg == nil || !scrollCfg.Horizontal {
+						parent.MinDimensions.Width = max(parent.MinDimensions.Width, contentWidth)
+					}
+				}
+			} else if sizingAlongAxis {
 				sizeToDistribute := parentSize - parentPadding - innerContentSize
 				// The content is too large, compress children as much as possible.
 				if sizeToDistribute < 0 {
-					scrollCfg, hasScrollConfig := parent.GetConfig(ElementConfigTypeClip).(*ClipElementConfig)
-					if hasScrollConfig {
-						if (xaxis && scrollCfg.Horizontal) || (!xaxis && scrollCfg.Vertical) {
-							// If the parent can scroll in the axis direction in this direction, don't compress children, just leave them alone.
-							continue
-						}
-					}
-
-					// Scrolling containers preferentially compress before others.
-					for sizeToDistribute < -eps && arrlen(resizableContainerBuffer) > 0 {
-						var largest, secondLargest, widthToAdd floatn = 0, 0, sizeToDistribute
-						for childIndex := intn(0); childIndex < arrlen(resizableContainerBuffer); childIndex++ {
-							child := &context.LayoutElements[resizableContainerBuffer[childIndex]]
-							childSize := child.Dimensions.SizeAxis(xaxis)
-							if floatequal(childSize, largest) {
-								continue
-							}
-							if childSize > largest {
-								secondLargest = largest
-								largest = childSize
-							}
-							if childSize < largest {
-								secondLargest = max(secondLargest, childSize)
-								widthToAdd = secondLargest - largest
-							}
-						}
-						widthToAdd = max(widthToAdd, sizeToDistribute/floatn(len(resizableContainerBuffer)))
-
-						for childIndex := intn(0); childIndex < arrlen(resizableContainerBuffer); childIndex++ {
-							child := &context.LayoutElements[resizableContainerBuffer[childIndex]]
-							childSize := child.Dimensions.SizeAxisPtr(xaxis)
-							minSize := child.MinDimensions.SizeAxis(xaxis)
-							previousWidth := *childSize
-							if floatequal(*childSize, largest) {
-								*childSize += widthToAdd
-								if *childSize <= minSize {
-									*childSize = minSize
-									resizableContainerBuffer = arrremoveswapback(resizableContainerBuffer, childIndex)
-									childIndex--
-								}
-								sizeToDistribute -= *childSize - previousWidth
-							}
-						}
+					if scrollsAlongAxis {
+						continue
 					}
+					context.compressContainers(resizableContainerBuffer, sizeToDistribute, xaxis)
 				} else if sizeToDistribute > 0 && growContainerCount > 0 {
 					// Content is too small, allow SizingGrow containers to expand.
-					for childIndex := intn(0); childIndex < arrlen(resizableContainerBuffer); childIndex++ {
-						child := &context.LayoutElements[resizableContainerBuffer[childIndex]]
-						childSizing := child.LayoutConfig.Sizing.SizingAxis(xaxis).Type
-						if childSizing != SizingGrow {
-							resizableContainerBuffer = arrremoveswapback(resizableContainerBuffer, childIndex)
-							childIndex--
-						}
-					}
-					for sizeToDistribute > eps && len(resizableContainerBuffer) > 0 {
-						smallest := maxfloat
-						secondSmallest := maxfloat
-						widthToAdd := sizeToDistribute
-						for childIndex := intn(0); childIndex < arrlen(resizableContainerBuffer); childIndex++ {
-							child := &context.LayoutElements[resizableContainerBuffer[childIndex]]
-							childSize := child.Dimensions.SizeAxis(xaxis)
-							if floatequal(childSize, smallest) {
-								continue
-							} else if childSize < smallest {
-								secondSmallest = smallest
-								smallest = childSize
-							}
-							if childSize > smallest {
-								secondSmallest = min(secondSmallest, childSize)
-								widthToAdd = secondSmallest - smallest
-							}
-						}
-						widthToAdd = min(widthToAdd, sizeToDistribute/floatn(len(resizableContainerBuffer)))
-						for childIndex := intn(0); childIndex < arrlen(resizableContainerBuffer); childIndex++ {
-							child := &context.LayoutElements[resizableContainerBuffer[childIndex]]
-							childSize := child.Dimensions.SizeAxisPtr(xaxis)
-							maxSize := child.LayoutConfig.Sizing.SizingAxis(xaxis).MinMax.Max
-							previousWidth := *childSize
-							if floatequal(*childSize, smallest) {
-								*childSize += widthToAdd
-								if *childSize >= maxSize {
-									*childSize = maxSize
-									resizableContainerBuffer = arrremoveswapback(resizableContainerBuffer, childIndex)
-									childIndex--
-								}
-								sizeToDistribute -= *childSize - previousWidth
-							}
+					context.growContainers(resizableContainerBuffer, sizeToDistribute, xaxis)
+				}
+			} else if parentStyleConfig.wraps() {
+				// Sizing across the lines of a wrapping element, grow containers expand to the size of their line.
+				if xaxis {
+					// Columns are broken again once heights are final, break them with the heights known so far.
+					context.breakWrapLines(parent, parent.Dimensions.Height-floatn(parentStyleConfig.Padding.Vertical()))
+				}
+				for start := intn(0); start < arrlen(children); {
+					end, _, lineCrossSize := context.wrapLineEnd(parent, start)
+					for _, child := range children[start:end] {
+						childElement := &context.LayoutElements[child]
+						if childElement.resizableAlongAxis(xaxis) && (xaxis || childElement.GetConfig(ElementConfigTypeImage) == nil) {
+							childElement.sizeOffAxis(lineCrossSize, xaxis)
 						}
 					}
+					start = end
 				}
-
 			} else {
 				// Sizing along the non-layout axis ("off axis")
 				for childOffset := intn(0); childOffset < arrlen(resizableContainerBuffer); childOffset++ {
 					childElement := &context.LayoutElements[resizableContainerBuffer[childOffset]]
-					childSizing := childElement.LayoutConfig.Sizing.SizingAxis(xaxis)
-					childSize := childElement.Dimensions.SizeAxisPtr(xaxis)
 					if !xaxis && childElement.GetConfig(ElementConfigTypeImage) != nil {
 						continue // Currently we don't support resizing aspect ratio images on the Y axis because it would break the ratio
 					}
 					// If laying out the children of a scroll panel, grow containers to exapnd to the height of the inner content, not outer content.
 					maxSize := parentSize - parentPadding
-					scrollCfg, ok := parent.GetConfig(ElementConfigTypeClip).(*ClipElementConfig)
-					if ok {
-						if (xaxis && scrollCfg.Horizontal) || (!xaxis && scrollCfg.Vertical) {
-							maxSize = max(maxSize, innerContentSize)
-						}
-					}
-					if childSizing.Type == SizingFit {
-						*childSize = max(childSizing.MinMax.Min, min(*childSize, maxSize))
-					} else if childSizing.Type == SizingGrow {
-						*childSize = min(maxSize, childSizing.MinMax.Max)
+					if scrollsAlongAxis {
+						maxSize = max(maxSize, innerContentSize)
 					}
+					childElement.sizeOffAxis(maxSize, xaxis)
 				}
 			}
 		}
@@ -1267,6 +1847,143 @@ func (context *Context) sizeContainersAlongAxis(xaxis bool) error {
 	return nil
 }
 
+// resizableAlongAxis reports whether the size of the element along the axis can be changed by its parent.
+func (le *LayoutElement) resizableAlongAxis(xaxis bool) bool {
+	sizing := le.LayoutConfig.Sizing.SizingAxis(xaxis)
+	textcfg, _ := le.GetConfig(ElementConfigTypeText).(*TextElementConfig)
+	return sizing.Type != SizingPercent &&
+		sizing.Type != SizingFixed &&
+		(textcfg == nil || textcfg.WrapMode == TextWrapWords || textcfg.Overflow != TextOverflowClip) &&
+		(xaxis || le.GetConfig(ElementConfigTypeImage) == nil)
+}
+
+// sizeOffAxis sizes the element across the layout axis of its parent, which has maxSize available for
+// it and its margin.
+func (le *LayoutElement) sizeOffAxis(maxSize floatn, xaxis bool) {
+	maxSize -= le.LayoutConfig.Margin.SizeAxis(xaxis)
+	sizing := le.LayoutConfig.Sizing.SizingAxis(xaxis)
+	size := le.Dimensions.SizeAxisPtr(xaxis)
+	if sizing.Type == SizingFit {
+		*size = max(sizing.MinMax.Min, min(*size, maxSize))
+	} else if sizing.Type == SizingGrow {
+		*size = min(maxSize, sizing.MinMax.Max)
+	}
+}
+
+// compressContainers shrinks the largest of the resizable elements along the axis, down to their
+// minimum size, until sizeToDistribute (negative) is taken up.
+func (context *Context) compressContainers(resizableContainerBuffer []intn, sizeToDistribute floatn, xaxis bool) {
+	// Scrolling containers preferentially compress before others.
+	for sizeToDistribute < -eps && arrlen(resizableContainerBuffer) > 0 {
+		var largest, secondLargest, widthToAdd floatn = 0, 0, sizeToDistribute
+		for childIndex := intn(0); childIndex < arrlen(resizableContainerBuffer); childIndex++ {
+			child := &context.LayoutElements[resizableContainerBuffer[childIndex]]
+			childSize := child.Dimensions.SizeAxis(xaxis)
+			if floatequal(childSize, largest) {
+				continue
+			}
+			if childSize > largest {
+				secondLargest = largest
+				largest = childSize
+			}
+			if childSize < largest {
+				secondLargest = max(secondLargest, childSize)
+				widthToAdd = secondLargest - largest
+			}
+		}
+		widthToAdd = max(widthToAdd, sizeToDistribute/floatn(len(resizableContainerBuffer)))
+
+		for childIndex := intn(0); childIndex < arrlen(resizableContainerBuffer); childIndex++ {
+			child := &context.LayoutElements[resizableContainerBuffer[childIndex]]
+			childSize := child.Dimensions.SizeAxisPtr(xaxis)
+			minSize := child.MinDimensions.SizeAxis(xaxis)
+			previousWidth := *childSize
+			if floatequal(*childSize, largest) {
+				*childSize += widthToAdd
+				if *childSize <= minSize {
+					*childSize = minSize
+					resizableContainerBuffer = arrremoveswapback(resizableContainerBuffer, childIndex)
+					childIndex--
+				}
+				sizeToDistribute -= *childSize - previousWidth
+			}
+		}
+	}
+}
+
+// growContainers expands the smallest of the SizingGrow elements among the resizable elements along
+// the axis, up to their maximum size, until sizeToDistribute is taken up.
+func (context *Context) growContainers(resizableContainerBuffer []intn, sizeToDistribute floatn, xaxis bool) {
+	for childIndex := intn(0); childIndex < arrlen(resizableContainerBuffer); childIndex++ {
+		child := &context.LayoutElements[resizableContainerBuffer[childIndex]]
+		childSizing := child.LayoutConfig.Sizing.SizingAxis(xaxis).Type
+		if childSizing != SizingGrow {
+			resizableContainerBuffer = arrremoveswapback(resizableContainerBuffer, childIndex)
+			childIndex--
+		}
+	}
+	for sizeToDistribute > eps && len(resizableContainerBuffer) > 0 {
+		smallest := maxfloat
+		secondSmallest := maxfloat
+		widthToAdd := sizeToDistribute
+		for childIndex := intn(0); childIndex < arrlen(resizableContainerBuffer); childIndex++ {
+			child := &context.LayoutElements[resizableContainerBuffer[childIndex]]
+			childSize := child.Dimensions.SizeAxis(xaxis)
+			if floatequal(childSize, smallest) {
+				continue
+			} else if childSize < smallest {
+				secondSmallest = smallest
+				smallest = childSize
+			}
+			if childSize > smallest {
+				secondSmallest = min(secondSmallest, childSize)
+				widthToAdd = secondSmallest - smallest
+			}
+		}
+		widthToAdd = min(widthToAdd, sizeToDistribute/floatn(len(resizableContainerBuffer)))
+		for childIndex := intn(0); childIndex < arrlen(resizableContainerBuffer); childIndex++ {
+			child := &context.LayoutElements[resizableContainerBuffer[childIndex]]
+			childSize := child.Dimensions.SizeAxisPtr(xaxis)
+			maxSize := child.LayoutConfig.Sizing.SizingAxis(xaxis).MinMax.Max
+			previousWidth := *childSize
+			if floatequal(*childSize, smallest) {
+				*childSize += widthToAdd
+				if *childSize >= maxSize {
+					*childSize = maxSize
+					resizableContainerBuffer = arrremoveswapback(resizableContainerBuffer, childIndex)
+					childIndex--
+				}
+				sizeToDistribute -= *childSize - previousWidth
+			}
+		}
+	}
+}
+
+// clipRect returns the effective scissor rectangle of the clip element with the given ID,
+// which is its bounding box intersected with the bounding boxes of all of its clip ancestors.
+// It is empty for clip elements not declared in the current layout, so it is only meaningful
+// from calculateFinalLayout until the next BeginLayout.
+func (context *Context) clipRect(clipElementID uintn) BoundingBox {
+	item := context.declaredHashMapItem(clipElementID)
+	if item == nil {
+		return BoundingBox{}
+	}
+	rect := item.BoundingBox
+	for {
+		clipElementID = uintn(context.LayoutElementClipElementIDs[context.layoutElementIndex(item.LayoutElement)])
+		if item = context.declaredHashMapItem(clipElementID); item == nil {
+			return rect
+		}
+		rect = rect.intersect(item.BoundingBox)
+	}
+}
+
+// layoutElementIndex returns the index of element, which must point into LayoutElements.
+func (context *Context) layoutElementIndex(element *LayoutElement) intn {
+	offset := uintptr(unsafe.Pointer(element)) - uintptr(unsafe.Pointer(unsafe.SliceData(context.LayoutElements)))
+	return intn(offset / unsafe.Sizeof(LayoutElement{}))
+}
+
 func (context *Context) IsOffscreen(boundingBox *BoundingBox) bool {
 	if context.DisableCulling {
 		return false
@@ -1316,11 +2033,16 @@ func (context *Context) attachElementConfig(config any) *ElementConfig {
 	return context.rawAttachElementConfig(context.openLayoutElement(), config)
 }
 
+// rawAttachElementConfig attaches config to the open element. It returns nil if there is no capacity left for the configuration.
 func (context *Context) rawAttachElementConfig(openLayoutElement *LayoutElement, config any) *ElementConfig {
 	Type := GetElementConfigType(config)
 	if openLayoutElement.GetConfig(Type) != nil {
 		panic("element already has type")
 	}
+	if arrfree(context.ElementConfigs) == 0 {
+		context.elementsCapacityExceeded()
+		return nil
+	}
 	context.ElementConfigs = arradd(context.ElementConfigs, ElementConfig{
 		Type:   Type,
 		Config: config,
@@ -1354,32 +2076,125 @@ func (context *Context) attachID(id ElementID) {
 	context.LayoutElementIDStrings = arradd(context.LayoutElementIDStrings, id.StringID)
 }
 
+// GetElementData returns the bounding box last calculated for the element with the given ID.
+// While a layout is being declared this is the bounding box from the previous layout.
+// Found is false if the element was not declared in the current or previous layout.
+func (context *Context) GetElementData(id ElementID) ElementData {
+	item := context.HashMapItem(id.ID)
+	if item == nil {
+		return ElementData{}
+	}
+	return ElementData{
+		BoundingBox: item.BoundingBox,
+		Found:       true,
+	}
+}
+
 func (context *Context) HashMapItem(id uintn) *LayoutElementHashMapItem {
-	item, ok := context.GoHash[id]
-	if !ok {
-		if id != 0 {
-			println("item not found in hash map")
-		}
+	itemIndex := context.layoutElementHashMap[context.hashMapSlot(id)]
+	if itemIndex == 0 {
+		return nil
+	}
+	return &context.layoutElementHashMapInternal[itemIndex-1]
+}
+
+// declaredHashMapItem returns the hash map item of an element declared in the current layout.
+// Items retained from the previous layout are not returned since their LayoutElement is stale.
+func (context *Context) declaredHashMapItem(id uintn) *LayoutElementHashMapItem {
+	item := context.HashMapItem(id)
+	if item == nil || item.Generation != context.Generation+1 {
 		return nil
 	}
 	return item
 }
 
 func (context *Context) AddHashMapItem(elementID ElementID, layoutElem *LayoutElement, idAlias uintn) *LayoutElementHashMapItem {
-	id := elementID.ID
-	existingID, existing := context.GoHash[id]
-	if existing {
-		println(context.Generation, "an element with this ID already existed "+elementID.StringID+"; "+existingID.ElementID.StringID)
+	slot := context.hashMapSlot(elementID.ID)
+	if itemIndex := context.layoutElementHashMap[slot]; itemIndex != 0 {
+		existingItem := &context.layoutElementHashMapInternal[itemIndex-1]
+		if existingItem.Generation <= context.Generation {
+			// First collision, item was declared in the previous layout. Assume this is the same element.
+			existingItem.ElementID = elementID
+			existingItem.Generation = context.Generation + 1
+			existingItem.LayoutElement = layoutElem
+			existingItem.IDAlias = idAlias
+			existingItem.OnHover = nil
+			existingItem.OnHoverUserData = nil
+			existingItem.debugData.collision = false
+		} else {
+			// Multiple collisions this frame, two elements have the same ID.
+			context.addWarning(WarningDuplicateID, elementID.StringID)
+			if context.DebugModeEnabled {
+				existingItem.debugData.collision = true
+			}
+		}
+		return existingItem
 	}
-	v := &LayoutElementHashMapItem{
+	item := LayoutElementHashMapItem{
 		Generation:    context.Generation + 1,
 		ElementID:     elementID,
 		LayoutElement: layoutElem,
 		IDAlias:       idAlias,
-		NextIndex:     -1,
 	}
-	context.GoHash[id] = v
-	return v
+	var itemIndex intn
+	if len(context.layoutElementHashMapFreelist) > 0 {
+		context.layoutElementHashMapFreelist, itemIndex = arrpop(context.layoutElementHashMapFreelist)
+		context.layoutElementHashMapInternal[itemIndex] = item
+	} else if arrfree(context.layoutElementHashMapInternal) > 0 {
+		itemIndex = arrlen(context.layoutElementHashMapInternal)
+		context.layoutElementHashMapInternal = arradd(context.layoutElementHashMapInternal, item)
+	} else {
+		context.elementsCapacityExceeded()
+		return nil
+	}
+	context.layoutElementHashMap[slot] = itemIndex + 1
+	return &context.layoutElementHashMapInternal[itemIndex]
+}
+
+// hashMapSlot returns the slot of the hash map index holding id,
+// or the empty slot where id would be inserted. Collisions are resolved by linear probing.
+func (context *Context) hashMapSlot(id uintn) uintn {
+	mask := uintn(len(context.layoutElementHashMap) - 1)
+	slot := id & mask
+	for {
+		itemIndex := context.layoutElementHashMap[slot]
+		if itemIndex == 0 || context.layoutElementHashMapInternal[itemIndex-1].ElementID.ID == id {
+			return slot
+		}
+		slot = (slot + 1) & mask
+	}
+}
+
+// removeHashMapItem removes the item with the given ID and frees it for reuse.
+// Following entries of the probe sequence are shifted back so no tombstones are needed.
+func (context *Context) removeHashMapItem(id uintn) {
+	slot := context.hashMapSlot(id)
+	itemIndex := context.layoutElementHashMap[slot]
+	if itemIndex == 0 {
+		return
+	}
+	context.layoutElementHashMapInternal[itemIndex-1] = LayoutElementHashMapItem{}
+	context.layoutElementHashMapFreelist = arradd(context.layoutElementHashMapFreelist, itemIndex-1)
+	mask := uintn(len(context.layoutElementHashMap) - 1)
+	context.layoutElementHashMap[slot] = 0
+	for next := (slot + 1) & mask; context.layoutElementHashMap[next] != 0; next = (next + 1) & mask {
+		nextID := context.layoutElementHashMapInternal[context.layoutElementHashMap[next]-1].ElementID.ID
+		home := nextID & mask
+		// Entry may fill the hole if its home slot is not between the hole and its current slot.
+		if (next-home)&mask >= (next-slot)&mask {
+			context.layoutElementHashMap[slot] = context.layoutElementHashMap[next]
+			context.layoutElementHashMap[next] = 0
+			slot = next
+		}
+	}
+}
+
+func nextPowerOfTwo(n intn) intn {
+	p := intn(1)
+	for p < n {
+		p <<= 1
+	}
+	return p
 }
 
 func arrlen[T any](s []T) intn {
@@ -1388,17 +2203,19 @@ func arrlen[T any](s []T) intn {
 func arrfree[T any](s []T) intn {
 	return intn(cap(s) - len(s))
 }
+
+// arradd appends v to s without growing it. If s is full v is discarded,
+// callers that must store v check arrfree beforehand.
 func arradd[T any](s []T, v T) []T {
 	if len(s) < cap(s) {
 		return append(s, v)
 	}
-	panic("attempted to add an element beyond capacity")
+	return s
 }
+
+// arrextend extends s by up to extend elements within its capacity.
 func arrextend[T any](s []T, extend intn) []T {
-	if arrcap(s)-arrlen(s) < extend {
-		panic("cannot extend array, not enough capacity")
-	}
-	return s[:arrlen(s)+extend]
+	return s[:arrlen(s)+min(extend, arrfree(s))]
 }
 func arrcap[T any](s []T) intn {
 	return intn(cap(s))
@@ -1425,6 +2242,12 @@ func arrremoveswapback[T any](s []T, index intn) []T {
 func clamp(Min, Max, v floatn) floatn {
 	return min(Max, max(Min, v))
 }
+func abs(v floatn) floatn {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
 func floatequal(a, b floatn) bool {
 	return math.Abs(float64(a-b)) < eps
 }
@@ -1446,25 +2269,37 @@ func (d *Dimensions) Aspect() floatn {
 	return d.Width / d.Height
 }
 
-var emptyChildren []intn
-
+// Children returns the indices of the element's children in Context.LayoutElements.
+// It is nil for text elements and for elements that have not been closed yet.
 func (le *LayoutElement) Children() []intn {
-	switch v := le.ChildrenOrTextContent.(type) {
-	case []intn:
-		return v
-	case nil:
-		return nil
-		// panic("no children")
-		// children := make([]intn, 8)[:0]
-		// le.ChildrenOrTextContent = children
-		// return children
-	default:
-		panic("children must be of type []intn")
-	}
+	return le.children
 }
 
 func (le *LayoutElement) SetChildren(children []intn) {
-	le.ChildrenOrTextContent = children
+	le.children = children
+	le.childCount = intn(len(children))
+}
+
+// outerSize returns the size of the element along the axis, margin included.
+func (le *LayoutElement) outerSize(xaxis bool) floatn {
+	return le.Dimensions.SizeAxis(xaxis) + le.LayoutConfig.Margin.SizeAxis(xaxis)
+}
+
+// outerDimensions returns the dimensions and minimum dimensions of the element, margin included.
+func (le *LayoutElement) outerDimensions() (dimensions, minDimensions Dimensions) {
+	margin := Dimensions{Width: floatn(le.LayoutConfig.Margin.Horizontal()), Height: floatn(le.LayoutConfig.Margin.Vertical())}
+	dimensions = Dimensions{Width: le.Dimensions.Width + margin.Width, Height: le.Dimensions.Height + margin.Height}
+	minDimensions = Dimensions{Width: le.MinDimensions.Width + margin.Width, Height: le.MinDimensions.Height + margin.Height}
+	return dimensions, minDimensions
+}
+
+// placedChild returns the index of the child placed i-th along the layout axis of the element, within
+// the line of children from start to end.
+func (le *LayoutElement) placedChild(i, start, end intn) intn {
+	if le.LayoutConfig.Reverse && le.LayoutConfig.LayoutDirection != Grid {
+		return start + end - 1 - i
+	}
+	return i
 }
 
 func (ap FloatingAttachPointType) AttachLeft() bool {
@@ -0,0 +1,763 @@
+package glay
+
+import "unicode/utf8"
+
+//go:generate go run gen_bidi.go -version 15.0.0 -o bidi_tables.go
+
+// bidiClass is a Bidi_Class property value of the Unicode Bidirectional Algorithm (UAX #9).
+type bidiClass uint8
+
+const (
+	bcL bidiClass = iota // Left-to-right, the default class.
+	bcR
+	bcAL
+	bcEN
+	bcES
+	bcET
+	bcAN
+	bcCS
+	bcNSM
+	bcBN
+	bcB
+	bcS
+	bcWS
+	bcON
+	bcLRE
+	bcLRO
+	bcRLE
+	bcRLO
+	bcPDF
+	bcLRI
+	bcRLI
+	bcFSI
+	bcPDI
+)
+
+type bidiClassRange struct {
+	lo, hi rune
+	class  bidiClass
+}
+
+type bidiBracket struct {
+	r, pair rune
+	opening bool
+}
+
+// bidiClassOf returns the Bidi_Class of r.
+func bidiClassOf(r rune) bidiClass {
+	lo, hi := 0, len(bidiClassRanges)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		switch rng := &bidiClassRanges[mid]; {
+		case r < rng.lo:
+			hi = mid
+		case r > rng.hi:
+			lo = mid + 1
+		default:
+			return rng.class
+		}
+	}
+	return bcL
+}
+
+// bidiBracketOf returns the paired bracket properties of r, ok is false if r is not a paired bracket.
+func bidiBracketOf(r rune) (bracket bidiBracket, ok bool) {
+	lo, hi := 0, len(bidiBrackets)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		switch b := &bidiBrackets[mid]; {
+		case r < b.r:
+			hi = mid
+		case r > b.r:
+			lo = mid + 1
+		default:
+			return *b, true
+		}
+	}
+	return bracket, false
+}
+
+// canonicalBracket maps the brackets with canonical decompositions to their decomposition, see BD16.
+func canonicalBracket(r rune) rune {
+	switch r {
+	case 0x2329:
+		return 0x3008
+	case 0x232A:
+		return 0x3009
+	}
+	return r
+}
+
+func (c bidiClass) isIsolateInitiator() bool { return c == bcLRI || c == bcRLI || c == bcFSI }
+
+// isRemoved reports whether characters of class c are removed by rule X9.
+func (c bidiClass) isRemoved() bool {
+	return c == bcBN || c == bcLRE || c == bcRLE || c == bcLRO || c == bcRLO || c == bcPDF
+}
+
+// isNeutralOrIsolate reports whether c is a neutral or isolate formatting character (NI), see N1.
+func (c bidiClass) isNeutralOrIsolate() bool {
+	return c == bcB || c == bcS || c == bcWS || c == bcON || c.isIsolateInitiator() || c == bcPDI
+}
+
+// strong returns the strong direction of c for the rules N0 and N1, where numbers count as R.
+// It returns bcON for other classes.
+func (c bidiClass) strong() bidiClass {
+	switch c {
+	case bcL:
+		return bcL
+	case bcR, bcAL, bcEN, bcAN:
+		return bcR
+	}
+	return bcON
+}
+
+// levelDirection returns the embedding direction of an embedding level.
+func levelDirection(level uint8) bidiClass {
+	if level%2 == 1 {
+		return bcR
+	}
+	return bcL
+}
+
+// hasRightToLeft reports whether text contains characters that may be displayed right to left.
+func hasRightToLeft(text string) bool {
+	for _, r := range text {
+		if r < 0x0590 {
+			continue // Right-to-left classes start with the Hebrew block.
+		}
+		switch bidiClassOf(r) {
+		case bcR, bcAL, bcRLE, bcRLO, bcRLI:
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// maxBidiTextLength is the number of characters of the longest text reordered by the
+	// bidirectional algorithm. Longer text is displayed in logical order.
+	maxBidiTextLength = 8192
+	maxBidiDepth      = 125 // Deepest explicit embedding level, see BD2.
+	maxBidiBrackets   = 63  // Size of the bracket stack of BD16.
+)
+
+// bidiText resolves the embedding levels of text with the Unicode Bidirectional Algorithm (UAX #9)
+// and reorders its lines. Its buffers are allocated once by the Context and reused for every text.
+type bidiText struct {
+	length          intn        // Length of the text in bytes.
+	runes           []rune      // Characters of the text.
+	offsets         []intn      // Byte offset of each character.
+	classes         []bidiClass // Bidi_Class of each character.
+	types           []bidiClass // Resolved type of each character.
+	levels          []uint8     // Resolved embedding level of each character.
+	paragraphLevels []uint8     // Embedding level of the paragraph of each character.
+	matchingPDI     []int32     // Index of the matching PDI of isolate initiators, -1 if none.
+	matchedPDI      []bool      // PDIs that match an isolate initiator.
+	sequence        []int32     // Characters of the isolating run sequence being resolved.
+	levelRuns       []bidiRun   // Level runs of the paragraph being resolved, or of a line.
+	bracketStack    []bidiBracketPair
+	bracketPairs    []bidiBracketPair
+	stack           [maxBidiDepth + 2]bidiStatus
+}
+
+// bidiRun is a maximal sequence of characters with the same embedding level.
+type bidiRun struct {
+	start, end intn // Character indices while resolving, byte offsets once reordered.
+	level      uint8
+}
+
+type bidiBracketPair struct {
+	open, close int32 // Positions in the isolating run sequence.
+	pair        rune  // Closing bracket of an open bracket on the stack.
+}
+
+// bidiStatus is an entry of the directional status stack of rules X1 to X8.
+type bidiStatus struct {
+	level    uint8
+	override bidiClass // bcL, bcR or bcON for neutral.
+	isolate  bool
+}
+
+func (b *bidiText) init(arena *_Arena) {
+	alloc(arena, &b.runes, maxBidiTextLength)
+	alloc(arena, &b.offsets, maxBidiTextLength)
+	alloc(arena, &b.classes, maxBidiTextLength)
+	alloc(arena, &b.types, maxBidiTextLength)
+	alloc(arena, &b.levels, maxBidiTextLength)
+	alloc(arena, &b.paragraphLevels, maxBidiTextLength)
+	alloc(arena, &b.matchingPDI, maxBidiTextLength)
+	alloc(arena, &b.matchedPDI, maxBidiTextLength)
+	alloc(arena, &b.sequence, maxBidiTextLength)
+	alloc(arena, &b.levelRuns, maxBidiTextLength)
+	alloc(arena, &b.bracketStack, maxBidiBrackets)
+	alloc(arena, &b.bracketPairs, maxBidiTextLength)
+}
+
+func (b *bidiText) reset() {
+	b.length = 0
+	b.runes = b.runes[:0]
+	b.offsets = b.offsets[:0]
+	b.classes = b.classes[:0]
+}
+
+// add appends text starting at byte offset of the text being resolved.
+// It returns false if the text is too long.
+func (b *bidiText) add(text string, offset intn) bool {
+	if arrfree(b.runes) < intn(utf8.RuneCountInString(text)) {
+		return false
+	}
+	for i, r := range text {
+		b.runes = arradd(b.runes, r)
+		b.offsets = arradd(b.offsets, offset+intn(i))
+		b.classes = arradd(b.classes, bidiClassOf(r))
+	}
+	b.length = offset + intn(len(text))
+	return true
+}
+
+// resolve computes the embedding levels of the text, which is split into paragraphs at paragraph separators.
+func (b *bidiText) resolve() {
+	n := len(b.runes)
+	b.types = append(b.types[:0], b.classes...)
+	b.levels = b.levels[:n]
+	b.paragraphLevels = b.paragraphLevels[:n]
+	b.matchingPDI = b.matchingPDI[:n]
+	b.matchedPDI = b.matchedPDI[:n]
+	for start := 0; start < n; {
+		end := start
+		for end < n && b.classes[end] != bcB {
+			end++
+		}
+		if end < n {
+			end++ // P1: The paragraph separator is kept with the preceding paragraph.
+		}
+		b.resolveParagraph(start, end)
+		start = end
+	}
+}
+
+func (b *bidiText) resolveParagraph(start, end int) {
+	// P2, P3: The paragraph level is given by its first strong character.
+	var level uint8
+	if b.firstStrong(start, end, false) == bcR {
+		level = 1
+	}
+	for i := start; i < end; i++ {
+		b.paragraphLevels[i] = level
+	}
+	b.matchIsolates(start, end)
+	b.explicitLevels(start, end, level)
+
+	// X10: Compute the level runs of the characters not removed by X9.
+	runs := b.levelRuns[:0]
+	for i := start; i < end; i++ {
+		if b.types[i] == bcBN {
+			continue
+		}
+		if len(runs) > 0 && runs[len(runs)-1].level == b.levels[i] {
+			runs[len(runs)-1].end = intn(i + 1)
+		} else {
+			runs = append(runs, bidiRun{start: intn(i), end: intn(i + 1), level: b.levels[i]})
+		}
+	}
+	// Resolve the isolating run sequences, which chain level runs across isolates.
+	for r := range runs {
+		first := runs[r].start
+		if b.classes[first] == bcPDI && b.matchedPDI[first] {
+			continue // Continues the sequence of its isolate initiator.
+		}
+		seq := b.sequence[:0]
+		for run := r; ; {
+			for i := runs[run].start; i < runs[run].end; i++ {
+				if b.types[i] != bcBN {
+					seq = append(seq, int32(i))
+				}
+			}
+			last := seq[len(seq)-1]
+			pdi := b.matchingPDI[last]
+			if !b.classes[last].isIsolateInitiator() || pdi < 0 {
+				break
+			}
+			run = bidiRunContaining(runs, intn(pdi))
+		}
+		b.resolveSequence(seq, start, end, level)
+	}
+	// I1, I2: Resolve the implicit levels once the levels of all sequences are known.
+	for i := start; i < end; i++ {
+		switch t, level := b.types[i], b.levels[i]; {
+		case level%2 == 0 && t == bcR:
+			b.levels[i] = level + 1
+		case level%2 == 0 && (t == bcAN || t == bcEN):
+			b.levels[i] = level + 2
+		case level%2 == 1 && (t == bcL || t == bcEN || t == bcAN):
+			b.levels[i] = level + 1
+		}
+	}
+	// Removed characters take the level of the preceding character, which keeps them in its run.
+	previous := level
+	for i := start; i < end; i++ {
+		if b.types[i] == bcBN {
+			b.levels[i] = previous
+		}
+		previous = b.levels[i]
+	}
+}
+
+// bidiRunContaining returns the index of the run containing character i.
+func bidiRunContaining(runs []bidiRun, i intn) int {
+	lo, hi := 0, len(runs)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if runs[mid].end <= i {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// firstStrong returns the class L or R of the first strong character between start and end,
+// skipping isolates, or bcON if there is none. If isolate is set the search stops at the PDI
+// closing the isolate the search started in, see P2 and X5c.
+func (b *bidiText) firstStrong(start, end int, isolate bool) bidiClass {
+	depth := 0
+	for i := start; i < end; i++ {
+		switch c := b.classes[i]; {
+		case depth == 0 && c == bcL:
+			return bcL
+		case depth == 0 && (c == bcR || c == bcAL):
+			return bcR
+		case c.isIsolateInitiator():
+			depth++
+		case c == bcPDI && depth > 0:
+			depth--
+		case c == bcPDI && isolate:
+			return bcON
+		}
+	}
+	return bcON
+}
+
+// matchIsolates finds the matching PDI of every isolate initiator, see BD9.
+func (b *bidiText) matchIsolates(start, end int) {
+	open := b.sequence[:0] // Used as the stack of open isolate initiators.
+	for i := start; i < end; i++ {
+		b.matchingPDI[i] = -1
+		b.matchedPDI[i] = false
+		switch c := b.classes[i]; {
+		case c.isIsolateInitiator():
+			open = append(open, int32(i))
+		case c == bcPDI && len(open) > 0:
+			b.matchingPDI[open[len(open)-1]] = int32(i)
+			b.matchedPDI[i] = true
+			open = open[:len(open)-1]
+		}
+	}
+}
+
+// explicitLevels applies rules X1 to X9: it computes the explicit embedding levels and directional
+// overrides of the paragraph and marks the characters removed by X9 as BN.
+func (b *bidiText) explicitLevels(start, end int, paragraphLevel uint8) {
+	stack := b.stack[:1]
+	stack[0] = bidiStatus{level: paragraphLevel, override: bcON}
+	var overflowIsolates, overflowEmbeddings, validIsolates int
+	for i := start; i < end; i++ {
+		top := &stack[len(stack)-1]
+		c := b.classes[i]
+		switch c {
+		case bcRLE, bcLRE, bcRLO, bcLRO: // X2 to X5
+			newLevel := (top.level + 2) &^ 1 // Least greater even level.
+			if c == bcRLE || c == bcRLO {
+				newLevel = (top.level + 1) | 1 // Least greater odd level.
+			}
+			if newLevel <= maxBidiDepth && overflowIsolates == 0 && overflowEmbeddings == 0 {
+				status := bidiStatus{level: newLevel, override: bcON}
+				if c == bcRLO {
+					status.override = bcR
+				} else if c == bcLRO {
+					status.override = bcL
+				}
+				stack = append(stack, status)
+			} else if overflowIsolates == 0 {
+				overflowEmbeddings++
+			}
+			b.levels[i] = top.level
+			b.types[i] = bcBN
+		case bcRLI, bcLRI, bcFSI: // X5a to X5c
+			b.levels[i] = top.level
+			if top.override != bcON {
+				b.types[i] = top.override
+			}
+			rtl := c == bcRLI
+			if c == bcFSI {
+				rtl = b.firstStrong(i+1, end, true) == bcR
+			}
+			newLevel := (top.level + 2) &^ 1
+			if rtl {
+				newLevel = (top.level + 1) | 1
+			}
+			if newLevel <= maxBidiDepth && overflowIsolates == 0 && overflowEmbeddings == 0 {
+				validIsolates++
+				stack = append(stack, bidiStatus{level: newLevel, override: bcON, isolate: true})
+			} else {
+				overflowIsolates++
+			}
+		case bcPDI: // X6a
+			if overflowIsolates > 0 {
+				overflowIsolates--
+			} else if validIsolates > 0 {
+				overflowEmbeddings = 0
+				for !stack[len(stack)-1].isolate {
+					stack = stack[:len(stack)-1]
+				}
+				stack = stack[:len(stack)-1]
+				validIsolates--
+			}
+			top = &stack[len(stack)-1]
+			b.levels[i] = top.level
+			if top.override != bcON {
+				b.types[i] = top.override
+			}
+		case bcPDF: // X7
+			if overflowIsolates > 0 {
+				// Do nothing.
+			} else if overflowEmbeddings > 0 {
+				overflowEmbeddings--
+			} else if !top.isolate && len(stack) >= 2 {
+				stack = stack[:len(stack)-1]
+			}
+			b.levels[i] = top.level
+			b.types[i] = bcBN
+		case bcB: // X8
+			b.levels[i] = paragraphLevel
+		case bcBN: // X9
+			b.levels[i] = top.level
+		default: // X6
+			b.levels[i] = top.level
+			if top.override != bcON {
+				b.types[i] = top.override
+			}
+		}
+	}
+}
+
+// resolveSequence applies the rules W1 to N2 to an isolating run sequence of the paragraph between start and end.
+func (b *bidiText) resolveSequence(seq []int32, start, end int, paragraphLevel uint8) {
+	types := b.types
+	level := b.levels[seq[0]]
+	// X10: The start and end of sequence types are given by the higher of the levels on either side.
+	before, after := paragraphLevel, paragraphLevel
+	for i := int(seq[0]) - 1; i >= start; i-- {
+		if types[i] != bcBN {
+			before = b.levels[i]
+			break
+		}
+	}
+	if last := seq[len(seq)-1]; !b.classes[last].isIsolateInitiator() {
+		for i := int(last) + 1; i < end; i++ {
+			if types[i] != bcBN {
+				after = b.levels[i]
+				break
+			}
+		}
+	}
+	sos := levelDirection(max(level, before))
+	eos := levelDirection(max(level, after))
+
+	// W1: Nonspacing marks take the type of the previous character.
+	previous := sos
+	for _, i := range seq {
+		if types[i] == bcNSM {
+			if previous.isIsolateInitiator() || previous == bcPDI {
+				types[i] = bcON
+			} else {
+				types[i] = previous
+			}
+		}
+		previous = types[i]
+	}
+	// W2: European numbers after Arabic letters are Arabic numbers. W3: Arabic letters are R.
+	lastStrong := sos
+	for _, i := range seq {
+		switch types[i] {
+		case bcL, bcR:
+			lastStrong = types[i]
+		case bcAL:
+			lastStrong = bcAL
+			types[i] = bcR
+		case bcEN:
+			if lastStrong == bcAL {
+				types[i] = bcAN
+			}
+		}
+	}
+	// W4: A single separator between two numbers of the same type takes their type.
+	for k := 1; k+1 < len(seq); k++ {
+		t, prev, next := types[seq[k]], types[seq[k-1]], types[seq[k+1]]
+		switch {
+		case t == bcES && prev == bcEN && next == bcEN, t == bcCS && prev == bcEN && next == bcEN:
+			types[seq[k]] = bcEN
+		case t == bcCS && prev == bcAN && next == bcAN:
+			types[seq[k]] = bcAN
+		}
+	}
+	// W5: Terminators adjacent to European numbers become European numbers.
+	for k := 0; k < len(seq); {
+		if types[seq[k]] != bcET {
+			k++
+			continue
+		}
+		runEnd := k
+		for runEnd < len(seq) && types[seq[runEnd]] == bcET {
+			runEnd++
+		}
+		if (k > 0 && types[seq[k-1]] == bcEN) || (runEnd < len(seq) && types[seq[runEnd]] == bcEN) {
+			for i := k; i < runEnd; i++ {
+				types[seq[i]] = bcEN
+			}
+		}
+		k = runEnd
+	}
+	// W6: Remaining separators and terminators are neutral. W7: European numbers after L are L.
+	lastStrong = sos
+	for _, i := range seq {
+		switch types[i] {
+		case bcES, bcET, bcCS:
+			types[i] = bcON
+		case bcL, bcR:
+			lastStrong = types[i]
+		case bcEN:
+			if lastStrong == bcL {
+				types[i] = bcL
+			}
+		}
+	}
+
+	embedding := levelDirection(level)
+	b.resolveBrackets(seq, sos, embedding)
+
+	// N1, N2: Sequences of neutrals take the direction of the surrounding strong text if both sides
+	// agree, otherwise the embedding direction.
+	for k := 0; k < len(seq); {
+		if !types[seq[k]].isNeutralOrIsolate() {
+			k++
+			continue
+		}
+		runEnd := k
+		for runEnd < len(seq) && types[seq[runEnd]].isNeutralOrIsolate() {
+			runEnd++
+		}
+		leading, trailing := sos, eos
+		if k > 0 {
+			leading = types[seq[k-1]].strong()
+		}
+		if runEnd < len(seq) {
+			trailing = types[seq[runEnd]].strong()
+		}
+		t := embedding
+		if leading == trailing {
+			t = leading
+		}
+		for ; k < runEnd; k++ {
+			types[seq[k]] = t
+		}
+	}
+
+}
+
+// resolveBrackets applies rule N0 to the paired brackets of an isolating run sequence.
+func (b *bidiText) resolveBrackets(seq []int32, sos, embedding bidiClass) {
+	types := b.types
+	// BD16: Identify the bracket pairs.
+	stack := b.bracketStack[:0]
+	pairs := b.bracketPairs[:0]
+identify:
+	for k, i := range seq {
+		if types[i] != bcON {
+			continue
+		}
+		bracket, ok := bidiBracketOf(b.runes[i])
+		switch {
+		case !ok:
+		case bracket.opening:
+			if len(stack) == cap(stack) {
+				break identify // Stack overflow, stop looking for pairs.
+			}
+			stack = append(stack, bidiBracketPair{open: int32(k), pair: canonicalBracket(bracket.pair)})
+		default:
+			closing := canonicalBracket(b.runes[i])
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j].pair == closing {
+					pairs = append(pairs, bidiBracketPair{open: stack[j].open, close: int32(k)})
+					stack = stack[:j]
+					break
+				}
+			}
+		}
+	}
+	// Sort the pairs by position of the opening bracket.
+	for i := 1; i < len(pairs); i++ {
+		for j := i; j > 0 && pairs[j].open < pairs[j-1].open; j-- {
+			pairs[j], pairs[j-1] = pairs[j-1], pairs[j]
+		}
+	}
+	for _, pair := range pairs {
+		opposite := bcON
+		t := bcON
+		for k := pair.open + 1; k < pair.close; k++ {
+			strong := types[seq[k]].strong()
+			if strong == embedding {
+				t = embedding
+				break
+			} else if strong != bcON {
+				opposite = strong
+			}
+		}
+		if t == bcON && opposite != bcON {
+			// N0 c: Use the direction of the context before the brackets if it is opposite to the embedding.
+			context := sos
+			for k := pair.open - 1; k >= 0; k-- {
+				if strong := types[seq[k]].strong(); strong != bcON {
+					context = strong
+					break
+				}
+			}
+			t = embedding
+			if context == opposite {
+				t = opposite
+			}
+		}
+		if t == bcON {
+			continue // N0 d: No strong type between the brackets.
+		}
+		for _, k := range [2]int32{pair.open, pair.close} {
+			types[seq[k]] = t
+			// Nonspacing marks following a bracket take its new type.
+			for k++; int(k) < len(seq) && b.classes[seq[k]] == bcNSM; k++ {
+				types[seq[k]] = t
+			}
+		}
+	}
+}
+
+// runeIndex returns the index of the first character at or after byte offset.
+func (b *bidiText) runeIndex(offset intn) int {
+	lo, hi := 0, len(b.offsets)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if b.offsets[mid] < offset {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// paragraphLevel returns the embedding level of the paragraph containing byte offset.
+func (b *bidiText) paragraphLevel(offset intn) uint8 {
+	i := b.runeIndex(offset)
+	if i >= len(b.paragraphLevels) {
+		if i == 0 {
+			return 0
+		}
+		i--
+	}
+	return b.paragraphLevels[i]
+}
+
+// lineRuns returns the level runs of the line between byte offsets start and end in visual order,
+// left to right, with rules L1 and L2 applied. Run boundaries are byte offsets.
+func (b *bidiText) lineRuns(start, end intn) []bidiRun {
+	first, last := b.runeIndex(start), b.runeIndex(end)
+	runs := b.levelRuns[:0]
+	if first >= last {
+		return runs
+	}
+	paragraphLevel := b.paragraphLevels[first]
+	// L1: Whitespace and isolate formatting characters before separators and at the end of
+	// the line are reset to the paragraph level, as are removed characters among them.
+	trailing := true
+	for i := last - 1; i >= first; i-- {
+		level := b.levels[i]
+		switch c := b.classes[i]; {
+		case c == bcS || c == bcB:
+			level = paragraphLevel
+			trailing = true
+		case trailing && (c == bcWS || c.isIsolateInitiator() || c == bcPDI || c.isRemoved()):
+			level = paragraphLevel
+		default:
+			trailing = false
+		}
+		// Runs are built back to front and reversed below.
+		if len(runs) > 0 && runs[len(runs)-1].level == level {
+			runs[len(runs)-1].start = b.offsets[i]
+		} else {
+			runEnd := b.length
+			if i+1 < len(b.offsets) {
+				runEnd = b.offsets[i+1]
+			}
+			runs = append(runs, bidiRun{start: b.offsets[i], end: runEnd, level: level})
+		}
+	}
+	reverseBidiRuns(runs)
+	// L2: From the highest level to the lowest odd level, reverse any sequence of runs at that level or higher.
+	highest, lowest := uint8(0), uint8(255)
+	for _, run := range runs {
+		highest = max(highest, run.level)
+		lowest = min(lowest, run.level)
+	}
+	for level := highest; level >= lowest|1; level-- {
+		for i := 0; i < len(runs); {
+			if runs[i].level < level {
+				i++
+				continue
+			}
+			j := i
+			for j < len(runs) && runs[j].level >= level {
+				j++
+			}
+			reverseBidiRuns(runs[i:j])
+			i = j
+		}
+	}
+	return runs
+}
+
+func reverseBidiRuns(runs []bidiRun) {
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+}
+
+// resolveBidi resolves the embedding levels of the text of an element with right-to-left characters
+// into Context.bidi. It returns false if the text is displayed in logical order.
+func (context *Context) resolveBidi(data *TextElementData) bool {
+	if !data.rightToLeft {
+		return false
+	}
+	b := &context.bidi
+	if b.runes == nil {
+		b.init(nil)
+	}
+	b.reset()
+	ok := true
+	if len(data.spans) > 0 {
+		for i := 0; i < len(data.spans) && ok; i++ {
+			ok = b.add(data.spans[i].Text, data.spans[i].offset)
+		}
+	} else {
+		ok = b.add(data.Text, 0)
+	}
+	if !ok {
+		if !context.booleanWarnings.bidiTextTooLong {
+			context.addWarning(WarningBidiTextTooLong, "")
+		}
+		context.booleanWarnings.bidiTextTooLong = true
+		return false
+	}
+	b.resolve()
+	return true
+}
@@ -0,0 +1,862 @@
+// Code generated by gen_bidi.go from Unicode 15.0.0 data. DO NOT EDIT.
+
+package glay
+
+// bidiClassRanges holds the Bidi_Class of all code points that are not of class L, sorted.
+var bidiClassRanges = [...]bidiClassRange{
+	{0x0000, 0x0008, bcBN},
+	{0x0009, 0x0009, bcS},
+	{0x000A, 0x000A, bcB},
+	{0x000B, 0x000B, bcS},
+	{0x000C, 0x000C, bcWS},
+	{0x000D, 0x000D, bcB},
+	{0x000E, 0x001B, bcBN},
+	{0x001C, 0x001E, bcB},
+	{0x001F, 0x001F, bcS},
+	{0x0020, 0x0020, bcWS},
+	{0x0021, 0x0022, bcON},
+	{0x0023, 0x0025, bcET},
+	{0x0026, 0x002A, bcON},
+	{0x002B, 0x002B, bcES},
+	{0x002C, 0x002C, bcCS},
+	{0x002D, 0x002D, bcES},
+	{0x002E, 0x002F, bcCS},
+	{0x0030, 0x0039, bcEN},
+	{0x003A, 0x003A, bcCS},
+	{0x003B, 0x0040, bcON},
+	{0x005B, 0x0060, bcON},
+	{0x007B, 0x007E, bcON},
+	{0x007F, 0x0084, bcBN},
+	{0x0085, 0x0085, bcB},
+	{0x0086, 0x009F, bcBN},
+	{0x00A0, 0x00A0, bcCS},
+	{0x00A1, 0x00A1, bcON},
+	{0x00A2, 0x00A5, bcET},
+	{0x00A6, 0x00A9, bcON},
+	{0x00AB, 0x00AC, bcON},
+	{0x00AD, 0x00AD, bcBN},
+	{0x00AE, 0x00AF, bcON},
+	{0x00B0, 0x00B1, bcET},
+	{0x00B2, 0x00B3, bcEN},
+	{0x00B4, 0x00B4, bcON},
+	{0x00B6, 0x00B8, bcON},
+	{0x00B9, 0x00B9, bcEN},
+	{0x00BB, 0x00BF, bcON},
+	{0x00D7, 0x00D7, bcON},
+	{0x00F7, 0x00F7, bcON},
+	{0x02B9, 0x02BA, bcON},
+	{0x02C2, 0x02CF, bcON},
+	{0x02D2, 0x02DF, bcON},
+	{0x02E5, 0x02ED, bcON},
+	{0x02EF, 0x02FF, bcON},
+	{0x0300, 0x036F, bcNSM},
+	{0x0374, 0x0375, bcON},
+	{0x037E, 0x037E, bcON},
+	{0x0384, 0x0385, bcON},
+	{0x0387, 0x0387, bcON},
+	{0x03F6, 0x03F6, bcON},
+	{0x0483, 0x0489, bcNSM},
+	{0x058A, 0x058A, bcON},
+	{0x058D, 0x058E, bcON},
+	{0x058F, 0x058F, bcET},
+	{0x0590, 0x0590, bcR},
+	{0x0591, 0x05BD, bcNSM},
+	{0x05BE, 0x05BE, bcR},
+	{0x05BF, 0x05BF, bcNSM},
+	{0x05C0, 0x05C0, bcR},
+	{0x05C1, 0x05C2, bcNSM},
+	{0x05C3, 0x05C3, bcR},
+	{0x05C4, 0x05C5, bcNSM},
+	{0x05C6, 0x05C6, bcR},
+	{0x05C7, 0x05C7, bcNSM},
+	{0x05C8, 0x05FF, bcR},
+	{0x0600, 0x0605, bcAN},
+	{0x0606, 0x0607, bcON},
+	{0x0608, 0x0608, bcAL},
+	{0x0609, 0x060A, bcET},
+	{0x060B, 0x060B, bcAL},
+	{0x060C, 0x060C, bcCS},
+	{0x060D, 0x060D, bcAL},
+	{0x060E, 0x060F, bcON},
+	{0x0610, 0x061A, bcNSM},
+	{0x061B, 0x064A, bcAL},
+	{0x064B, 0x065F, bcNSM},
+	{0x0660, 0x0669, bcAN},
+	{0x066A, 0x066A, bcET},
+	{0x066B, 0x066C, bcAN},
+	{0x066D, 0x066F, bcAL},
+	{0x0670, 0x0670, bcNSM},
+	{0x0671, 0x06D5, bcAL},
+	{0x06D6, 0x06DC, bcNSM},
+	{0x06DD, 0x06DD, bcAN},
+	{0x06DE, 0x06DE, bcON},
+	{0x06DF, 0x06E4, bcNSM},
+	{0x06E5, 0x06E6, bcAL},
+	{0x06E7, 0x06E8, bcNSM},
+	{0x06E9, 0x06E9, bcON},
+	{0x06EA, 0x06ED, bcNSM},
+	{0x06EE, 0x06EF, bcAL},
+	{0x06F0, 0x06F9, bcEN},
+	{0x06FA, 0x0710, bcAL},
+	{0x0711, 0x0711, bcNSM},
+	{0x0712, 0x072F, bcAL},
+	{0x0730, 0x074A, bcNSM},
+	{0x074B, 0x07A5, bcAL},
+	{0x07A6, 0x07B0, bcNSM},
+	{0x07B1, 0x07BF, bcAL},
+	{0x07C0, 0x07EA, bcR},
+	{0x07EB, 0x07F3, bcNSM},
+	{0x07F4, 0x07F5, bcR},
+	{0x07F6, 0x07F9, bcON},
+	{0x07FA, 0x07FC, bcR},
+	{0x07FD, 0x07FD, bcNSM},
+	{0x07FE, 0x0815, bcR},
+	{0x0816, 0x0819, bcNSM},
+	{0x081A, 0x081A, bcR},
+	{0x081B, 0x0823, bcNSM},
+	{0x0824, 0x0824, bcR},
+	{0x0825, 0x0827, bcNSM},
+	{0x0828, 0x0828, bcR},
+	{0x0829, 0x082D, bcNSM},
+	{0x082E, 0x0858, bcR},
+	{0x0859, 0x085B, bcNSM},
+	{0x085C, 0x085F, bcR},
+	{0x0860, 0x086A, bcAL},
+	{0x086B, 0x086F, bcR},
+	{0x0870, 0x088E, bcAL},
+	{0x088F, 0x088F, bcR},
+	{0x0890, 0x0891, bcAN},
+	{0x0892, 0x0897, bcR},
+	{0x0898, 0x089F, bcNSM},
+	{0x08A0, 0x08C9, bcAL},
+	{0x08CA, 0x08E1, bcNSM},
+	{0x08E2, 0x08E2, bcAN},
+	{0x08E3, 0x0902, bcNSM},
+	{0x093A, 0x093A, bcNSM},
+	{0x093C, 0x093C, bcNSM},
+	{0x0941, 0x0948, bcNSM},
+	{0x094D, 0x094D, bcNSM},
+	{0x0951, 0x0957, bcNSM},
+	{0x0962, 0x0963, bcNSM},
+	{0x0981, 0x0981, bcNSM},
+	{0x09BC, 0x09BC, bcNSM},
+	{0x09C1, 0x09C4, bcNSM},
+	{0x09CD, 0x09CD, bcNSM},
+	{0x09E2, 0x09E3, bcNSM},
+	{0x09F2, 0x09F3, bcET},
+	{0x09FB, 0x09FB, bcET},
+	{0x09FE, 0x09FE, bcNSM},
+	{0x0A01, 0x0A02, bcNSM},
+	{0x0A3C, 0x0A3C, bcNSM},
+	{0x0A41, 0x0A42, bcNSM},
+	{0x0A47, 0x0A48, bcNSM},
+	{0x0A4B, 0x0A4D, bcNSM},
+	{0x0A51, 0x0A51, bcNSM},
+	{0x0A70, 0x0A71, bcNSM},
+	{0x0A75, 0x0A75, bcNSM},
+	{0x0A81, 0x0A82, bcNSM},
+	{0x0ABC, 0x0ABC, bcNSM},
+	{0x0AC1, 0x0AC5, bcNSM},
+	{0x0AC7, 0x0AC8, bcNSM},
+	{0x0ACD, 0x0ACD, bcNSM},
+	{0x0AE2, 0x0AE3, bcNSM},
+	{0x0AF1, 0x0AF1, bcET},
+	{0x0AFA, 0x0AFF, bcNSM},
+	{0x0B01, 0x0B01, bcNSM},
+	{0x0B3C, 0x0B3C, bcNSM},
+	{0x0B3F, 0x0B3F, bcNSM},
+	{0x0B41, 0x0B44, bcNSM},
+	{0x0B4D, 0x0B4D, bcNSM},
+	{0x0B55, 0x0B56, bcNSM},
+	{0x0B62, 0x0B63, bcNSM},
+	{0x0B82, 0x0B82, bcNSM},
+	{0x0BC0, 0x0BC0, bcNSM},
+	{0x0BCD, 0x0BCD, bcNSM},
+	{0x0BF3, 0x0BF8, bcON},
+	{0x0BF9, 0x0BF9, bcET},
+	{0x0BFA, 0x0BFA, bcON},
+	{0x0C00, 0x0C00, bcNSM},
+	{0x0C04, 0x0C04, bcNSM},
+	{0x0C3C, 0x0C3C, bcNSM},
+	{0x0C3E, 0x0C40, bcNSM},
+	{0x0C46, 0x0C48, bcNSM},
+	{0x0C4A, 0x0C4D, bcNSM},
+	{0x0C55, 0x0C56, bcNSM},
+	{0x0C62, 0x0C63, bcNSM},
+	{0x0C78, 0x0C7E, bcON},
+	{0x0C81, 0x0C81, bcNSM},
+	{0x0CBC, 0x0CBC, bcNSM},
+	{0x0CCC, 0x0CCD, bcNSM},
+	{0x0CE2, 0x0CE3, bcNSM},
+	{0x0D00, 0x0D01, bcNSM},
+	{0x0D3B, 0x0D3C, bcNSM},
+	{0x0D41, 0x0D44, bcNSM},
+	{0x0D4D, 0x0D4D, bcNSM},
+	{0x0D62, 0x0D63, bcNSM},
+	{0x0D81, 0x0D81, bcNSM},
+	{0x0DCA, 0x0DCA, bcNSM},
+	{0x0DD2, 0x0DD4, bcNSM},
+	{0x0DD6, 0x0DD6, bcNSM},
+	{0x0E31, 0x0E31, bcNSM},
+	{0x0E34, 0x0E3A, bcNSM},
+	{0x0E3F, 0x0E3F, bcET},
+	{0x0E47, 0x0E4E, bcNSM},
+	{0x0EB1, 0x0EB1, bcNSM},
+	{0x0EB4, 0x0EBC, bcNSM},
+	{0x0EC8, 0x0ECE, bcNSM},
+	{0x0F18, 0x0F19, bcNSM},
+	{0x0F35, 0x0F35, bcNSM},
+	{0x0F37, 0x0F37, bcNSM},
+	{0x0F39, 0x0F39, bcNSM},
+	{0x0F3A, 0x0F3D, bcON},
+	{0x0F71, 0x0F7E, bcNSM},
+	{0x0F80, 0x0F84, bcNSM},
+	{0x0F86, 0x0F87, bcNSM},
+	{0x0F8D, 0x0F97, bcNSM},
+	{0x0F99, 0x0FBC, bcNSM},
+	{0x0FC6, 0x0FC6, bcNSM},
+	{0x102D, 0x1030, bcNSM},
+	{0x1032, 0x1037, bcNSM},
+	{0x1039, 0x103A, bcNSM},
+	{0x103D, 0x103E, bcNSM},
+	{0x1058, 0x1059, bcNSM},
+	{0x105E, 0x1060, bcNSM},
+	{0x1071, 0x1074, bcNSM},
+	{0x1082, 0x1082, bcNSM},
+	{0x1085, 0x1086, bcNSM},
+	{0x108D, 0x108D, bcNSM},
+	{0x109D, 0x109D, bcNSM},
+	{0x135D, 0x135F, bcNSM},
+	{0x1390, 0x1399, bcON},
+	{0x1400, 0x1400, bcON},
+	{0x1680, 0x1680, bcWS},
+	{0x169B, 0x169C, bcON},
+	{0x1712, 0x1714, bcNSM},
+	{0x1732, 0x1733, bcNSM},
+	{0x1752, 0x1753, bcNSM},
+	{0x1772, 0x1773, bcNSM},
+	{0x17B4, 0x17B5, bcNSM},
+	{0x17B7, 0x17BD, bcNSM},
+	{0x17C6, 0x17C6, bcNSM},
+	{0x17C9, 0x17D3, bcNSM},
+	{0x17DB, 0x17DB, bcET},
+	{0x17DD, 0x17DD, bcNSM},
+	{0x17F0, 0x17F9, bcON},
+	{0x1800, 0x180A, bcON},
+	{0x180B, 0x180D, bcNSM},
+	{0x180E, 0x180E, bcBN},
+	{0x180F, 0x180F, bcNSM},
+	{0x1885, 0x1886, bcNSM},
+	{0x18A9, 0x18A9, bcNSM},
+	{0x1920, 0x1922, bcNSM},
+	{0x1927, 0x1928, bcNSM},
+	{0x1932, 0x1932, bcNSM},
+	{0x1939, 0x193B, bcNSM},
+	{0x1940, 0x1940, bcON},
+	{0x1944, 0x1945, bcON},
+	{0x19DE, 0x19FF, bcON},
+	{0x1A17, 0x1A18, bcNSM},
+	{0x1A1B, 0x1A1B, bcNSM},
+	{0x1A56, 0x1A56, bcNSM},
+	{0x1A58, 0x1A5E, bcNSM},
+	{0x1A60, 0x1A60, bcNSM},
+	{0x1A62, 0x1A62, bcNSM},
+	{0x1A65, 0x1A6C, bcNSM},
+	{0x1A73, 0x1A7C, bcNSM},
+	{0x1A7F, 0x1A7F, bcNSM},
+	{0x1AB0, 0x1ACE, bcNSM},
+	{0x1B00, 0x1B03, bcNSM},
+	{0x1B34, 0x1B34, bcNSM},
+	{0x1B36, 0x1B3A, bcNSM},
+	{0x1B3C, 0x1B3C, bcNSM},
+	{0x1B42, 0x1B42, bcNSM},
+	{0x1B6B, 0x1B73, bcNSM},
+	{0x1B80, 0x1B81, bcNSM},
+	{0x1BA2, 0x1BA5, bcNSM},
+	{0x1BA8, 0x1BA9, bcNSM},
+	{0x1BAB, 0x1BAD, bcNSM},
+	{0x1BE6, 0x1BE6, bcNSM},
+	{0x1BE8, 0x1BE9, bcNSM},
+	{0x1BED, 0x1BED, bcNSM},
+	{0x1BEF, 0x1BF1, bcNSM},
+	{0x1C2C, 0x1C33, bcNSM},
+	{0x1C36, 0x1C37, bcNSM},
+	{0x1CD0, 0x1CD2, bcNSM},
+	{0x1CD4, 0x1CE0, bcNSM},
+	{0x1CE2, 0x1CE8, bcNSM},
+	{0x1CED, 0x1CED, bcNSM},
+	{0x1CF4, 0x1CF4, bcNSM},
+	{0x1CF8, 0x1CF9, bcNSM},
+	{0x1DC0, 0x1DFF, bcNSM},
+	{0x1FBD, 0x1FBD, bcON},
+	{0x1FBF, 0x1FC1, bcON},
+	{0x1FCD, 0x1FCF, bcON},
+	{0x1FDD, 0x1FDF, bcON},
+	{0x1FED, 0x1FEF, bcON},
+	{0x1FFD, 0x1FFE, bcON},
+	{0x2000, 0x200A, bcWS},
+	{0x200B, 0x200D, bcBN},
+	{0x200F, 0x200F, bcR},
+	{0x2010, 0x2027, bcON},
+	{0x2028, 0x2028, bcWS},
+	{0x2029, 0x2029, bcB},
+	{0x202A, 0x202A, bcLRE},
+	{0x202B, 0x202B, bcRLE},
+	{0x202C, 0x202C, bcPDF},
+	{0x202D, 0x202D, bcLRO},
+	{0x202E, 0x202E, bcRLO},
+	{0x202F, 0x202F, bcCS},
+	{0x2030, 0x2034, bcET},
+	{0x2035, 0x2043, bcON},
+	{0x2044, 0x2044, bcCS},
+	{0x2045, 0x205E, bcON},
+	{0x205F, 0x205F, bcWS},
+	{0x2060, 0x2065, bcBN},
+	{0x2066, 0x2066, bcLRI},
+	{0x2067, 0x2067, bcRLI},
+	{0x2068, 0x2068, bcFSI},
+	{0x2069, 0x2069, bcPDI},
+	{0x206A, 0x206F, bcBN},
+	{0x2070, 0x2070, bcEN},
+	{0x2074, 0x2079, bcEN},
+	{0x207A, 0x207B, bcES},
+	{0x207C, 0x207E, bcON},
+	{0x2080, 0x2089, bcEN},
+	{0x208A, 0x208B, bcES},
+	{0x208C, 0x208E, bcON},
+	{0x20A0, 0x20CF, bcET},
+	{0x20D0, 0x20F0, bcNSM},
+	{0x2100, 0x2101, bcON},
+	{0x2103, 0x2106, bcON},
+	{0x2108, 0x2109, bcON},
+	{0x2114, 0x2114, bcON},
+	{0x2116, 0x2118, bcON},
+	{0x211E, 0x2123, bcON},
+	{0x2125, 0x2125, bcON},
+	{0x2127, 0x2127, bcON},
+	{0x2129, 0x2129, bcON},
+	{0x212E, 0x212E, bcET},
+	{0x213A, 0x213B, bcON},
+	{0x2140, 0x2144, bcON},
+	{0x214A, 0x214D, bcON},
+	{0x2150, 0x215F, bcON},
+	{0x2189, 0x218B, bcON},
+	{0x2190, 0x2211, bcON},
+	{0x2212, 0x2212, bcES},
+	{0x2213, 0x2213, bcET},
+	{0x2214, 0x2335, bcON},
+	{0x237B, 0x2394, bcON},
+	{0x2396, 0x2426, bcON},
+	{0x2440, 0x244A, bcON},
+	{0x2460, 0x2487, bcON},
+	{0x2488, 0x249B, bcEN},
+	{0x24EA, 0x26AB, bcON},
+	{0x26AD, 0x27FF, bcON},
+	{0x2900, 0x2B73, bcON},
+	{0x2B76, 0x2B95, bcON},
+	{0x2B97, 0x2BFF, bcON},
+	{0x2CE5, 0x2CEA, bcON},
+	{0x2CEF, 0x2CF1, bcNSM},
+	{0x2CF9, 0x2CFF, bcON},
+	{0x2D7F, 0x2D7F, bcNSM},
+	{0x2DE0, 0x2DFF, bcNSM},
+	{0x2E00, 0x2E5D, bcON},
+	{0x2E80, 0x2E99, bcON},
+	{0x2E9B, 0x2EF3, bcON},
+	{0x2F00, 0x2FD5, bcON},
+	{0x2FF0, 0x2FFB, bcON},
+	{0x3000, 0x3000, bcWS},
+	{0x3001, 0x3004, bcON},
+	{0x3008, 0x3020, bcON},
+	{0x302A, 0x302D, bcNSM},
+	{0x3030, 0x3030, bcON},
+	{0x3036, 0x3037, bcON},
+	{0x303D, 0x303F, bcON},
+	{0x3099, 0x309A, bcNSM},
+	{0x309B, 0x309C, bcON},
+	{0x30A0, 0x30A0, bcON},
+	{0x30FB, 0x30FB, bcON},
+	{0x31C0, 0x31E3, bcON},
+	{0x321D, 0x321E, bcON},
+	{0x3250, 0x325F, bcON},
+	{0x327C, 0x327E, bcON},
+	{0x32B1, 0x32BF, bcON},
+	{0x32CC, 0x32CF, bcON},
+	{0x3377, 0x337A, bcON},
+	{0x33DE, 0x33DF, bcON},
+	{0x33FF, 0x33FF, bcON},
+	{0x4DC0, 0x4DFF, bcON},
+	{0xA490, 0xA4C6, bcON},
+	{0xA60D, 0xA60F, bcON},
+	{0xA66F, 0xA672, bcNSM},
+	{0xA673, 0xA673, bcON},
+	{0xA674, 0xA67D, bcNSM},
+	{0xA67E, 0xA67F, bcON},
+	{0xA69E, 0xA69F, bcNSM},
+	{0xA6F0, 0xA6F1, bcNSM},
+	{0xA700, 0xA721, bcON},
+	{0xA788, 0xA788, bcON},
+	{0xA802, 0xA802, bcNSM},
+	{0xA806, 0xA806, bcNSM},
+	{0xA80B, 0xA80B, bcNSM},
+	{0xA825, 0xA826, bcNSM},
+	{0xA828, 0xA82B, bcON},
+	{0xA82C, 0xA82C, bcNSM},
+	{0xA838, 0xA839, bcET},
+	{0xA874, 0xA877, bcON},
+	{0xA8C4, 0xA8C5, bcNSM},
+	{0xA8E0, 0xA8F1, bcNSM},
+	{0xA8FF, 0xA8FF, bcNSM},
+	{0xA926, 0xA92D, bcNSM},
+	{0xA947, 0xA951, bcNSM},
+	{0xA980, 0xA982, bcNSM},
+	{0xA9B3, 0xA9B3, bcNSM},
+	{0xA9B6, 0xA9B9, bcNSM},
+	{0xA9BC, 0xA9BD, bcNSM},
+	{0xA9E5, 0xA9E5, bcNSM},
+	{0xAA29, 0xAA2E, bcNSM},
+	{0xAA31, 0xAA32, bcNSM},
+	{0xAA35, 0xAA36, bcNSM},
+	{0xAA43, 0xAA43, bcNSM},
+	{0xAA4C, 0xAA4C, bcNSM},
+	{0xAA7C, 0xAA7C, bcNSM},
+	{0xAAB0, 0xAAB0, bcNSM},
+	{0xAAB2, 0xAAB4, bcNSM},
+	{0xAAB7, 0xAAB8, bcNSM},
+	{0xAABE, 0xAABF, bcNSM},
+	{0xAAC1, 0xAAC1, bcNSM},
+	{0xAAEC, 0xAAED, bcNSM},
+	{0xAAF6, 0xAAF6, bcNSM},
+	{0xAB6A, 0xAB6B, bcON},
+	{0xABE5, 0xABE5, bcNSM},
+	{0xABE8, 0xABE8, bcNSM},
+	{0xABED, 0xABED, bcNSM},
+	{0xFB1D, 0xFB1D, bcR},
+	{0xFB1E, 0xFB1E, bcNSM},
+	{0xFB1F, 0xFB28, bcR},
+	{0xFB29, 0xFB29, bcES},
+	{0xFB2A, 0xFB4F, bcR},
+	{0xFB50, 0xFD3D, bcAL},
+	{0xFD3E, 0xFD4F, bcON},
+	{0xFD50, 0xFDCE, bcAL},
+	{0xFDCF, 0xFDCF, bcON},
+	{0xFDD0, 0xFDEF, bcBN},
+	{0xFDF0, 0xFDFC, bcAL},
+	{0xFDFD, 0xFDFF, bcON},
+	{0xFE00, 0xFE0F, bcNSM},
+	{0xFE10, 0xFE19, bcON},
+	{0xFE20, 0xFE2F, bcNSM},
+	{0xFE30, 0xFE4F, bcON},
+	{0xFE50, 0xFE50, bcCS},
+	{0xFE51, 0xFE51, bcON},
+	{0xFE52, 0xFE52, bcCS},
+	{0xFE54, 0xFE54, bcON},
+	{0xFE55, 0xFE55, bcCS},
+	{0xFE56, 0xFE5E, bcON},
+	{0xFE5F, 0xFE5F, bcET},
+	{0xFE60, 0xFE61, bcON},
+	{0xFE62, 0xFE63, bcES},
+	{0xFE64, 0xFE66, bcON},
+	{0xFE68, 0xFE68, bcON},
+	{0xFE69, 0xFE6A, bcET},
+	{0xFE6B, 0xFE6B, bcON},
+	{0xFE70, 0xFEFE, bcAL},
+	{0xFEFF, 0xFEFF, bcBN},
+	{0xFF01, 0xFF02, bcON},
+	{0xFF03, 0xFF05, bcET},
+	{0xFF06, 0xFF0A, bcON},
+	{0xFF0B, 0xFF0B, bcES},
+	{0xFF0C, 0xFF0C, bcCS},
+	{0xFF0D, 0xFF0D, bcES},
+	{0xFF0E, 0xFF0F, bcCS},
+	{0xFF10, 0xFF19, bcEN},
+	{0xFF1A, 0xFF1A, bcCS},
+	{0xFF1B, 0xFF20, bcON},
+	{0xFF3B, 0xFF40, bcON},
+	{0xFF5B, 0xFF65, bcON},
+	{0xFFE0, 0xFFE1, bcET},
+	{0xFFE2, 0xFFE4, bcON},
+	{0xFFE5, 0xFFE6, bcET},
+	{0xFFE8, 0xFFEE, bcON},
+	{0xFFF0, 0xFFF8, bcBN},
+	{0xFFF9, 0xFFFD, bcON},
+	{0xFFFE, 0xFFFF, bcBN},
+	{0x10101, 0x10101, bcON},
+	{0x10140, 0x1018C, bcON},
+	{0x10190, 0x1019C, bcON},
+	{0x101A0, 0x101A0, bcON},
+	{0x101FD, 0x101FD, bcNSM},
+	{0x102E0, 0x102E0, bcNSM},
+	{0x102E1, 0x102FB, bcEN},
+	{0x10376, 0x1037A, bcNSM},
+	{0x10800, 0x1091E, bcR},
+	{0x1091F, 0x1091F, bcON},
+	{0x10920, 0x10A00, bcR},
+	{0x10A01, 0x10A03, bcNSM},
+	{0x10A04, 0x10A04, bcR},
+	{0x10A05, 0x10A06, bcNSM},
+	{0x10A07, 0x10A0B, bcR},
+	{0x10A0C, 0x10A0F, bcNSM},
+	{0x10A10, 0x10A37, bcR},
+	{0x10A38, 0x10A3A, bcNSM},
+	{0x10A3B, 0x10A3E, bcR},
+	{0x10A3F, 0x10A3F, bcNSM},
+	{0x10A40, 0x10AE4, bcR},
+	{0x10AE5, 0x10AE6, bcNSM},
+	{0x10AE7, 0x10B38, bcR},
+	{0x10B39, 0x10B3F, bcON},
+	{0x10B40, 0x10CFF, bcR},
+	{0x10D00, 0x10D23, bcAL},
+	{0x10D24, 0x10D27, bcNSM},
+	{0x10D28, 0x10D2F, bcR},
+	{0x10D30, 0x10D39, bcAN},
+	{0x10D3A, 0x10E5F, bcR},
+	{0x10E60, 0x10E7E, bcAN},
+	{0x10E7F, 0x10EAA, bcR},
+	{0x10EAB, 0x10EAC, bcNSM},
+	{0x10EAD, 0x10EFC, bcR},
+	{0x10EFD, 0x10EFF, bcNSM},
+	{0x10F00, 0x10F2F, bcR},
+	{0x10F30, 0x10F45, bcAL},
+	{0x10F46, 0x10F50, bcNSM},
+	{0x10F51, 0x10F59, bcAL},
+	{0x10F5A, 0x10F81, bcR},
+	{0x10F82, 0x10F85, bcNSM},
+	{0x10F86, 0x10FFF, bcR},
+	{0x11001, 0x11001, bcNSM},
+	{0x11038, 0x11046, bcNSM},
+	{0x11052, 0x11065, bcON},
+	{0x11070, 0x11070, bcNSM},
+	{0x11073, 0x11074, bcNSM},
+	{0x1107F, 0x11081, bcNSM},
+	{0x110B3, 0x110B6, bcNSM},
+	{0x110B9, 0x110BA, bcNSM},
+	{0x110C2, 0x110C2, bcNSM},
+	{0x11100, 0x11102, bcNSM},
+	{0x11127, 0x1112B, bcNSM},
+	{0x1112D, 0x11134, bcNSM},
+	{0x11173, 0x11173, bcNSM},
+	{0x11180, 0x11181, bcNSM},
+	{0x111B6, 0x111BE, bcNSM},
+	{0x111C9, 0x111CC, bcNSM},
+	{0x111CF, 0x111CF, bcNSM},
+	{0x1122F, 0x11231, bcNSM},
+	{0x11234, 0x11234, bcNSM},
+	{0x11236, 0x11237, bcNSM},
+	{0x1123E, 0x1123E, bcNSM},
+	{0x11241, 0x11241, bcNSM},
+	{0x112DF, 0x112DF, bcNSM},
+	{0x112E3, 0x112EA, bcNSM},
+	{0x11300, 0x11301, bcNSM},
+	{0x1133B, 0x1133C, bcNSM},
+	{0x11340, 0x11340, bcNSM},
+	{0x11366, 0x1136C, bcNSM},
+	{0x11370, 0x11374, bcNSM},
+	{0x11438, 0x1143F, bcNSM},
+	{0x11442, 0x11444, bcNSM},
+	{0x11446, 0x11446, bcNSM},
+	{0x1145E, 0x1145E, bcNSM},
+	{0x114B3, 0x114B8, bcNSM},
+	{0x114BA, 0x114BA, bcNSM},
+	{0x114BF, 0x114C0, bcNSM},
+	{0x114C2, 0x114C3, bcNSM},
+	{0x115B2, 0x115B5, bcNSM},
+	{0x115BC, 0x115BD, bcNSM},
+	{0x115BF, 0x115C0, bcNSM},
+	{0x115DC, 0x115DD, bcNSM},
+	{0x11633, 0x1163A, bcNSM},
+	{0x1163D, 0x1163D, bcNSM},
+	{0x1163F, 0x11640, bcNSM},
+	{0x11660, 0x1166C, bcON},
+	{0x116AB, 0x116AB, bcNSM},
+	{0x116AD, 0x116AD, bcNSM},
+	{0x116B0, 0x116B5, bcNSM},
+	{0x116B7, 0x116B7, bcNSM},
+	{0x1171D, 0x1171F, bcNSM},
+	{0x11722, 0x11725, bcNSM},
+	{0x11727, 0x1172B, bcNSM},
+	{0x1182F, 0x11837, bcNSM},
+	{0x11839, 0x1183A, bcNSM},
+	{0x1193B, 0x1193C, bcNSM},
+	{0x1193E, 0x1193E, bcNSM},
+	{0x11943, 0x11943, bcNSM},
+	{0x119D4, 0x119D7, bcNSM},
+	{0x119DA, 0x119DB, bcNSM},
+	{0x119E0, 0x119E0, bcNSM},
+	{0x11A01, 0x11A06, bcNSM},
+	{0x11A09, 0x11A0A, bcNSM},
+	{0x11A33, 0x11A38, bcNSM},
+	{0x11A3B, 0x11A3E, bcNSM},
+	{0x11A47, 0x11A47, bcNSM},
+	{0x11A51, 0x11A56, bcNSM},
+	{0x11A59, 0x11A5B, bcNSM},
+	{0x11A8A, 0x11A96, bcNSM},
+	{0x11A98, 0x11A99, bcNSM},
+	{0x11C30, 0x11C36, bcNSM},
+	{0x11C38, 0x11C3D, bcNSM},
+	{0x11C92, 0x11CA7, bcNSM},
+	{0x11CAA, 0x11CB0, bcNSM},
+	{0x11CB2, 0x11CB3, bcNSM},
+	{0x11CB5, 0x11CB6, bcNSM},
+	{0x11D31, 0x11D36, bcNSM},
+	{0x11D3A, 0x11D3A, bcNSM},
+	{0x11D3C, 0x11D3D, bcNSM},
+	{0x11D3F, 0x11D45, bcNSM},
+	{0x11D47, 0x11D47, bcNSM},
+	{0x11D90, 0x11D91, bcNSM},
+	{0x11D95, 0x11D95, bcNSM},
+	{0x11D97, 0x11D97, bcNSM},
+	{0x11EF3, 0x11EF4, bcNSM},
+	{0x11F00, 0x11F01, bcNSM},
+	{0x11F36, 0x11F3A, bcNSM},
+	{0x11F40, 0x11F40, bcNSM},
+	{0x11F42, 0x11F42, bcNSM},
+	{0x11FD5, 0x11FDC, bcON},
+	{0x11FDD, 0x11FE0, bcET},
+	{0x11FE1, 0x11FF1, bcON},
+	{0x13440, 0x13440, bcNSM},
+	{0x13447, 0x13455, bcNSM},
+	{0x16AF0, 0x16AF4, bcNSM},
+	{0x16B30, 0x16B36, bcNSM},
+	{0x16F4F, 0x16F4F, bcNSM},
+	{0x16F8F, 0x16F92, bcNSM},
+	{0x16FE2, 0x16FE2, bcON},
+	{0x16FE4, 0x16FE4, bcNSM},
+	{0x1BC9D, 0x1BC9E, bcNSM},
+	{0x1BCA0, 0x1BCA3, bcBN},
+	{0x1CF00, 0x1CF2D, bcNSM},
+	{0x1CF30, 0x1CF46, bcNSM},
+	{0x1D167, 0x1D169, bcNSM},
+	{0x1D173, 0x1D17A, bcBN},
+	{0x1D17B, 0x1D182, bcNSM},
+	{0x1D185, 0x1D18B, bcNSM},
+	{0x1D1AA, 0x1D1AD, bcNSM},
+	{0x1D1E9, 0x1D1EA, bcON},
+	{0x1D200, 0x1D241, bcON},
+	{0x1D242, 0x1D244, bcNSM},
+	{0x1D245, 0x1D245, bcON},
+	{0x1D300, 0x1D356, bcON},
+	{0x1D6DB, 0x1D6DB, bcON},
+	{0x1D715, 0x1D715, bcON},
+	{0x1D74F, 0x1D74F, bcON},
+	{0x1D789, 0x1D789, bcON},
+	{0x1D7C3, 0x1D7C3, bcON},
+	{0x1D7CE, 0x1D7FF, bcEN},
+	{0x1DA00, 0x1DA36, bcNSM},
+	{0x1DA3B, 0x1DA6C, bcNSM},
+	{0x1DA75, 0x1DA75, bcNSM},
+	{0x1DA84, 0x1DA84, bcNSM},
+	{0x1DA9B, 0x1DA9F, bcNSM},
+	{0x1DAA1, 0x1DAAF, bcNSM},
+	{0x1E000, 0x1E006, bcNSM},
+	{0x1E008, 0x1E018, bcNSM},
+	{0x1E01B, 0x1E021, bcNSM},
+	{0x1E023, 0x1E024, bcNSM},
+	{0x1E026, 0x1E02A, bcNSM},
+	{0x1E08F, 0x1E08F, bcNSM},
+	{0x1E130, 0x1E136, bcNSM},
+	{0x1E2AE, 0x1E2AE, bcNSM},
+	{0x1E2EC, 0x1E2EF, bcNSM},
+	{0x1E2FF, 0x1E2FF, bcET},
+	{0x1E4EC, 0x1E4EF, bcNSM},
+	{0x1E800, 0x1E8CF, bcR},
+	{0x1E8D0, 0x1E8D6, bcNSM},
+	{0x1E8D7, 0x1E943, bcR},
+	{0x1E944, 0x1E94A, bcNSM},
+	{0x1E94B, 0x1EC70, bcR},
+	{0x1EC71, 0x1ECB4, bcAL},
+	{0x1ECB5, 0x1ED00, bcR},
+	{0x1ED01, 0x1ED3D, bcAL},
+	{0x1ED3E, 0x1EDFF, bcR},
+	{0x1EE00, 0x1EEEF, bcAL},
+	{0x1EEF0, 0x1EEF1, bcON},
+	{0x1EEF2, 0x1EEFF, bcAL},
+	{0x1EF00, 0x1EFFF, bcR},
+	{0x1F000, 0x1F02B, bcON},
+	{0x1F030, 0x1F093, bcON},
+	{0x1F0A0, 0x1F0AE, bcON},
+	{0x1F0B1, 0x1F0BF, bcON},
+	{0x1F0C1, 0x1F0CF, bcON},
+	{0x1F0D1, 0x1F0F5, bcON},
+	{0x1F100, 0x1F10A, bcEN},
+	{0x1F10B, 0x1F10F, bcON},
+	{0x1F12F, 0x1F12F, bcON},
+	{0x1F16A, 0x1F16F, bcON},
+	{0x1F1AD, 0x1F1AD, bcON},
+	{0x1F260, 0x1F265, bcON},
+	{0x1F300, 0x1F6D7, bcON},
+	{0x1F6DC, 0x1F6EC, bcON},
+	{0x1F6F0, 0x1F6FC, bcON},
+	{0x1F700, 0x1F776, bcON},
+	{0x1F77B, 0x1F7D9, bcON},
+	{0x1F7E0, 0x1F7EB, bcON},
+	{0x1F7F0, 0x1F7F0, bcON},
+	{0x1F800, 0x1F80B, bcON},
+	{0x1F810, 0x1F847, bcON},
+	{0x1F850, 0x1F859, bcON},
+	{0x1F860, 0x1F887, bcON},
+	{0x1F890, 0x1F8AD, bcON},
+	{0x1F8B0, 0x1F8B1, bcON},
+	{0x1F900, 0x1FA53, bcON},
+	{0x1FA60, 0x1FA6D, bcON},
+	{0x1FA70, 0x1FA7C, bcON},
+	{0x1FA80, 0x1FA88, bcON},
+	{0x1FA90, 0x1FABD, bcON},
+	{0x1FABF, 0x1FAC5, bcON},
+	{0x1FACE, 0x1FADB, bcON},
+	{0x1FAE0, 0x1FAE8, bcON},
+	{0x1FAF0, 0x1FAF8, bcON},
+	{0x1FB00, 0x1FB92, bcON},
+	{0x1FB94, 0x1FBCA, bcON},
+	{0x1FBF0, 0x1FBF9, bcEN},
+	{0x1FFFE, 0x1FFFF, bcBN},
+	{0x2FFFE, 0x2FFFF, bcBN},
+	{0x3FFFE, 0x3FFFF, bcBN},
+	{0x4FFFE, 0x4FFFF, bcBN},
+	{0x5FFFE, 0x5FFFF, bcBN},
+	{0x6FFFE, 0x6FFFF, bcBN},
+	{0x7FFFE, 0x7FFFF, bcBN},
+	{0x8FFFE, 0x8FFFF, bcBN},
+	{0x9FFFE, 0x9FFFF, bcBN},
+	{0xAFFFE, 0xAFFFF, bcBN},
+	{0xBFFFE, 0xBFFFF, bcBN},
+	{0xCFFFE, 0xCFFFF, bcBN},
+	{0xDFFFE, 0xE00FF, bcBN},
+	{0xE0100, 0xE01EF, bcNSM},
+	{0xE01F0, 0xE0FFF, bcBN},
+	{0xEFFFE, 0xEFFFF, bcBN},
+	{0xFFFFE, 0xFFFFF, bcBN},
+	{0x10FFFE, 0x10FFFF, bcBN},
+}
+
+// bidiBrackets holds the paired brackets of Bidi_Paired_Bracket_Type Open or Close, sorted.
+var bidiBrackets = [...]bidiBracket{
+	{0x0028, 0x0029, true},
+	{0x0029, 0x0028, false},
+	{0x005B, 0x005D, true},
+	{0x005D, 0x005B, false},
+	{0x007B, 0x007D, true},
+	{0x007D, 0x007B, false},
+	{0x0F3A, 0x0F3B, true},
+	{0x0F3B, 0x0F3A, false},
+	{0x0F3C, 0x0F3D, true},
+	{0x0F3D, 0x0F3C, false},
+	{0x169B, 0x169C, true},
+	{0x169C, 0x169B, false},
+	{0x2045, 0x2046, true},
+	{0x2046, 0x2045, false},
+	{0x207D, 0x207E, true},
+	{0x207E, 0x207D, false},
+	{0x208D, 0x208E, true},
+	{0x208E, 0x208D, false},
+	{0x2308, 0x2309, true},
+	{0x2309, 0x2308, false},
+	{0x230A, 0x230B, true},
+	{0x230B, 0x230A, false},
+	{0x2329, 0x232A, true},
+	{0x232A, 0x2329, false},
+	{0x2768, 0x2769, true},
+	{0x2769, 0x2768, false},
+	{0x276A, 0x276B, true},
+	{0x276B, 0x276A, false},
+	{0x276C, 0x276D, true},
+	{0x276D, 0x276C, false},
+	{0x276E, 0x276F, true},
+	{0x276F, 0x276E, false},
+	{0x2770, 0x2771, true},
+	{0x2771, 0x2770, false},
+	{0x2772, 0x2773, true},
+	{0x2773, 0x2772, false},
+	{0x2774, 0x2775, true},
+	{0x2775, 0x2774, false},
+	{0x27C5, 0x27C6, true},
+	{0x27C6, 0x27C5, false},
+	{0x27E6, 0x27E7, true},
+	{0x27E7, 0x27E6, false},
+	{0x27E8, 0x27E9, true},
+	{0x27E9, 0x27E8, false},
+	{0x27EA, 0x27EB, true},
+	{0x27EB, 0x27EA, false},
+	{0x27EC, 0x27ED, true},
+	{0x27ED, 0x27EC, false},
+	{0x27EE, 0x27EF, true},
+	{0x27EF, 0x27EE, false},
+	{0x2983, 0x2984, true},
+	{0x2984, 0x2983, false},
+	{0x2985, 0x2986, true},
+	{0x2986, 0x2985, false},
+	{0x2987, 0x2988, true},
+	{0x2988, 0x2987, false},
+	{0x2989, 0x298A, true},
+	{0x298A, 0x2989, false},
+	{0x298B, 0x298C, true},
+	{0x298C, 0x298B, false},
+	{0x298D, 0x2990, true},
+	{0x298E, 0x298F, false},
+	{0x298F, 0x298E, true},
+	{0x2990, 0x298D, false},
+	{0x2991, 0x2992, true},
+	{0x2992, 0x2991, false},
+	{0x2993, 0x2994, true},
+	{0x2994, 0x2993, false},
+	{0x2995, 0x2996, true},
+	{0x2996, 0x2995, false},
+	{0x2997, 0x2998, true},
+	{0x2998, 0x2997, false},
+	{0x29D8, 0x29D9, true},
+	{0x29D9, 0x29D8, false},
+	{0x29DA, 0x29DB, true},
+	{0x29DB, 0x29DA, false},
+	{0x29FC, 0x29FD, true},
+	{0x29FD, 0x29FC, false},
+	{0x2E22, 0x2E23, true},
+	{0x2E23, 0x2E22, false},
+	{0x2E24, 0x2E25, true},
+	{0x2E25, 0x2E24, false},
+	{0x2E26, 0x2E27, true},
+	{0x2E27, 0x2E26, false},
+	{0x2E28, 0x2E29, true},
+	{0x2E29, 0x2E28, false},
+	{0x2E55, 0x2E56, true},
+	{0x2E56, 0x2E55, false},
+	{0x2E57, 0x2E58, true},
+	{0x2E58, 0x2E57, false},
+	{0x2E59, 0x2E5A, true},
+	{0x2E5A, 0x2E59, false},
+	{0x2E5B, 0x2E5C, true},
+	{0x2E5C, 0x2E5B, false},
+	{0x3008, 0x3009, true},
+	{0x3009, 0x3008, false},
+	{0x300A, 0x300B, true},
+	{0x300B, 0x300A, false},
+	{0x300C, 0x300D, true},
+	{0x300D, 0x300C, false},
+	{0x300E, 0x300F, true},
+	{0x300F, 0x300E, false},
+	{0x3010, 0x3011, true},
+	{0x3011, 0x3010, false},
+	{0x3014, 0x3015, true},
+	{0x3015, 0x3014, false},
+	{0x3016, 0x3017, true},
+	{0x3017, 0x3016, false},
+	{0x3018, 0x3019, true},
+	{0x3019, 0x3018, false},
+	{0x301A, 0x301B, true},
+	{0x301B, 0x301A, false},
+	{0xFE59, 0xFE5A, true},
+	{0xFE5A, 0xFE59, false},
+	{0xFE5B, 0xFE5C, true},
+	{0xFE5C, 0xFE5B, false},
+	{0xFE5D, 0xFE5E, true},
+	{0xFE5E, 0xFE5D, false},
+	{0xFF08, 0xFF09, true},
+	{0xFF09, 0xFF08, false},
+	{0xFF3B, 0xFF3D, true},
+	{0xFF3D, 0xFF3B, false},
+	{0xFF5B, 0xFF5D, true},
+	{0xFF5D, 0xFF5B, false},
+	{0xFF5F, 0xFF60, true},
+	{0xFF60, 0xFF5F, false},
+	{0xFF62, 0xFF63, true},
+	{0xFF63, 0xFF62, false},
+}
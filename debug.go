@@ -29,6 +29,20 @@ func (log logger) logerr(msg string, attrs ...slog.Attr) {
 	log.logattrs(slog.LevelError, msg, attrs...)
 }
 
-func (log logger) SetLogger(logger *slog.Logger) {
+// SetLogger sets the logger that receives debug information and warnings. A nil logger disables logging.
+func (log *logger) SetLogger(logger *slog.Logger) {
 	log.l = logger
 }
+
+// addWarning records a warning for the current layout if warnings are enabled and forwards it to the logger.
+// Warnings beyond the capacity of Context.Warnings are only logged.
+func (context *Context) addWarning(kind WarningKind, stringID string) {
+	if !context.WarningsEnabled {
+		return
+	}
+	warning := Warning{Kind: kind, StringID: stringID, Generation: context.Generation}
+	if arrfree(context.Warnings) > 0 {
+		context.Warnings = arradd(context.Warnings, warning)
+	}
+	context.warn(kind.String(), slog.String("id", stringID), slog.Uint64("generation", uint64(warning.Generation)))
+}
@@ -0,0 +1,725 @@
+package glay
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	debugViewWidth         = 400
+	debugViewRowHeight     = 30
+	debugViewOuterPadding  = 10
+	debugViewIndentWidth   = 16
+	debugViewMaxTextLength = 40
+)
+
+var (
+	debugViewColor1           = Color{58, 56, 52, 255}
+	debugViewColor2           = Color{62, 60, 58, 255}
+	debugViewColor3           = Color{141, 133, 135, 255}
+	debugViewColor4           = Color{238, 226, 231, 255}
+	debugViewColorSelectedRow = Color{102, 80, 78, 255}
+	debugViewHighlightColor   = Color{168, 66, 28, 100}
+
+	debugViewInfoTextConfig  = TextElementConfig{TextColor: debugViewColor4, FontSize: 16, WrapMode: TextWrapNone}
+	debugViewInfoTitleConfig = TextElementConfig{TextColor: debugViewColor3, FontSize: 16, WrapMode: TextWrapNone}
+	debugViewLabelConfig     = TextElementConfig{TextColor: debugViewColor4, FontSize: 16}
+	debugViewDimLabelConfig  = TextElementConfig{TextColor: debugViewColor3, FontSize: 16}
+
+	debugViewLabelPadding = Padding{Left: 8, Right: 8, Top: 2, Bottom: 2}
+	debugViewLabelBorder  = BorderWidth{Left: 1, Right: 1, Top: 1, Bottom: 1}
+)
+
+type debugLayoutData struct {
+	rowCount                intn
+	selectedElementRowIndex intn
+}
+
+func debugElementConfigTypeLabel(Type ElementConfigType) (string, Color) {
+	switch Type {
+	case ElementConfigTypeShared:
+		return "Shared", Color{243, 134, 48, 255}
+	case ElementConfigTypeText:
+		return "Text", Color{105, 210, 231, 255}
+	case ElementConfigTypeAspectRatio:
+		return "Aspect", Color{101, 149, 194, 255}
+	case ElementConfigTypeImage:
+		return "Image", Color{121, 189, 154, 255}
+	case ElementConfigTypeFloating:
+		return "Floating", Color{250, 105, 0, 255}
+	case ElementConfigTypeClip:
+		return "Scroll", Color{242, 196, 90, 255}
+	case ElementConfigTypeBorder:
+		return "Border", Color{108, 91, 123, 255}
+	case ElementConfigTypeCustom:
+		return "Custom", Color{11, 72, 107, 255}
+	}
+	return "Error", Color{0, 0, 0, 255}
+}
+
+// renderDebugView declares the debug tools panel as a floating element to the right of the root container.
+// It lists the element tree of the layout declared so far and shows details of the element selected by clicking its row.
+func (context *Context) renderDebugView() error {
+	closeButtonID := ID("Clay__DebugViewTopHeaderCloseButtonOuter")
+	if context.PointerInfo.State == PointerDataPressedThisFrame && context.PointerOver(closeButtonID) {
+		context.DebugModeEnabled = false
+		return nil
+	}
+	initialRootsLength := arrlen(context.LayoutElementTreeRoots)
+	initialElementsLength := arrlen(context.LayoutElements)
+	scrollID := ID("Clay__DebugViewOuterScrollPane")
+	var scrollYOffset floatn
+	pointerInDebugView := context.PointerInfo.Position.Y < context.LayoutDimensions.Height-300
+	for i := intn(0); i < arrlen(context.scrollContainerDatas); i++ {
+		scrollContainerData := &context.scrollContainerDatas[i]
+		if scrollContainerData.ElementID == scrollID.ID {
+			if !context.ExternalScrollHandlingEnabled {
+				scrollYOffset = scrollContainerData.ScrollPosition.Y
+			} else {
+				pointerInDebugView = context.PointerInfo.Position.Y+scrollContainerData.ScrollPosition.Y < context.LayoutDimensions.Height-300
+			}
+			break
+		}
+	}
+	highlightedRow := intn(-1)
+	if pointerInDebugView {
+		highlightedRow = intn((context.PointerInfo.Position.Y-scrollYOffset)/debugViewRowHeight) - 1
+	}
+	if context.PointerInfo.Position.X < context.LayoutDimensions.Width-debugViewWidth {
+		highlightedRow = -1
+	}
+	var layoutData debugLayoutData
+	return context.Clay(ElementDeclaration{
+		ID: ID("Clay__DebugView"),
+		Layout: LayoutConfig{
+			Sizing:          Sizing{Width: NewSizingAxis(SizingFixed, debugViewWidth), Height: NewSizingAxis(SizingFixed, context.LayoutDimensions.Height)},
+			LayoutDirection: TopToBottom,
+		},
+		Floating: FloatingElementConfig{
+			Zindex:       32765,
+			AttachPoints: FloatingAttachPoints{Element: AttachPointLeftCenter, Parent: AttachPointRightCenter},
+			AttachTo:     AttachToRoot,
+		},
+		Border: BorderElementConfig{Color: debugViewColor3, Width: BorderWidth{Bottom: 1}},
+	}, func(context *Context) error {
+		// Header with close button.
+		context.Clay(ElementDeclaration{
+			Layout: LayoutConfig{
+				Sizing:         Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingFixed, debugViewRowHeight)},
+				Padding:        Padding{Left: debugViewOuterPadding, Right: debugViewOuterPadding},
+				ChildAlignment: ChildAlignment{Y: AlignYCenter},
+			},
+			BackgroundColor: debugViewColor2,
+		}, func(context *Context) error {
+			context.Text("Clay Debug Tools", &debugViewInfoTextConfig)
+			context.debugViewSpacer()
+			return context.Clay(ElementDeclaration{
+				ID: closeButtonID,
+				Layout: LayoutConfig{
+					Sizing:         Sizing{Width: NewSizingAxis(SizingFixed, debugViewRowHeight-10), Height: NewSizingAxis(SizingFixed, debugViewRowHeight-10)},
+					ChildAlignment: ChildAlignment{X: AlignXCenter, Y: AlignYCenter},
+				},
+				BackgroundColor: Color{217, 91, 67, 80},
+				CornerRadius:    cornerRadiusAll(4),
+				Border:          BorderElementConfig{Color: Color{217, 91, 67, 255}, Width: debugViewLabelBorder},
+			}, func(context *Context) error {
+				return context.Text("x", &debugViewLabelConfig)
+			})
+		})
+		context.debugViewSeparator()
+
+		// Element tree list.
+		context.Clay(ElementDeclaration{
+			ID:     scrollID,
+			Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingGrow, 0, 0)}},
+			Clip:   ClipElementConfig{Horizontal: true, Vertical: true, ChildOffset: context.ScrollOffset(scrollID)},
+		}, func(context *Context) error {
+			backgroundColor := debugViewColor1
+			if (initialElementsLength+initialRootsLength)&1 == 0 {
+				backgroundColor = debugViewColor2
+			}
+			return context.Clay(ElementDeclaration{
+				Layout: LayoutConfig{
+					Sizing:          Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingGrow, 0, 0)},
+					LayoutDirection: TopToBottom,
+				},
+				BackgroundColor: backgroundColor,
+			}, func(context *Context) error {
+				panelContentsID := ID("Clay__DebugViewPaneOuter")
+				err := context.Clay(ElementDeclaration{
+					ID:     panelContentsID,
+					Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingGrow, 0, 0)}},
+					Floating: FloatingElementConfig{
+						Zindex:             32766,
+						PointerCaptureMode: PointerCaptureModePassthrough,
+						AttachTo:           AttachToParent,
+					},
+				}, func(context *Context) error {
+					return context.Clay(ElementDeclaration{
+						Layout: LayoutConfig{
+							Sizing:          Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingGrow, 0, 0)},
+							Padding:         Padding{Left: debugViewOuterPadding, Right: debugViewOuterPadding},
+							LayoutDirection: TopToBottom,
+						},
+					}, func(context *Context) (err error) {
+						layoutData, err = context.renderDebugLayoutElementsList(initialRootsLength, highlightedRow)
+						return err
+					})
+				})
+				if err != nil {
+					return err
+				}
+				// The element list floats, so an empty element of the same width sets the scroll pane's content width.
+				contentWidth := context.HashMapItem(panelContentsID.ID).LayoutElement.Dimensions.Width
+				context.Clay(ElementDeclaration{
+					Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, contentWidth)}, LayoutDirection: TopToBottom},
+				})
+				for i := intn(0); i < layoutData.rowCount; i++ {
+					rowColor := debugViewColor1
+					if i&1 == 0 {
+						rowColor = debugViewColor2
+					}
+					if i == layoutData.selectedElementRowIndex {
+						rowColor = debugViewColorSelectedRow
+					}
+					if i == highlightedRow {
+						rowColor.R *= 1.25
+						rowColor.G *= 1.25
+						rowColor.B *= 1.25
+					}
+					context.Clay(ElementDeclaration{
+						Layout: LayoutConfig{
+							Sizing:          Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingFixed, debugViewRowHeight)},
+							LayoutDirection: TopToBottom,
+						},
+						BackgroundColor: rowColor,
+					})
+				}
+				return nil
+			})
+		})
+		context.debugViewSeparator()
+
+		selectedItem := context.declaredHashMapItem(context.DebugSelectElementID)
+		if selectedItem != nil {
+			return context.renderDebugSelectedElement(selectedItem)
+		}
+		return context.Clay(ElementDeclaration{
+			ID: ID("Clay__DebugViewWarningsScrollPane"),
+			Layout: LayoutConfig{
+				Sizing:          Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingFixed, 300)},
+				ChildGap:        6,
+				LayoutDirection: TopToBottom,
+			},
+			BackgroundColor: debugViewColor2,
+			Clip:            ClipElementConfig{Horizontal: true, Vertical: true, ChildOffset: context.ScrollOffset(ID("Clay__DebugViewWarningsScrollPane"))},
+		}, func(context *Context) error {
+			context.Clay(ElementDeclaration{
+				ID: ID("Clay__DebugViewWarningItemHeader"),
+				Layout: LayoutConfig{
+					Sizing:         Sizing{Height: NewSizingAxis(SizingFixed, debugViewRowHeight)},
+					Padding:        Padding{Left: debugViewOuterPadding, Right: debugViewOuterPadding},
+					ChildGap:       8,
+					ChildAlignment: ChildAlignment{Y: AlignYCenter},
+				},
+			}, func(context *Context) error {
+				context.Text("Warnings", &debugViewInfoTextConfig)
+				if len(context.Warnings) > 0 {
+					context.debugViewLabel(strconv.Itoa(len(context.Warnings)), &debugViewLabelConfig, Color{177, 147, 8, 90}, Color{177, 147, 8, 255})
+				}
+				return nil
+			})
+			context.Clay(ElementDeclaration{
+				ID:              ID("Clay__DebugViewWarningsTopBorder"),
+				Layout:          LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingFixed, 1)}},
+				BackgroundColor: Color{200, 200, 200, 255},
+			})
+			for i := range context.Warnings {
+				warning := &context.Warnings[i]
+				context.Clay(ElementDeclaration{
+					ID: hashString("Clay__DebugViewWarningItem", uintn(i), 0),
+					Layout: LayoutConfig{
+						Sizing:          Sizing{Width: NewSizingAxis(SizingGrow, 0, 0)},
+						Padding:         Padding{Left: debugViewOuterPadding, Right: debugViewOuterPadding},
+						ChildGap:        4,
+						LayoutDirection: TopToBottom,
+					},
+				}, func(context *Context) error {
+					context.Text(warning.Kind.String(), &debugViewLabelConfig)
+					if warning.StringID != "" {
+						context.Text(warning.StringID, &debugViewInfoTitleConfig)
+					}
+					return nil
+				})
+			}
+			return nil
+		})
+	})
+}
+
+// renderDebugLayoutElementsList declares one row per element of the first initialRootsLength tree roots.
+// Nested elements are indented and collapsed elements do not list their children.
+func (context *Context) renderDebugLayoutElementsList(initialRootsLength, highlightedRowIndex intn) (layoutData debugLayoutData, err error) {
+	dfsBuffer := context.ReusableElementIndexBuffer[:0]
+	rowLayoutConfig := LayoutConfig{
+		Sizing:         Sizing{Height: NewSizingAxis(SizingFixed, debugViewRowHeight)},
+		ChildGap:       6,
+		ChildAlignment: ChildAlignment{Y: AlignYCenter},
+	}
+	var highlightedElementID uintn
+	for rootIndex := intn(0); rootIndex < initialRootsLength; rootIndex++ {
+		dfsBuffer = dfsBuffer[:0]
+		root := &context.LayoutElementTreeRoots[rootIndex]
+		dfsBuffer = arradd(dfsBuffer, root.LayoutElementIndex)
+		context.TreeNodeVisited[0] = false
+		if rootIndex > 0 {
+			context.Clay(ElementDeclaration{
+				ID: hashString("Clay__DebugView_EmptyRowOuter", uintn(rootIndex), 0),
+				Layout: LayoutConfig{
+					Sizing:  Sizing{Width: NewSizingAxis(SizingGrow, 0, 0)},
+					Padding: Padding{Left: debugViewIndentWidth / 2},
+				},
+			}, func(context *Context) error {
+				return context.Clay(ElementDeclaration{
+					ID:     hashString("Clay__DebugView_EmptyRow", uintn(rootIndex), 0),
+					Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingFixed, debugViewRowHeight)}},
+					Border: BorderElementConfig{Color: debugViewColor3, Width: BorderWidth{Top: 1}},
+				})
+			})
+			layoutData.rowCount++
+		}
+		for len(dfsBuffer) > 0 {
+			currentElementIndex := dfsBuffer[len(dfsBuffer)-1]
+			currentElement := &context.LayoutElements[currentElementIndex]
+			_, isText := currentElement.GetConfig(ElementConfigTypeText).(*TextElementConfig)
+			children := currentElement.Children()
+			if context.TreeNodeVisited[len(dfsBuffer)-1] {
+				if !isText && len(children) > 0 {
+					// Close the three indentation containers opened on the way down.
+					for i := 0; i < 3; i++ {
+						if err = context.closeElement(); err != nil {
+							return layoutData, err
+						}
+					}
+				}
+				dfsBuffer = dfsBuffer[:len(dfsBuffer)-1]
+				continue
+			}
+			if highlightedRowIndex == layoutData.rowCount {
+				if context.PointerInfo.State == PointerDataPressedThisFrame {
+					context.DebugSelectElementID = currentElement.ID
+				}
+				highlightedElementID = currentElement.ID
+			}
+			context.TreeNodeVisited[len(dfsBuffer)-1] = true
+			currentElementData := context.HashMapItem(currentElement.ID)
+			offscreen := currentElementData != nil && context.IsOffscreen(&currentElementData.BoundingBox)
+			if context.DebugSelectElementID == currentElement.ID {
+				layoutData.selectedElementRowIndex = layoutData.rowCount
+			}
+			labelConfig := &debugViewLabelConfig
+			nameConfig := &debugViewInfoTextConfig
+			if offscreen {
+				labelConfig = &debugViewDimLabelConfig
+				nameConfig = &debugViewDimLabelConfig
+			}
+			context.Clay(ElementDeclaration{
+				ID:     hashString("Clay__DebugView_ElementOuter", currentElement.ID, 0),
+				Layout: rowLayoutConfig,
+			}, func(context *Context) error {
+				// Collapse icon/button.
+				if !isText && len(children) > 0 {
+					context.Clay(ElementDeclaration{
+						ID: hashString("Clay__DebugView_CollapseElement", currentElement.ID, 0),
+						Layout: LayoutConfig{
+							Sizing:         Sizing{Width: NewSizingAxis(SizingFixed, 16), Height: NewSizingAxis(SizingFixed, 16)},
+							ChildAlignment: ChildAlignment{X: AlignXCenter, Y: AlignYCenter},
+						},
+						CornerRadius: cornerRadiusAll(4),
+						Border:       BorderElementConfig{Color: debugViewColor3, Width: debugViewLabelBorder},
+					}, func(context *Context) error {
+						if currentElementData != nil && currentElementData.debugData.collapsed {
+							return context.Text("+", &debugViewLabelConfig)
+						}
+						return context.Text("-", &debugViewLabelConfig)
+					})
+				} else {
+					// Square dot for empty containers.
+					context.Clay(ElementDeclaration{
+						Layout: LayoutConfig{
+							Sizing:         Sizing{Width: NewSizingAxis(SizingFixed, 16), Height: NewSizingAxis(SizingFixed, 16)},
+							ChildAlignment: ChildAlignment{X: AlignXCenter, Y: AlignYCenter},
+						},
+					}, func(context *Context) error {
+						return context.Clay(ElementDeclaration{
+							Layout:          LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, 8), Height: NewSizingAxis(SizingFixed, 8)}},
+							BackgroundColor: debugViewColor3,
+							CornerRadius:    cornerRadiusAll(2),
+						})
+					})
+				}
+				// Collisions and offscreen info.
+				if currentElementData != nil && currentElementData.debugData.collision {
+					context.debugViewLabel("Duplicate ID", &debugViewDimLabelConfig, Color{}, Color{177, 147, 8, 255})
+				}
+				if offscreen {
+					context.debugViewLabel("Offscreen", &debugViewDimLabelConfig, Color{}, debugViewColor3)
+				}
+				if currentElementData != nil && currentElementData.ElementID.StringID != "" {
+					context.Text(currentElementData.ElementID.StringID, nameConfig)
+				}
+				for i := range currentElement.ElementConfigs {
+					elementConfig := &currentElement.ElementConfigs[i]
+					if elementConfig.Type == ElementConfigTypeShared {
+						labelColor := Color{243, 134, 48, 90}
+						sharedConfig := elementConfig.Config.(*SharedElementConfig)
+						if sharedConfig.BackgroundColor.A > 0 {
+							context.debugViewLabel("Color", labelConfig, labelColor, labelColor)
+						}
+						if sharedConfig.CornerRadius != (CornerRadius{}) {
+							context.debugViewLabel("Radius", labelConfig, labelColor, labelColor)
+						}
+						continue
+					}
+					label, color := debugElementConfigTypeLabel(elementConfig.Type)
+					backgroundColor := color
+					backgroundColor.A = 90
+					context.debugViewLabel(label, labelConfig, backgroundColor, color)
+				}
+				return nil
+			})
+
+			if isText {
+				// Render the text contents below the element as a non-interactive row.
+				layoutData.rowCount++
+				textElementData := currentElement.textElementData
+				context.Clay(ElementDeclaration{
+					Layout: LayoutConfig{
+						Sizing:         Sizing{Height: NewSizingAxis(SizingFixed, debugViewRowHeight)},
+						ChildAlignment: ChildAlignment{Y: AlignYCenter},
+					},
+				}, func(context *Context) error {
+					context.Clay(ElementDeclaration{
+						Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, debugViewIndentWidth+16)}},
+					})
+					text := textElementData.Text
+					if len(textElementData.spans) > 0 {
+						text = textElementData.spans[0].Text // Rich text, show the first span.
+					}
+					if len(text) > debugViewMaxTextLength {
+						n := debugViewMaxTextLength
+						for n > 0 && !utf8.RuneStart(text[n]) {
+							n-- // Don't cut a multibyte character in half.
+						}
+						text = text[:n] + "..."
+					}
+					return context.Text(`"`+text+`"`, nameConfig)
+				})
+			} else if len(children) > 0 {
+				// Open indentation containers, closed when the DFS returns to this element.
+				context.openElement()
+				context.configureOpenElement(ElementDeclaration{Layout: LayoutConfig{Padding: Padding{Left: 8}}})
+				context.openElement()
+				context.configureOpenElement(ElementDeclaration{
+					Layout: LayoutConfig{Padding: Padding{Left: debugViewIndentWidth}},
+					Border: BorderElementConfig{Color: debugViewColor3, Width: BorderWidth{Left: 1}},
+				})
+				context.openElement()
+				context.configureOpenElement(ElementDeclaration{Layout: LayoutConfig{LayoutDirection: TopToBottom}})
+			}
+
+			layoutData.rowCount++
+			if !isText && (currentElementData == nil || !currentElementData.debugData.collapsed) {
+				for i := arrlen(children) - 1; i >= 0; i-- {
+					dfsBuffer = arradd(dfsBuffer, children[i])
+					context.TreeNodeVisited[len(dfsBuffer)-1] = false
+				}
+			}
+		}
+	}
+
+	if context.PointerInfo.State == PointerDataPressedThisFrame {
+		collapseButtonID := hashString("Clay__DebugView_CollapseElement", 0, 0)
+		for i := arrlen(context.PointerOverIDs) - 1; i >= 0; i-- {
+			elementID := context.PointerOverIDs[i]
+			if elementID.BaseID == collapseButtonID.BaseID {
+				// Collapse buttons are offset by the ID of the element they collapse.
+				highlightedItem := context.HashMapItem(elementID.Offset)
+				if highlightedItem != nil {
+					highlightedItem.debugData.collapsed = !highlightedItem.debugData.collapsed
+				}
+				break
+			}
+		}
+	}
+
+	if highlightedElementID != 0 {
+		err = context.Clay(ElementDeclaration{
+			ID:     ID("Clay__DebugView_ElementHighlight"),
+			Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingGrow, 0, 0)}},
+			Floating: FloatingElementConfig{
+				ParentID:           highlightedElementID,
+				Zindex:             32767,
+				PointerCaptureMode: PointerCaptureModePassthrough,
+				AttachTo:           AttachToElementWithID,
+			},
+		}, func(context *Context) error {
+			return context.Clay(ElementDeclaration{
+				ID:              ID("Clay__DebugView_ElementHighlightRectangle"),
+				Layout:          LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingGrow, 0, 0)}},
+				BackgroundColor: debugViewHighlightColor,
+			})
+		})
+	}
+	return layoutData, err
+}
+
+// renderDebugSelectedElement declares the details pane of the element selected in the debug view.
+func (context *Context) renderDebugSelectedElement(selectedItem *LayoutElementHashMapItem) error {
+	scrollID := ID("Clay__DebugViewElementInfoScrollPane")
+	selectedElement := selectedItem.LayoutElement
+	return context.Clay(ElementDeclaration{
+		ID: scrollID,
+		Layout: LayoutConfig{
+			Sizing:          Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingFixed, 300)},
+			LayoutDirection: TopToBottom,
+		},
+		BackgroundColor: debugViewColor2,
+		Clip:            ClipElementConfig{Vertical: true, ChildOffset: context.ScrollOffset(scrollID)},
+		Border:          BorderElementConfig{Color: debugViewColor3, Width: BorderWidth{BetweenChildren: 1}},
+	}, func(context *Context) error {
+		context.Clay(ElementDeclaration{
+			Layout: LayoutConfig{
+				Sizing:         Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingFixed, debugViewRowHeight+8)},
+				Padding:        Padding{Left: debugViewOuterPadding, Right: debugViewOuterPadding},
+				ChildAlignment: ChildAlignment{Y: AlignYCenter},
+			},
+		}, func(context *Context) error {
+			context.Text("Layout Config", &debugViewInfoTextConfig)
+			context.debugViewSpacer()
+			elementID := selectedItem.ElementID
+			if elementID.StringID != "" {
+				if elementID.Offset != 0 {
+					return context.Text(elementID.StringID+" ("+debugItoa(floatn(elementID.Offset))+")", &debugViewInfoTitleConfig)
+				}
+				return context.Text(elementID.StringID, &debugViewInfoTitleConfig)
+			}
+			return nil
+		})
+		layoutConfig := selectedElement.LayoutConfig
+		context.debugViewAttributes(func(context *Context) error {
+			bb := selectedItem.BoundingBox
+			context.debugViewAttribute("Bounding Box", "{ x: "+debugItoa(bb.X)+", y: "+debugItoa(bb.Y)+", width: "+debugItoa(bb.Width)+", height: "+debugItoa(bb.Height)+" }")
+			context.debugViewAttribute("Layout Direction", layoutConfig.LayoutDirection.String())
+			context.debugViewAttribute("Sizing", "width: "+debugSizingString(layoutConfig.Sizing.Width))
+			context.Text("height: "+debugSizingString(layoutConfig.Sizing.Height), &debugViewInfoTextConfig)
+			pd := layoutConfig.Padding
+			context.debugViewAttribute("Padding", "{ left: "+debugItoa(floatn(pd.Left))+", right: "+debugItoa(floatn(pd.Right))+", top: "+debugItoa(floatn(pd.Top))+", bottom: "+debugItoa(floatn(pd.Bottom))+" }")
+			mg := layoutConfig.Margin
+			context.debugViewAttribute("Margin", "{ left: "+debugItoa(floatn(mg.Left))+", right: "+debugItoa(floatn(mg.Right))+", top: "+debugItoa(floatn(mg.Top))+", bottom: "+debugItoa(floatn(mg.Bottom))+" }")
+			context.debugViewAttribute("Child Gap", debugItoa(floatn(layoutConfig.ChildGap)))
+			context.debugViewAttribute("Child Alignment", "{ x: "+layoutConfig.ChildAlignment.X.String()+", y: "+layoutConfig.ChildAlignment.Y.String()+" }")
+			context.debugViewAttribute("Self", layoutConfig.Self.String())
+			context.debugViewAttribute("Distribute", layoutConfig.Distribute.String())
+			context.debugViewAttribute("Wrap", strconv.FormatBool(layoutConfig.Wrap))
+			context.debugViewAttribute("Reverse", strconv.FormatBool(layoutConfig.Reverse))
+			if layoutConfig.LayoutDirection == Grid {
+				context.debugViewGridTracks("Grid Columns", layoutConfig.GridColumns)
+				context.debugViewGridTracks("Grid Rows", layoutConfig.GridRows)
+			}
+			context.debugViewAttribute("Span", "{ columns: "+debugItoa(floatn(layoutConfig.ColumnSpan))+", rows: "+debugItoa(floatn(layoutConfig.RowSpan))+" }")
+			return nil
+		})
+		for i := range selectedElement.ElementConfigs {
+			elementConfig := &selectedElement.ElementConfigs[i]
+			context.renderDebugViewElementConfigHeader(selectedItem.ElementID.StringID, elementConfig.Type)
+			switch config := elementConfig.Config.(type) {
+			case *SharedElementConfig:
+				context.debugViewAttributes(func(context *Context) error {
+					context.Text("Background Color", &debugViewInfoTitleConfig)
+					context.renderDebugViewColor(config.BackgroundColor)
+					context.debugViewAttribute("Corner Radius", debugCornerRadiusString(config.CornerRadius))
+					return nil
+				})
+			case *TextElementConfig:
+				context.debugViewAttributes(func(context *Context) error {
+					context.debugViewAttribute("Font Size", debugItoa(floatn(config.FontSize)))
+					context.debugViewAttribute("Font ID", debugItoa(floatn(config.FontID)))
+					context.debugViewAttribute("Line Height", debugItoa(floatn(config.LineHeight)))
+					context.debugViewAttribute("Letter Spacing", debugItoa(floatn(config.LetterSpacing)))
+					context.debugViewAttribute("Wrap Mode", config.WrapMode.String())
+					context.debugViewAttribute("Text Alignment", config.TextAlignment.String())
+					context.debugViewAttribute("Max Lines", debugItoa(floatn(config.MaxLines)))
+					context.debugViewAttribute("Overflow", config.Overflow.String())
+					context.Text("Text Color", &debugViewInfoTitleConfig)
+					context.renderDebugViewColor(config.TextColor)
+					return nil
+				})
+			case *AspectRatioElementConfig:
+				context.debugViewAttributes(func(context *Context) error {
+					context.debugViewAttribute("Aspect Ratio", strconv.FormatFloat(float64(config.AspectRatio), 'g', 4, 32))
+					return nil
+				})
+			case *ImageElementConfig:
+				context.debugViewAttributes(func(context *Context) error {
+					dim := config.SourceDimensions
+					context.debugViewAttribute("Image Dimensions", "{ width: "+debugItoa(dim.Width)+", height: "+debugItoa(dim.Height)+" }")
+					return nil
+				})
+			case *ClipElementConfig:
+				context.debugViewAttributes(func(context *Context) error {
+					context.debugViewAttribute("Vertical", strconv.FormatBool(config.Vertical))
+					context.debugViewAttribute("Horizontal", strconv.FormatBool(config.Horizontal))
+					return nil
+				})
+			case *FloatingElementConfig:
+				context.debugViewAttributes(func(context *Context) error {
+					context.debugViewAttribute("Offset", "{ x: "+debugItoa(config.Offset.X)+", y: "+debugItoa(config.Offset.Y)+" }")
+					context.debugViewAttribute("Expand", "{ width: "+debugItoa(config.Expand.Width)+", height: "+debugItoa(config.Expand.Height)+" }")
+					context.debugViewAttribute("z-index", strconv.Itoa(int(config.Zindex)))
+					context.debugViewAttribute("Parent", context.debugElementName(config.ParentID))
+					context.debugViewAttribute("Attach To", config.AttachTo.String())
+					return nil
+				})
+			case *BorderElementConfig:
+				context.debugViewAttributes(func(context *Context) error {
+					w := config.Width
+					context.debugViewAttribute("Border Widths", "{ left: "+debugItoa(floatn(w.Left))+", right: "+debugItoa(floatn(w.Right))+", top: "+debugItoa(floatn(w.Top))+", bottom: "+debugItoa(floatn(w.Bottom))+" }")
+					context.Text("Border Color", &debugViewInfoTitleConfig)
+					context.renderDebugViewColor(config.Color)
+					return nil
+				})
+			}
+		}
+		return nil
+	})
+}
+
+func (context *Context) renderDebugViewElementConfigHeader(elementID string, Type ElementConfigType) {
+	label, color := debugElementConfigTypeLabel(Type)
+	backgroundColor := color
+	backgroundColor.A = 90
+	context.Clay(ElementDeclaration{
+		Layout: LayoutConfig{
+			Sizing:         Sizing{Width: NewSizingAxis(SizingGrow, 0, 0)},
+			Padding:        PaddingAll(debugViewOuterPadding),
+			ChildAlignment: ChildAlignment{Y: AlignYCenter},
+		},
+	}, func(context *Context) error {
+		context.debugViewLabel(label, &debugViewLabelConfig, backgroundColor, color)
+		context.debugViewSpacer()
+		return context.Text(elementID, &debugViewInfoTitleConfig)
+	})
+}
+
+func (context *Context) renderDebugViewColor(color Color) {
+	context.Clay(ElementDeclaration{
+		Layout: LayoutConfig{ChildAlignment: ChildAlignment{Y: AlignYCenter}},
+	}, func(context *Context) error {
+		context.Text("{ r: "+debugItoa(color.R)+", g: "+debugItoa(color.G)+", b: "+debugItoa(color.B)+", a: "+debugItoa(color.A)+" }", &debugViewInfoTextConfig)
+		context.Clay(ElementDeclaration{Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, 10)}}})
+		return context.Clay(ElementDeclaration{
+			Layout:          LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, debugViewRowHeight-8), Height: NewSizingAxis(SizingFixed, debugViewRowHeight-8)}},
+			BackgroundColor: color,
+			CornerRadius:    cornerRadiusAll(4),
+			Border:          BorderElementConfig{Color: debugViewColor4, Width: debugViewLabelBorder},
+		})
+	})
+}
+
+// debugViewAttributes declares a padded vertical list container for the attributes of a configuration.
+func (context *Context) debugViewAttributes(declChildren func(context *Context) error) {
+	context.Clay(ElementDeclaration{
+		Layout: LayoutConfig{
+			Padding:         Padding{Left: debugViewOuterPadding, Right: debugViewOuterPadding, Top: 8, Bottom: 8},
+			ChildGap:        8,
+			LayoutDirection: TopToBottom,
+		},
+	}, declChildren)
+}
+
+// debugViewAttribute declares an attribute title followed by its value.
+func (context *Context) debugViewAttribute(title, value string) {
+	context.Text(title, &debugViewInfoTitleConfig)
+	context.Text(value, &debugViewInfoTextConfig)
+}
+
+// debugViewGridTracks declares an attribute listing the sizing of the tracks of a Grid element, one per line.
+func (context *Context) debugViewGridTracks(title string, tracks []SizingAxis) {
+	if len(tracks) == 0 {
+		context.debugViewAttribute(title, "fit content")
+		return
+	}
+	context.debugViewAttribute(title, debugSizingString(tracks[0]))
+	for _, track := range tracks[1:] {
+		context.Text(debugSizingString(track), &debugViewInfoTextConfig)
+	}
+}
+
+// debugViewLabel declares a bordered tag with text inside.
+func (context *Context) debugViewLabel(text string, textConfig *TextElementConfig, backgroundColor, borderColor Color) {
+	context.Clay(ElementDeclaration{
+		Layout:          LayoutConfig{Padding: debugViewLabelPadding},
+		BackgroundColor: backgroundColor,
+		CornerRadius:    cornerRadiusAll(4),
+		Border:          BorderElementConfig{Color: borderColor, Width: debugViewLabelBorder},
+	}, func(context *Context) error {
+		return context.Text(text, textConfig)
+	})
+}
+
+func (context *Context) debugViewSpacer() {
+	context.Clay(ElementDeclaration{Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0)}}})
+}
+
+func (context *Context) debugViewSeparator() {
+	context.Clay(ElementDeclaration{
+		Layout:          LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingFixed, 1)}},
+		BackgroundColor: debugViewColor3,
+	})
+}
+
+func (context *Context) debugElementName(id uintn) string {
+	item := context.HashMapItem(id)
+	if item == nil || item.ElementID.StringID == "" {
+		return debugItoa(floatn(id))
+	}
+	return item.ElementID.StringID
+}
+
+func debugSizingString(sizing SizingAxis) string {
+	label := sizing.Type.String()
+	switch sizing.Type {
+	case SizingPercent:
+		return label + " (" + debugItoa(sizing.Percent*100) + "%)"
+	case SizingFixed:
+		return label + " (" + debugItoa(sizing.MinMax.Min) + ")"
+	}
+	var minmax string
+	if sizing.MinMax.Min != 0 {
+		minmax = "min: " + debugItoa(sizing.MinMax.Min)
+	}
+	if sizing.MinMax.Max != 0 && sizing.MinMax.Max != maxfloat {
+		if minmax != "" {
+			minmax += ", "
+		}
+		minmax += "max: " + debugItoa(sizing.MinMax.Max)
+	}
+	if minmax == "" {
+		return label
+	}
+	return label + " (" + minmax + ")"
+}
+
+func debugCornerRadiusString(radius CornerRadius) string {
+	return "{ topLeft: " + debugItoa(radius.TopLeft) + ", topRight: " + debugItoa(radius.TopRight) +
+		", bottomLeft: " + debugItoa(radius.BottomLeft) + ", bottomRight: " + debugItoa(radius.BottomRight) + " }"
+}
+
+func debugItoa(v floatn) string {
+	return strconv.Itoa(int(v))
+}
+
+func cornerRadiusAll(radius floatn) CornerRadius {
+	return CornerRadius{TopLeft: radius, TopRight: radius, BottomLeft: radius, BottomRight: radius}
+}
@@ -0,0 +1,177 @@
+//go:build ignore
+
+// gen_bidi generates bidi_tables.go from the Unicode Character Database.
+// It reads extracted/DerivedBidiClass.txt and BidiBrackets.txt from -ucd, which is
+// either a URL or a local directory and defaults to the UCD of -version on unicode.org.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const maxRune = 0x10FFFF
+
+func main() {
+	version := flag.String("version", "15.0.0", "Unicode version")
+	ucd := flag.String("ucd", "", "URL or directory of the Unicode Character Database, defaults to unicode.org for -version")
+	output := flag.String("o", "bidi_tables.go", "output file")
+	flag.Parse()
+	if *ucd == "" {
+		*ucd = "https://www.unicode.org/Public/" + *version + "/ucd"
+	}
+
+	classes := make([]string, maxRune+1)
+	err := parse(*ucd, "extracted/DerivedBidiClass.txt", func(fields []string) error {
+		lo, hi, err := parseRange(fields[0])
+		if err != nil {
+			return err
+		}
+		for r := lo; r <= hi; r++ {
+			classes[r] = fields[1]
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	var brackets bytes.Buffer
+	err = parse(*ucd, "BidiBrackets.txt", func(fields []string) error {
+		if len(fields) < 3 {
+			return fmt.Errorf("missing fields")
+		}
+		r, _, err := parseRange(fields[0])
+		if err != nil {
+			return err
+		}
+		pair, _, err := parseRange(fields[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&brackets, "\t{0x%04X, 0x%04X, %t},\n", r, pair, fields[2] == "o")
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gen_bidi.go from Unicode %s data. DO NOT EDIT.\n\n", *version)
+	buf.WriteString("package glay\n\n")
+	buf.WriteString("// bidiClassRanges holds the Bidi_Class of all code points that are not of class L, sorted.\n")
+	buf.WriteString("var bidiClassRanges = [...]bidiClassRange{\n")
+	start, current := rune(0), class(classes[0])
+	for r := rune(1); r <= maxRune+1; r++ {
+		var c string
+		if r <= maxRune {
+			c = class(classes[r])
+			if c == current {
+				continue
+			}
+		}
+		if current != "L" {
+			fmt.Fprintf(&buf, "\t{0x%04X, 0x%04X, bc%s},\n", start, r-1, current)
+		}
+		start, current = r, c
+	}
+	buf.WriteString("}\n\n")
+	buf.WriteString("// bidiBrackets holds the paired brackets of Bidi_Paired_Bracket_Type Open or Close, sorted.\n")
+	buf.WriteString("var bidiBrackets = [...]bidiBracket{\n")
+	buf.Write(brackets.Bytes())
+	buf.WriteString("}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = os.WriteFile(*output, src, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// class returns the Bidi_Class of a code point not listed in DerivedBidiClass.txt as L,
+// all other defaults are given by its "# @missing:" lines.
+func class(c string) string {
+	if c == "" {
+		return "L"
+	}
+	return c
+}
+
+// parse calls fn with the fields of every data line of a UCD file. Default values given by
+// "# @missing:" lines are reported before the data lines that override them.
+func parse(ucd, name string, fn func(fields []string) error) error {
+	var rd io.ReadCloser
+	if strings.HasPrefix(ucd, "http://") || strings.HasPrefix(ucd, "https://") {
+		resp, err := http.Get(ucd + "/" + name)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("%s: %s", name, resp.Status)
+		}
+		rd = resp.Body
+	} else {
+		fp, err := os.Open(filepath.Join(ucd, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		rd = fp
+	}
+	defer rd.Close()
+	scanner := bufio.NewScanner(rd)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if missing, ok := strings.CutPrefix(line, "# @missing:"); ok {
+			line = missing
+		} else if strings.HasPrefix(line, "#") {
+			continue
+		}
+		data, _, _ := strings.Cut(line, "#")
+		if strings.TrimSpace(data) == "" {
+			continue
+		}
+		fields := strings.Split(data, ";")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if len(fields) < 2 {
+			return fmt.Errorf("%s:%d: missing fields", name, lineNum)
+		}
+		err := fn(fields)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", name, lineNum, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func parseRange(s string) (lo, hi rune, err error) {
+	first, last, isRange := strings.Cut(s, "..")
+	l, err := strconv.ParseUint(first, 16, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	h := l
+	if isRange {
+		h, err = strconv.ParseUint(last, 16, 32)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if l > h || h > maxRune {
+		return 0, 0, fmt.Errorf("invalid code point range %q", s)
+	}
+	return rune(l), rune(h), nil
+}
@@ -1,6 +1,8 @@
 package glay
 
-//go:generate stringer -linecomment -output=stringers.go -type=ElementConfigType,LayoutDirection,LayoutAlignmentX,LayoutAlignmentY,SizingType,TextElementConfigWrapMode,TextAlignment,FloatingAttachPointType,MousePointerCaptureMode,FloatingAttachToElement,RenderCommandType,Error
+import "unsafe"
+
+//go:generate stringer -linecomment -output=stringers.go -type=ElementConfigType,LayoutDirection,LayoutAlignmentX,LayoutAlignmentY,SizingType,TextElementConfigWrapMode,TextAlignment,TextOverflow,FloatingAttachPointType,MousePointerCaptureMode,FloatingAttachToElement,RenderCommandType,Error,WarningKind
 
 // Internal clay types to better match the source and also
 // allow easily switching between a 32-bit implementation or 64-bit.
@@ -11,13 +13,21 @@ type (
 	strslice = []byte // Non-owning string slice
 )
 
+// unsafeStrslice returns the bytes of s without copying. The result must not be modified.
+func unsafeStrslice(s string) strslice {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
 func ID(name string) ElementID {
 	return hashString(name, 0, 0)
 }
 
 type Context struct {
-	MaxElementCount               intn
-	MaxMeasureTextCacheWordCount  intn
+	MaxElementCount              intn
+	MaxMeasureTextCacheWordCount intn
+	// MeasureTextCacheMaxAge is the number of layouts a cached text measurement is kept
+	// without being used. Zero means the default of 2.
+	MeasureTextCacheMaxAge        uintn
 	WarningsEnabled               bool
 	PointerInfo                   MousePointerData
 	LayoutDimensions              Dimensions
@@ -29,9 +39,21 @@ type Context struct {
 	DebugSelectElementID          uintn
 	Generation                    uintn
 	MeasureTextFunction           func(text string, config *TextElementConfig, userData any) Dimensions
-	MeasureTextUserData           any
+	// MeasureTextMetricsFunction, if set, is used instead of MeasureTextFunction to measure
+	// the words of text together with the ascent and descent of their font.
+	MeasureTextMetricsFunction func(text string, config *TextElementConfig, userData any) TextMetrics
+	MeasureTextUserData        any
+	// BatchTextMeasurer, if set, measures the words of each text not yet in the measurement cache
+	// with a single call. Other measurements go through MeasureTextFunction, or through the
+	// BatchTextMeasurer one string at a time if MeasureTextFunction is nil.
+	BatchTextMeasurer         BatchTextMeasurer
 	QueryScrollOffsetFunction func(elementID uint32, userData any) Vector2
 	QueryScrollOffsetUserData any
+	// Mirrored lays out elements declared while it is set mirrored horizontally, for right-to-left
+	// locales. Their Padding, Margin, borders, corner radii, X alignment and floating attach points
+	// and offsets swap left for right, and LeftToRight and Grid elements toggle Reverse, which
+	// reverses their X alignment. Text keeps the direction of its contents.
+	Mirrored bool
 	// Layout elements / render commands
 	LayoutElements              []LayoutElement
 	renderCommands              []RenderCommand
@@ -50,12 +72,21 @@ type Context struct {
 	ImageElementConfigs       []ImageElementConfig
 	FloatingElementConfigs    []FloatingElementConfig
 	ClipElementConfigs        []ClipElementConfig
-	CustomElementConfigs      []any
+	CustomElementConfigs      []CustomElementConfig
 	BorderElementConfigs      []BorderElementConfig
 	SharedElementConfigs      []SharedElementConfig
 	// Misc Data Structures.
-	LayoutElementIDStrings             []string
-	WrappedTextLines                   []WrappedTextLine
+	LayoutElementIDStrings []string
+	WrappedTextLines       []WrappedTextLine
+	wrappedTextFragments   []wrappedTextFragment
+	textSpans              []textSpan
+	textRuns               []textRun
+	gridTracks             []floatn
+	gridFreeRows           []intn // First free row of each column of the Grid element being placed.
+	// Bidirectional text state, allocated on the first text with right-to-left characters.
+	bidi bidiText
+	// Widths of the reordered runs of bidirectional text, see textRunWidthCached.
+	textRunWidths                      []textRunWidth
 	LayoutElementTreeNodes1            []layoutElementTreeNode
 	LayoutElementTreeRoots             []layoutElementTreeRoot
 	measureTextHashMapInternal         []measureTextCacheItem
@@ -63,13 +94,29 @@ type Context struct {
 	measureTextHashMap                 []intn
 	measuredWords                      []measuredWord
 	measuredWordsFreeList              []intn
+	textCacheStats                     TextCacheStats
+	textBatch                          textBatch
+	sweptGeneration                    uintn // Generation of the last sweep of the text measurement cache.
 	openClipElementStack               []intn
 	PointerOverIDs                     []ElementID
 	scrollContainerDatas               []scrollContainerDataInternal
 	TreeNodeVisited                    []bool
 	DynamicStringData                  []byte
-	debugElementData                   []debugElementData
-	GoHash                             map[uintn]*LayoutElementHashMapItem
+	// Warnings raised during the last layout. Reset by BeginLayout.
+	Warnings                  []Warning
+	booleanWarnings           booleanWarnings
+	renderCommandScissorDepth intn
+	// Render data referenced by renderCommands, reset with them every layout.
+	rectangleRenderData []RectangleRenderData
+	borderRenderData    []BorderRenderData
+	textRenderData      []TextRenderData
+	imageRenderData     []ImageRenderData
+	clipRenderData      []ClipRenderData
+	customRenderData    []CustomRenderData
+	// Open-addressed index of element IDs into layoutElementHashMapInternal. A slot holds the item index plus one, 0 is empty.
+	layoutElementHashMap         []intn
+	layoutElementHashMapInternal []LayoutElementHashMapItem
+	layoutElementHashMapFreelist []intn
 	logger
 }
 
@@ -93,6 +140,8 @@ const (
 	ElementConfigTypeShared                               // element config shared
 )
 
+// GetElementConfigType returns the type of an element configuration pointer such as *BorderElementConfig.
+// ElementConfigTypeNone is returned for values that are not element configurations.
 func GetElementConfigType(a any) (Type ElementConfigType) {
 	switch a.(type) {
 	case *BorderElementConfig:
@@ -109,8 +158,10 @@ func GetElementConfigType(a any) (Type ElementConfigType) {
 		Type = ElementConfigTypeText
 	case *SharedElementConfig:
 		Type = ElementConfigTypeShared
+	case *CustomElementConfig:
+		Type = ElementConfigTypeCustom
 	default:
-		panic("invalid element Config, make sure it is pointer type")
+		Type = ElementConfigTypeNone
 	}
 	return Type
 }
@@ -123,20 +174,90 @@ type ElementConfig struct {
 type WrappedTextLine struct {
 	Dimensions Dimensions
 	Line       string
+	// WordSpacing is the extra width of each run of breaking spaces between the words
+	// of a justified line, such that the line fills the width of its element.
+	WordSpacing floatn
+	// Byte offset of the line in the text of the element. The text of a rich text
+	// element is the concatenation of the text of its spans.
+	offset intn
+	// Parts of the line of a rich text element, whose Line is empty.
+	fragments []wrappedTextFragment
+	// Height of the tallest span on a line of rich text, or of its ascent and descent if greater.
+	naturalHeight floatn
+	// Greatest ascent of the spans on a line of rich text, which are aligned on their baseline.
+	ascent floatn
+	// Set on a line shortened by TextElementConfig.Overflow, whose Line is the text before the
+	// ellipsis and suffix the text kept after it, both parts of the text of the element.
+	ellipsis bool
+	suffix   string
+	// Offsets of the ellipsis and suffix from the start of a shortened line.
+	ellipsisX, suffixX floatn
+}
+
+// wrappedTextFragment is the part of a rich text span on a wrapped line.
+type wrappedTextFragment struct {
+	Line  string
+	Span  intn   // Index into the spans of the text element.
+	X     floatn // Offset from the start of the line.
+	Width floatn
+	// Byte offset of Line in the text of the element.
+	offset intn
+	// Runs of breaking spaces on the line before the fragment, used to justify the line.
+	spaces intn
 }
+
 type TextElementData struct {
 	Text                string
 	PreferredDimensions Dimensions
 	ElementIndex        intn
 	WrappedLines        []WrappedTextLine
+	spans               []textSpan // Set for rich text elements only, Text is empty.
+	rightToLeft         bool       // Text has right-to-left characters and is reordered when rendered.
+	// Distance from the top of the element to the baseline of its first line.
+	baseline floatn
+}
+
+// TextSpan is a run of text with its own style in a rich text element, see [Context.RichText].
+type TextSpan struct {
+	Text   string
+	Config *TextElementConfig
+	// UserData is set on the render commands of the span.
+	UserData any
+}
+
+// textSpan is a TextSpan of a rich text element with its configuration stored in the Context.
+type textSpan struct {
+	Text     string
+	Config   *TextElementConfig
+	UserData any
+	Height   floatn // Measured height of the span text.
+	Ascent   floatn // Measured ascent and descent of the span text.
+	Descent  floatn
+	offset   intn // Byte offset of the span in the text of the element.
+	words    intn // First measured word, looked up when wrapping.
 }
 type LayoutElement struct {
-	ChildrenOrTextContent any // Is either []intn when children or TextContent.
-	Dimensions            Dimensions
-	MinDimensions         Dimensions
-	LayoutConfig          *LayoutConfig
-	ElementConfigs        []ElementConfig
-	ID                    uintn
+	children        []intn           // Indices into Context.LayoutElements, set when the element is closed.
+	childCount      intn             // Number of children declared so far.
+	textElementData *TextElementData // Set for text elements only.
+	wrapBreak       bool             // Starts a new line of children in a wrapping parent.
+	gridArea        gridArea         // Cells taken up in a Grid parent.
+	mirrored        bool             // Declared while Context.Mirrored was set.
+	gridTracks      []floatn         // Sizes of the column tracks followed by the row tracks of a Grid element.
+	Dimensions      Dimensions
+	MinDimensions   Dimensions
+	LayoutConfig    *LayoutConfig
+	ElementConfigs  []ElementConfig
+	ID              uintn
+
+	// Deprecated: Use Children for the children of an element. ChildrenOrTextContent is only set
+	// to the *TextElementData of text elements since storing children in it allocated on every layout.
+	ChildrenOrTextContent any
+}
+
+// gridArea is the cells taken up by a child of a Grid element.
+type gridArea struct {
+	column, row, columns, rows intn
 }
 
 type layoutElementTreeNode struct {
@@ -157,9 +278,9 @@ type LayoutElementHashMapItem struct {
 	LayoutElement   *LayoutElement
 	OnHover         func(_ ElementID, _ MousePointerData, userData any)
 	OnHoverUserData any
-	NextIndex       intn
 	Generation      uintn
 	IDAlias         uintn
+	debugData       debugElementData
 }
 
 func (hmi LayoutElementHashMapItem) isdefault() bool {
@@ -199,6 +320,8 @@ type LayoutDirection uint8
 const (
 	LeftToRight LayoutDirection = iota // left to right
 	TopToBottom                        // top to bottom
+	// Grid places children in the cells of the tracks of LayoutConfig.GridColumns and GridRows.
+	Grid // grid
 )
 
 type LayoutAlignmentX uint8
@@ -209,12 +332,94 @@ const (
 	AlignXCenter                         // align x center
 )
 
+// mirrored returns the alignment with left and right swapped.
+func (a LayoutAlignmentX) mirrored() LayoutAlignmentX {
+	switch a {
+	case AlignXLeft:
+		return AlignXRight
+	case AlignXRight:
+		return AlignXLeft
+	}
+	return a
+}
+
 type LayoutAlignmentY uint8
 
 const (
 	AlignYTop    LayoutAlignmentY = iota // align y top
 	AlignYBottom                         // align y bottom
 	AlignYCenter                         // align y center
+	// AlignYBaseline aligns the baselines of the first line of text of the children of a
+	// LeftToRight element, see [TextMetrics]. Children without text are aligned
+	// on their bottom edge. It is the same as AlignYTop in TopToBottom elements.
+	AlignYBaseline // align y baseline
+)
+
+// flipped returns the alignment with top and bottom swapped. Baseline alignment is top alignment.
+func (a LayoutAlignmentY) flipped() LayoutAlignmentY {
+	switch a {
+	case AlignYTop, AlignYBaseline:
+		return AlignYBottom
+	case AlignYBottom:
+		return AlignYTop
+	}
+	return a
+}
+
+// SelfAlignment overrides the ChildAlignment of the parent of an element across the parent's layout axis.
+type SelfAlignment uint8
+
+const (
+	AlignSelfAuto   SelfAlignment = iota // align self auto
+	AlignSelfStart                       // align self start
+	AlignSelfCenter                      // align self center
+	AlignSelfEnd                         // align self end
+)
+
+// alignX returns the X alignment of an element whose parent aligns its children with alignment.
+// The start of a mirrored element is its right.
+func (sa SelfAlignment) alignX(alignment LayoutAlignmentX, mirrored bool) LayoutAlignmentX {
+	switch sa {
+	case AlignSelfStart:
+		alignment = AlignXLeft
+	case AlignSelfCenter:
+		return AlignXCenter
+	case AlignSelfEnd:
+		alignment = AlignXRight
+	default:
+		return alignment
+	}
+	if mirrored {
+		return alignment.mirrored()
+	}
+	return alignment
+}
+
+// alignY returns the Y alignment of an element whose parent aligns its children with alignment.
+func (sa SelfAlignment) alignY(alignment LayoutAlignmentY) LayoutAlignmentY {
+	switch sa {
+	case AlignSelfStart:
+		return AlignYTop
+	case AlignSelfCenter:
+		return AlignYCenter
+	case AlignSelfEnd:
+		return AlignYBottom
+	}
+	return alignment
+}
+
+// LayoutDistribution spreads the space left along the layout axis of an element between its children.
+type LayoutDistribution uint8
+
+const (
+	DistributeNone LayoutDistribution = iota // distribute none
+	// DistributeBetween puts equal space between children, none before the first or after the last.
+	DistributeBetween // distribute between
+	// DistributeAround puts equal space on both sides of every child, so the space between
+	// children is twice that before the first and after the last.
+	DistributeAround // distribute around
+	// DistributeEvenly puts equal space between children and before the first and after the last.
+	DistributeEvenly // distribute evenly
 )
 
 type SizingType uint8
@@ -308,6 +513,61 @@ type LayoutConfig struct {
 	ChildGap        uint16
 	ChildAlignment  ChildAlignment
 	LayoutDirection LayoutDirection
+	// Distribute spreads the space left along the layout axis between children, on top of ChildGap,
+	// instead of aligning them with ChildAlignment. Each line of a wrapping element is spread on its
+	// own. It has no effect on Grid elements.
+	Distribute LayoutDistribution
+	// GridColumns and GridRows size the column and row tracks of a Grid element like the Sizing
+	// of an element, percentages being of the element size. Children are placed in order in the
+	// first free cells, row by row. Rows past GridRows fit their content. ChildGap separates tracks
+	// and ChildAlignment aligns children within their cells.
+	GridColumns []SizingAxis
+	GridRows    []SizingAxis
+	// Self aligns the element across the layout axis of its parent instead of the parent's ChildAlignment,
+	// or within its cells on both axes in a Grid parent. Start is the top, and the left or the right of
+	// elements declared while Context.Mirrored is set.
+	Self SelfAlignment
+	// Margin is space kept around the element by its parent, on top of the parent's Padding and ChildGap.
+	// SizingPercent is a percentage of the space left after the margin. It has no effect on root
+	// and floating elements.
+	Margin Padding
+	// ColumnSpan and RowSpan are the number of cells taken up by the element in a Grid parent.
+	// Zero is one cell.
+	ColumnSpan uint16
+	RowSpan    uint16
+	// Wrap breaks children that do not fit along the layout axis onto new lines, spaced by ChildGap.
+	// Each line distributes its leftover space to its own SizingGrow children and ChildAlignment
+	// aligns the children within each line and the lines within the element. Widths of the children
+	// of a TopToBottom element are sized to columns broken with the heights of children before
+	// text is wrapped, children whose height changes afterwards may end up in another column.
+	// Wrap has no effect on Grid elements, whose children are placed in cells.
+	Wrap bool
+	// Reverse places children from the end of the layout axis: right to left in LeftToRight elements,
+	// bottom to top in TopToBottom elements and columns right to left in Grid elements. Lines of
+	// wrapping elements keep their order. ChildAlignment along the layout axis is reversed with the
+	// children, AlignXLeft packs the children of a reversed LeftToRight element against its right edge.
+	Reverse bool
+}
+
+// alignX returns the alignment of children along X, from the right in reversed LeftToRight elements.
+func (layoutConfig *LayoutConfig) alignX() LayoutAlignmentX {
+	if layoutConfig.Reverse && layoutConfig.LayoutDirection == LeftToRight {
+		return layoutConfig.ChildAlignment.X.mirrored()
+	}
+	return layoutConfig.ChildAlignment.X
+}
+
+// alignY returns the alignment of children along Y, from the bottom in reversed TopToBottom elements.
+func (layoutConfig *LayoutConfig) alignY() LayoutAlignmentY {
+	if layoutConfig.Reverse && layoutConfig.LayoutDirection == TopToBottom {
+		return layoutConfig.ChildAlignment.Y.flipped()
+	}
+	return layoutConfig.ChildAlignment.Y
+}
+
+// wraps reports whether the children of the element are broken into lines, see Wrap.
+func (layoutConfig *LayoutConfig) wraps() bool {
+	return layoutConfig.Wrap && layoutConfig.LayoutDirection != Grid
 }
 
 type TextElementConfigWrapMode uint8
@@ -320,10 +580,28 @@ const (
 
 type TextAlignment uint8
 
+// Text alignment is relative to the direction of the paragraph: TextAlignLeft aligns lines of
+// right-to-left paragraphs to the right and TextAlignRight to the left.
 const (
 	TextAlignLeft   TextAlignment = iota // text align left
 	TextAlignCenter                      // text align center
 	TextAlignRight                       // text align right
+	// TextAlignJustify stretches the spaces between words so that every wrapped line
+	// fills the element, except for the last line and lines ending with a newline.
+	TextAlignJustify // text align justify
+)
+
+// TextOverflow selects how text that exceeds TextElementConfig.MaxLines or the width of its element is shortened.
+type TextOverflow uint8
+
+const (
+	// TextOverflowClip drops the lines beyond MaxLines and leaves overlong lines as they are.
+	TextOverflowClip TextOverflow = iota // text overflow clip
+	// TextOverflowEllipsis ends the final line with an ellipsis.
+	TextOverflowEllipsis // text overflow ellipsis
+	// TextOverflowEllipsisMiddle replaces the middle of the final line with an ellipsis, keeping
+	// the start of the line and the end of its paragraph. It is meant for single line text such as file paths.
+	TextOverflowEllipsisMiddle // text overflow ellipsis middle
 )
 
 type TextElementConfig struct {
@@ -334,6 +612,12 @@ type TextElementConfig struct {
 	LineHeight    uint16
 	WrapMode      TextElementConfigWrapMode
 	TextAlignment TextAlignment
+	// MaxLines limits the number of wrapped lines. Zero means no limit.
+	MaxLines uint16
+	// Overflow sets how text that does not fit is shortened. With an ellipsis the text
+	// element may also shrink below the width of its longest word. The parts of a shortened
+	// line before and after the ellipsis and the ellipsis are separate render commands.
+	Overflow TextOverflow
 }
 
 type AspectRatioElementConfig struct {
@@ -345,6 +629,12 @@ type ImageElementConfig struct {
 	SourceDimensions Dimensions
 }
 
+// CustomElementConfig marks an element as custom. The element is emitted as a
+// RenderCommandTypeCustom command carrying CustomData for the renderer to draw.
+type CustomElementConfig struct {
+	CustomData any
+}
+
 type FloatingAttachPointType uint8
 
 const (
@@ -359,6 +649,12 @@ const (
 	AttachPointRightBottom                                 // attach right bottom
 )
 
+// mirrored returns the attach point on the other side of the element horizontally.
+func (ap FloatingAttachPointType) mirrored() FloatingAttachPointType {
+	column, row := ap/3, ap%3
+	return (2-column)*3 + row
+}
+
 type FloatingAttachPoints struct {
 	Element FloatingAttachPointType
 	Parent  FloatingAttachPointType
@@ -414,12 +710,20 @@ type BorderElementConfig struct {
 }
 
 type TextRenderData struct {
+	// Contents shares memory with the text given to Text or RichText and must not be modified.
 	Contents      strslice
 	TextColor     Color
 	FontID        uint16
 	FontSize      uint16
 	LetterSpacing uint16
 	LineHeight    uint16
+	// WordSpacing is extra width to add to each run of breaking spaces in Contents,
+	// set on lines of justified text. See [TextAlignJustify].
+	WordSpacing floatn
+	// RightToLeft is set on runs of right-to-left text, whose Contents are in logical order
+	// and must be drawn from the right edge of the bounding box. Bidirectional text is split
+	// into runs of a single direction, emitted in visual order.
+	RightToLeft bool
 }
 
 type ImageRenderData struct {
@@ -461,6 +765,9 @@ type ElementData struct {
 	Found       bool
 }
 
+// RenderCommandType identifies the kind of a RenderCommand. ScissorStart and ScissorEnd
+// commands are always balanced and nest. Both carry the effective scissor rectangle in their
+// BoundingBox, which is already intersected with all enclosing scissor rectangles.
 type RenderCommandType uint8
 
 const (
@@ -507,6 +814,7 @@ type ElementDeclaration struct {
 	Floating        FloatingElementConfig
 	Clip            ClipElementConfig
 	Border          BorderElementConfig
+	Custom          CustomElementConfig
 	UserData        any
 }
 
@@ -517,6 +825,7 @@ const (
 	ErrPercentageOver1                                 // an element was configured with CLAY_SIZING_PERCENT, but the provided percentage value was over 1.0. Clay expects a value between 0 and 1, i.e. 20% is 0.2
 	ErrFloatingContainerParentNotFound                 // a floating element was declared with a parentId, but no element with that ID was found
 	ErrElementsCapacityExceeded                        // Clay ran out of capacity while attempting to create render commands. This is usually caused by a large amount of wrapping text elements while close to the max element capacity. Try using Clay_SetMaxElementCount() with a higher value
+	ErrTextMeasurementCapacityExceeded                 // Clay ran out of capacity in its internal text measurement cache. Try using Clay_SetMaxMeasureTextCacheWordCount() with a higher value
 	// ErrArenaCapacityExceeded                           // Clay attempted to allocate memory in its arena, but ran out of capacity. Try increasing the capacity of the arena passed to Clay_Initialize()
 )
 
@@ -524,22 +833,119 @@ func (e Error) Error() string {
 	return e.String()
 }
 
+// WarningKind identifies the problem reported by a [Warning].
+type WarningKind uint8
+
+const (
+	WarningDuplicateID                   WarningKind = iota // an element with this ID was already declared in the current layout
+	WarningFloatingParentNotFound                           // a floating element was declared with a parentId, but no element with that ID was found
+	WarningPercentageOver1                                  // an element was configured with SizingPercent, but the provided percentage value was over 1.0
+	WarningElementsCapacityExceeded                         // ran out of element capacity, try increasing MaxElementCount
+	WarningTextMeasurementCacheExhausted                    // ran out of text measurement cache capacity, try increasing MaxMeasureTextCacheWordCount
+	WarningBidiTextTooLong                                  // text is too long to be reordered for display and is displayed in logical order
+)
+
+// Warning is a non-fatal problem found while declaring or calculating a layout.
+type Warning struct {
+	Kind WarningKind
+	// StringID is the string ID of the offending element, if known.
+	StringID string
+	// Generation is the Context.Generation of the layout the warning was raised in.
+	Generation uintn
+}
+
+func (w Warning) String() string {
+	if w.StringID == "" {
+		return w.Kind.String()
+	}
+	return w.Kind.String() + ": " + w.StringID
+}
+
+// booleanWarnings record capacity overflows during the current layout.
+// Each is only reported once per layout.
+type booleanWarnings struct {
+	maxElementsExceeded         bool
+	maxRenderCommandsExceeded   bool
+	maxTextMeasureCacheExceeded bool
+	bidiTextTooLong             bool
+}
+
 type debugElementData struct {
 	collision, collapsed bool
 }
 
 type measuredWord struct {
 	StartOffset intn
-	Length      intn
-	Width       floatn
+	// Length in bytes, including the trailing breaking space if any.
+	Length intn
+	Width  floatn
+	// SpaceLength and SpaceWidth are the byte length and width of the trailing breaking space,
+	// which is trimmed when the word ends a wrapped line.
+	SpaceLength intn
+	SpaceWidth  floatn
 	Next        intn
 }
 
+// TextMetrics are the dimensions of measured text and the vertical metrics of its font,
+// see Context.MeasureTextMetricsFunction. Ascent and Descent are the distances from the baseline
+// to the top and bottom of the text. Text measured without metrics has its baseline at the bottom.
+type TextMetrics struct {
+	Dimensions
+	Ascent, Descent floatn
+}
+
+// BatchTextMeasurer measures many strings of the same configuration in one call, which saves the
+// per call overhead of text shapers. See Context.BatchTextMeasurer.
+type BatchTextMeasurer interface {
+	// MeasureTextBatch sets dimensions[i] to the dimensions of texts[i] rendered with config.
+	// The slices are reused by the Context and must not be retained after the call returns.
+	// Text measured in a batch has its baseline at the bottom, see [TextMetrics], unless the
+	// measurer is also a BatchTextMetricsMeasurer.
+	MeasureTextBatch(texts []string, config *TextElementConfig, dimensions []Dimensions)
+}
+
+// BatchTextMetricsMeasurer is a BatchTextMeasurer that also measures the ascent and descent of
+// text, which are needed to align text on its baseline. When the BatchTextMeasurer of a Context
+// implements it, MeasureTextMetricsBatch is called instead of MeasureTextBatch.
+type BatchTextMetricsMeasurer interface {
+	BatchTextMeasurer
+	// MeasureTextMetricsBatch sets metrics[i] to the metrics of texts[i] rendered with config.
+	// The slices are reused by the Context and must not be retained after the call returns.
+	MeasureTextMetricsBatch(texts []string, config *TextElementConfig, metrics []TextMetrics)
+}
+
+// textBatch holds the strings measured by the last call to the BatchTextMeasurer.
+type textBatch struct {
+	texts            []string
+	dimensions       []Dimensions
+	metrics          []TextMetrics
+	next             int // Index of the next result returned by measureTextPart.
+	single           [1]string
+	singleDimensions [1]Dimensions
+}
+
+// TextCacheStats describes the usage of the text measurement cache, see [Context.TextCacheStats].
+type TextCacheStats struct {
+	Hits      uint64 // Measurements found in the cache.
+	Misses    uint64 // Measurements not found, which were measured with the MeasureTextFunction.
+	Evictions uint64 // Measurements removed after not being used for MeasureTextCacheMaxAge layouts.
+	// Entries is the number of cached measurements.
+	Entries intn
+	// WordSlotsUsed and WordSlotsCapacity are the number of measured words in use and allocated,
+	// see Context.MaxMeasureTextCacheWordCount.
+	WordSlotsUsed     intn
+	WordSlotsCapacity intn
+	// FreeListLength is the number of freed word slots available for reuse.
+	FreeListLength intn
+}
+
 type measureTextCacheItem struct {
 	unwrappedDimensions    Dimensions
 	measureWordsStartIndex intn
 	minWidth               floatn
+	ascent, descent        floatn
 	containsNewlines       bool
+	rightToLeft            bool
 	// hash map data.
 	ID         uintn
 	nextIndex  intn
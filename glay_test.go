@@ -1,6 +1,18 @@
 package glay
 
-import "testing"
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
 
 func TestAPI(t *testing.T) {
 	var context Context
@@ -47,3 +59,1780 @@ func TestAPI(t *testing.T) {
 		t.Errorf("expected 1 command, got %d", len(cmds))
 	}
 }
+
+func TestPointerState(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{
+		Layout: Dimensions{Width: 100, Height: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = context.BeginLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed := NewSizingAxis(SizingFixed, 50)
+	err = context.Clay(ElementDeclaration{
+		ID: ID("Outer"),
+		Layout: LayoutConfig{
+			Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingGrow, 0, 0)},
+		},
+	}, func(context *Context) error {
+		context.Clay(ElementDeclaration{ID: ID("Left"), Layout: LayoutConfig{Sizing: Sizing{Width: fixed, Height: fixed}}})
+		context.Clay(ElementDeclaration{ID: ID("Right"), Layout: LayoutConfig{Sizing: Sizing{Width: fixed, Height: fixed}}})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = context.EndLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context.SetPointerState(Vector2{X: 75, Y: 25}, true)
+	if !context.PointerOver(ID("Right")) || !context.PointerOver(ID("Outer")) {
+		t.Error("expected pointer over Right and Outer")
+	}
+	if context.PointerOver(ID("Left")) {
+		t.Error("pointer should not be over Left")
+	}
+	if context.PointerInfo.State != PointerDataPressedThisFrame {
+		t.Errorf("expected %v, got %v", PointerDataPressedThisFrame, context.PointerInfo.State)
+	}
+	context.SetPointerState(Vector2{X: 25, Y: 75}, true)
+	if context.PointerOver(ID("Left")) || context.PointerOver(ID("Right")) {
+		t.Error("pointer should not be over Left or Right")
+	}
+	if context.PointerInfo.State != PointerDataPressed {
+		t.Errorf("expected %v, got %v", PointerDataPressed, context.PointerInfo.State)
+	}
+	context.SetPointerState(Vector2{X: 25, Y: 25}, false)
+	if !context.PointerOver(ID("Left")) {
+		t.Error("expected pointer over Left")
+	}
+	if context.PointerInfo.State != PointerReleasedThisFrame {
+		t.Errorf("expected %v, got %v", PointerReleasedThisFrame, context.PointerInfo.State)
+	}
+	context.SetPointerState(Vector2{X: 25, Y: 25}, false)
+	if context.PointerInfo.State != PointerReleased {
+		t.Errorf("expected %v, got %v", PointerReleased, context.PointerInfo.State)
+	}
+}
+
+func TestHover(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{
+		Layout: Dimensions{Width: 100, Height: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hoveredIDs []string
+	onHover := func(id ElementID, pointer MousePointerData, userData any) {
+		hoveredIDs = append(hoveredIDs, id.StringID)
+		if userData.(int) != len(hoveredIDs) {
+			t.Errorf("expected user data %d, got %v", len(hoveredIDs), userData)
+		}
+	}
+	var hovered bool
+	layout := func() {
+		err = context.BeginLayout()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = context.Clay(ElementDeclaration{
+			ID:     ID("Panel"),
+			Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingGrow, 0, 0)}},
+		}, func(context *Context) error {
+			context.OnHover(onHover, 1)
+			return context.Clay(ElementDeclaration{
+				ID:     ID("Button"),
+				Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, 20), Height: NewSizingAxis(SizingFixed, 20)}},
+			}, func(context *Context) error {
+				context.OnHover(onHover, 2)
+				hovered = context.Hovered()
+				return nil
+			})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = context.EndLayout()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	layout()
+	if hovered {
+		t.Error("button hovered before pointer state was set")
+	}
+	context.SetPointerState(Vector2{X: 10, Y: 10}, false)
+	if len(hoveredIDs) != 2 || hoveredIDs[0] != "Panel" || hoveredIDs[1] != "Button" {
+		t.Errorf("expected Panel then Button hover callbacks, got %v", hoveredIDs)
+	}
+	layout()
+	if !hovered {
+		t.Error("expected button to be hovered")
+	}
+}
+
+func TestUpdateScrollContainers(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{
+		Layout: Dimensions{Width: 100, Height: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listID := ID("List")
+	layout := func(withList bool) {
+		err = context.BeginLayout()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if withList {
+			err = context.Clay(ElementDeclaration{
+				ID: listID,
+				Layout: LayoutConfig{
+					LayoutDirection: TopToBottom,
+					Sizing:          Sizing{Width: NewSizingAxis(SizingFixed, 100), Height: NewSizingAxis(SizingFixed, 50)},
+				},
+				Clip: ClipElementConfig{Vertical: true, ChildOffset: context.ScrollOffset(listID)},
+			}, func(context *Context) error {
+				for i := 0; i < 5; i++ {
+					context.Clay(ElementDeclaration{
+						Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingFixed, 20)}},
+					})
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err = context.EndLayout()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	layout(true)
+	context.SetPointerState(Vector2{X: 10, Y: 10}, false)
+	context.UpdateScrollContainers(false, Vector2{Y: -1}, 1.0/60)
+	if got := context.ScrollOffset(listID); got.Y != -10 {
+		t.Errorf("expected scroll offset -10, got %v", got.Y)
+	}
+	layout(true)
+	context.SetPointerState(Vector2{X: 10, Y: 10}, false)
+	context.UpdateScrollContainers(false, Vector2{Y: -100}, 1.0/60)
+	if got := context.ScrollOffset(listID); got.Y != -50 {
+		t.Errorf("expected scroll offset clamped to -50, got %v", got.Y)
+	}
+	scrollData := context.GetScrollContainerData(listID)
+	if !scrollData.Found || scrollData.ScrollPosition.Y != -50 {
+		t.Fatal("expected scroll container data with scroll position -50")
+	}
+	if scrollData.ContentDimensions != (Dimensions{Width: 100, Height: 100}) || scrollData.ScrollContainerDimensions != (Dimensions{Width: 100, Height: 50}) {
+		t.Errorf("unexpected scroll container data %+v", scrollData)
+	}
+	elementData := context.GetElementData(listID)
+	if !elementData.Found || elementData.BoundingBox != (BoundingBox{Dimensions: Dimensions{Width: 100, Height: 50}}) {
+		t.Errorf("unexpected element data %+v", elementData)
+	}
+	if context.GetElementData(ID("NotDeclared")).Found {
+		t.Error("expected undeclared element to not be found")
+	}
+	layout(false)
+	context.UpdateScrollContainers(false, Vector2{}, 1.0/60)
+	if len(context.scrollContainerDatas) != 0 {
+		t.Errorf("expected scroll container data to be discarded, got %d", len(context.scrollContainerDatas))
+	}
+}
+
+func TestDebugView(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{
+		Layout: Dimensions{Width: 800, Height: 600},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	context.MeasureTextFunction = func(text string, config *TextElementConfig, userData any) Dimensions {
+		return Dimensions{Width: floatn(len(text)) * 8, Height: 16}
+	}
+	context.DebugModeEnabled = true
+	layout := func() []RenderCommand {
+		err = context.BeginLayout()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = context.Clay(ElementDeclaration{
+			ID:     ID("Panel"),
+			Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0)}},
+		}, func(context *Context) error {
+			return context.Text("Hello", &TextElementConfig{FontSize: 16})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmds, err := context.EndLayout()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cmds
+	}
+	layout()
+	panel := context.GetElementData(ID("Panel"))
+	if !panel.Found || panel.BoundingBox.Width != 800-debugViewWidth {
+		t.Errorf("expected panel to leave room for debug view, got %+v", panel)
+	}
+	if !context.GetElementData(ID("Clay__DebugView")).Found {
+		t.Fatal("expected debug view to be declared")
+	}
+	// Rows start below the header, the second row lists Panel.
+	context.SetPointerState(Vector2{X: 500, Y: 2*debugViewRowHeight + debugViewRowHeight/2}, true)
+	layout()
+	if context.DebugSelectElementID != ID("Panel").ID {
+		t.Fatalf("expected Panel to be selected, got %d", context.DebugSelectElementID)
+	}
+	context.SetPointerState(Vector2{X: 500, Y: 2*debugViewRowHeight + debugViewRowHeight/2}, false)
+	layout()
+	if !context.GetElementData(ID("Clay__DebugViewElementInfoScrollPane")).Found {
+		t.Error("expected selected element details pane")
+	}
+	context.SetPointerState(Vector2{}, false)
+	closeButton := context.GetElementData(ID("Clay__DebugViewTopHeaderCloseButtonOuter"))
+	context.SetPointerState(Vector2{X: closeButton.BoundingBox.X + 1, Y: closeButton.BoundingBox.Y + 1}, true)
+	layout()
+	if context.DebugModeEnabled {
+		t.Error("expected close button to disable debug mode")
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{
+		Layout: Dimensions{Width: 100, Height: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logs bytes.Buffer
+	context.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	err = context.BeginLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	context.Clay(ElementDeclaration{ID: ID("Twin")})
+	context.Clay(ElementDeclaration{ID: ID("Twin")})
+	err = context.Clay(ElementDeclaration{
+		ID:     ID("Wide"),
+		Layout: LayoutConfig{Sizing: Sizing{Width: SizingAxis{Type: SizingPercent, Percent: 2}}},
+	})
+	if !errors.Is(err, ErrPercentageOver1) {
+		t.Errorf("expected ErrPercentageOver1, got %v", err)
+	}
+	want := []Warning{
+		{Kind: WarningDuplicateID, StringID: "Twin", Generation: context.Generation},
+		{Kind: WarningPercentageOver1, StringID: "Wide", Generation: context.Generation},
+	}
+	if len(context.Warnings) != len(want) {
+		t.Fatalf("expected %d warnings, got %v", len(want), context.Warnings)
+	}
+	for i := range want {
+		if context.Warnings[i] != want[i] {
+			t.Errorf("warning %d: expected %v, got %v", i, want[i], context.Warnings[i])
+		}
+	}
+	if !strings.Contains(logs.String(), WarningDuplicateID.String()) {
+		t.Errorf("expected duplicate ID warning to be logged, got %q", logs.String())
+	}
+
+	err = context.BeginLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(context.Warnings) != 0 {
+		t.Errorf("expected warnings to be reset by BeginLayout, got %v", context.Warnings)
+	}
+}
+
+func TestCapacityExceeded(t *testing.T) {
+	context := Context{MaxElementCount: 8, MaxMeasureTextCacheWordCount: 32}
+	err := context.Initialize(Config{
+		Layout: Dimensions{Width: 100, Height: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	context.MeasureTextFunction = func(text string, config *TextElementConfig, userData any) Dimensions {
+		return Dimensions{Width: floatn(len(text)), Height: 10}
+	}
+	border := BorderElementConfig{Color: Color{A: 255}, Width: BorderWidth{Left: 1}}
+	err = context.BeginLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = context.Clay(ElementDeclaration{
+		ID:              ID("List"),
+		BackgroundColor: Color{A: 255},
+		Border:          border,
+		Clip:            ClipElementConfig{Vertical: true},
+	}, func(context *Context) error {
+		for i := 0; i < 20; i++ {
+			context.Clay(ElementDeclaration{BackgroundColor: Color{A: 255}, Border: border})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(context.Clay(ElementDeclaration{}), ErrElementsCapacityExceeded) {
+		t.Error("expected Clay to return ErrElementsCapacityExceeded")
+	}
+	cmds, err := context.EndLayout()
+	if !errors.Is(err, ErrElementsCapacityExceeded) {
+		t.Errorf("expected ErrElementsCapacityExceeded, got %v", err)
+	}
+	if len(cmds) == 0 || len(cmds) > 8 {
+		t.Errorf("expected truncated render commands, got %d", len(cmds))
+	}
+	if cmds[len(cmds)-1].CommandType != RenderCommandTypeScissorEnd {
+		t.Errorf("expected truncated commands to end the open scissor, got %v", cmds[len(cmds)-1].CommandType)
+	}
+	var capacityWarnings int
+	for _, warning := range context.Warnings {
+		if warning.Kind == WarningElementsCapacityExceeded {
+			capacityWarnings++
+		}
+	}
+	if capacityWarnings == 0 {
+		t.Error("expected capacity warning")
+	}
+
+	err = context.BeginLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs := len(context.TextElementConfigs)
+	err = context.Text(strings.Repeat("word ", 40), &TextElementConfig{})
+	if !errors.Is(err, ErrTextMeasurementCapacityExceeded) {
+		t.Errorf("expected ErrTextMeasurementCapacityExceeded, got %v", err)
+	}
+	if len(context.TextElementConfigs) != configs {
+		t.Errorf("expected text config to be released, got %d more", len(context.TextElementConfigs)-configs)
+	}
+	err = context.RichText([]TextSpan{{Text: "a", Config: &TextElementConfig{}}, {Text: strings.Repeat("span ", 40)}})
+	if !errors.Is(err, ErrTextMeasurementCapacityExceeded) {
+		t.Errorf("expected ErrTextMeasurementCapacityExceeded from RichText, got %v", err)
+	}
+	if len(context.TextElementConfigs) != configs {
+		t.Errorf("expected span configs to be released, got %d more", len(context.TextElementConfigs)-configs)
+	}
+	_, err = context.EndLayout()
+	if !errors.Is(err, ErrTextMeasurementCapacityExceeded) {
+		t.Errorf("expected ErrTextMeasurementCapacityExceeded from EndLayout, got %v", err)
+	}
+}
+
+// TestTextConfigsCapacityExceeded checks that Text reports a full text configuration buffer
+// instead of rendering with the configuration of another element.
+func TestTextConfigsCapacityExceeded(t *testing.T) {
+	context := Context{MaxElementCount: 16}
+	err := context.Initialize(Config{Layout: Dimensions{Width: 1000, Height: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	context.MeasureTextFunction = func(text string, config *TextElementConfig, userData any) Dimensions {
+		return Dimensions{Width: floatn(len(text)), Height: 10}
+	}
+	err = context.BeginLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spans := make([]TextSpan, 16)
+	for i := range spans {
+		spans[i] = TextSpan{Text: "s ", Config: &TextElementConfig{FontID: 1}}
+	}
+	err = context.RichText(spans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = context.Text("hello", &TextElementConfig{FontID: 7})
+	if !errors.Is(err, ErrElementsCapacityExceeded) {
+		t.Errorf("expected ErrElementsCapacityExceeded, got %v", err)
+	}
+	cmds, _ := context.EndLayout()
+	for _, cmd := range cmds {
+		if cmd.CommandType != RenderCommandTypeText {
+			continue
+		}
+		if data := cmd.RenderData.(*TextRenderData); string(data.Contents) == "hello" {
+			t.Errorf("expected text beyond capacity not to render, got font %d", data.FontID)
+		}
+	}
+}
+
+func layoutBenchmarkFrame(context *Context) ([]RenderCommand, error) {
+	err := context.BeginLayout()
+	if err != nil {
+		return nil, err
+	}
+	listID := ID("List")
+	context.Clay(ElementDeclaration{
+		ID:              ID("Outer"),
+		BackgroundColor: Color{43, 41, 51, 255},
+		Layout: LayoutConfig{
+			Sizing:   Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingGrow, 0, 0)},
+			Padding:  PaddingAll(16),
+			ChildGap: 16,
+		},
+	}, func(context *Context) error {
+		context.Clay(ElementDeclaration{
+			ID:              ID("Sidebar"),
+			BackgroundColor: Color{90, 90, 90, 255},
+			Border:          BorderElementConfig{Color: Color{A: 255}, Width: BorderWidth{Right: 1, BetweenChildren: 1}},
+			Layout: LayoutConfig{
+				LayoutDirection: TopToBottom,
+				Sizing:          Sizing{Width: NewSizingAxis(SizingFixed, 200), Height: NewSizingAxis(SizingGrow, 0, 0)},
+				ChildGap:        8,
+			},
+		}, func(context *Context) error {
+			for i := 0; i < 10; i++ {
+				context.Clay(ElementDeclaration{
+					ID:              hashString("SidebarItem", uintn(i), 0),
+					BackgroundColor: Color{120, 120, 120, 255},
+					CornerRadius:    CornerRadius{TopLeft: 4, TopRight: 4, BottomLeft: 4, BottomRight: 4},
+					Layout:          LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0)}, Padding: PaddingAll(4)},
+				}, func(context *Context) error {
+					return context.Text("Sidebar item with some wrapping text", &TextElementConfig{FontSize: 16, TextColor: Color{255, 255, 255, 255}})
+				})
+			}
+			return nil
+		})
+		return context.Clay(ElementDeclaration{
+			ID: listID,
+			Layout: LayoutConfig{
+				LayoutDirection: TopToBottom,
+				Sizing:          Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingGrow, 0, 0)},
+			},
+			Clip: ClipElementConfig{Vertical: true, ChildOffset: context.ScrollOffset(listID)},
+		}, func(context *Context) error {
+			for i := 0; i < 50; i++ {
+				context.Clay(ElementDeclaration{
+					BackgroundColor: Color{60, 60, 60, 255},
+					Layout:          LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingFixed, 24)}},
+				})
+			}
+			return context.Clay(ElementDeclaration{
+				ID:              ID("Tooltip"),
+				BackgroundColor: Color{0, 0, 0, 200},
+				Layout:          LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, 100), Height: NewSizingAxis(SizingFixed, 30)}},
+				Floating:        FloatingElementConfig{AttachTo: AttachToParent, Zindex: 1},
+			})
+		})
+	})
+	cmds, err := context.EndLayout()
+	context.SetPointerState(Vector2{X: 400, Y: 300}, false)
+	context.UpdateScrollContainers(false, Vector2{Y: -1}, 1.0/60)
+	return cmds, err
+}
+
+func newBenchmarkContext(tb testing.TB) *Context {
+	context := new(Context)
+	err := context.Initialize(Config{
+		Layout: Dimensions{Width: 800, Height: 600},
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	context.MeasureTextFunction = func(text string, config *TextElementConfig, userData any) Dimensions {
+		return Dimensions{Width: floatn(len(text)) * floatn(config.FontSize) / 2, Height: floatn(config.FontSize)}
+	}
+	// Warm up text measurement cache and scroll containers.
+	for i := 0; i < 3; i++ {
+		if _, err := layoutBenchmarkFrame(context); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return context
+}
+
+func TestLayoutZeroAlloc(t *testing.T) {
+	context := newBenchmarkContext(t)
+	allocs := testing.AllocsPerRun(10, func() {
+		layoutBenchmarkFrame(context)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations per frame after warm-up, got %v", allocs)
+	}
+}
+
+func BenchmarkLayout(b *testing.B) {
+	context := newBenchmarkContext(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := layoutBenchmarkFrame(context)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCustomElement(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{
+		Layout: Dimensions{Width: 100, Height: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = context.BeginLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	type plot struct{ name string }
+	radius := CornerRadius{TopLeft: 2, TopRight: 2, BottomLeft: 2, BottomRight: 2}
+	err = context.Clay(ElementDeclaration{
+		ID:              ID("Plot"),
+		Layout:          LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, 60), Height: NewSizingAxis(SizingFixed, 40)}},
+		BackgroundColor: Color{10, 20, 30, 255},
+		CornerRadius:    radius,
+		Custom:          CustomElementConfig{CustomData: &plot{name: "sine"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds, err := context.EndLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds) != 1 || cmds[0].CommandType != RenderCommandTypeCustom {
+		t.Fatalf("expected a single custom command, got %+v", cmds)
+	}
+	if cmds[0].BoundingBox.Dimensions != (Dimensions{Width: 60, Height: 40}) {
+		t.Errorf("unexpected bounding box %+v", cmds[0].BoundingBox)
+	}
+	data, ok := cmds[0].RenderData.(*CustomRenderData)
+	if !ok {
+		t.Fatalf("expected *CustomRenderData, got %T", cmds[0].RenderData)
+	}
+	if data.BackgroundColor != (Color{10, 20, 30, 255}) || data.CornerRadius != radius {
+		t.Errorf("unexpected custom render data %+v", data)
+	}
+	if p, _ := data.CustomData.(*plot); p == nil || p.name != "sine" {
+		t.Errorf("expected custom data to be passed through, got %v", data.CustomData)
+	}
+}
+
+func TestNestedClip(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{
+		Layout: Dimensions{Width: 100, Height: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = context.BeginLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed := func(width, height float32) Sizing {
+		return Sizing{Width: NewSizingAxis(SizingFixed, width), Height: NewSizingAxis(SizingFixed, height)}
+	}
+	err = context.Clay(ElementDeclaration{
+		ID:     ID("Outer"),
+		Layout: LayoutConfig{Sizing: fixed(60, 60), Padding: PaddingAll(20)},
+		Clip:   ClipElementConfig{Vertical: true},
+	}, func(context *Context) error {
+		return context.Clay(ElementDeclaration{
+			ID:     ID("Inner"),
+			Layout: LayoutConfig{Sizing: fixed(80, 80)},
+			Clip:   ClipElementConfig{Horizontal: true},
+		}, func(context *Context) error {
+			return context.Clay(ElementDeclaration{
+				ID:     ID("Target"),
+				Layout: LayoutConfig{Sizing: fixed(10, 10)},
+			})
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, clipTo := range []FloatingClipToElement{ClipToAttachedParent, ClipToNone} {
+		err = context.Clay(ElementDeclaration{
+			Layout: LayoutConfig{Sizing: fixed(10, 10)},
+			Floating: FloatingElementConfig{
+				AttachTo: AttachToElementWithID,
+				ParentID: ID("Target").ID,
+				ClipTo:   clipTo,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmds, err := context.EndLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer := BoundingBox{Dimensions: Dimensions{Width: 60, Height: 60}}
+	inner := BoundingBox{Vector2: Vector2{X: 20, Y: 20}, Dimensions: Dimensions{Width: 40, Height: 40}}
+	want := []struct {
+		typ RenderCommandType
+		bb  BoundingBox
+	}{
+		{RenderCommandTypeScissorStart, outer},
+		{RenderCommandTypeScissorStart, inner},
+		{RenderCommandTypeScissorEnd, inner},
+		{RenderCommandTypeScissorEnd, outer},
+		// Floating element attached to Target is clipped by Inner and its ancestors.
+		{RenderCommandTypeScissorStart, inner},
+		{RenderCommandTypeScissorEnd, inner},
+	}
+	var got int
+	for _, cmd := range cmds {
+		if cmd.CommandType != RenderCommandTypeScissorStart && cmd.CommandType != RenderCommandTypeScissorEnd {
+			continue
+		}
+		if got >= len(want) {
+			t.Fatalf("unexpected scissor command %v %+v", cmd.CommandType, cmd.BoundingBox)
+		}
+		if cmd.CommandType != want[got].typ || cmd.BoundingBox != want[got].bb {
+			t.Errorf("scissor command %d: want %v %+v, got %v %+v", got, want[got].typ, want[got].bb, cmd.CommandType, cmd.BoundingBox)
+		}
+		got++
+	}
+	if got != len(want) {
+		t.Errorf("want %d scissor commands, got %d", len(want), got)
+	}
+}
+
+func TestTextWrap(t *testing.T) {
+	for _, test := range []struct {
+		text  string
+		width float32
+		want  []string
+	}{
+		{text: "héllo wörld\u2028日本語\u3000a\u00a0b", width: 65, want: []string{"héllo", "wörld", "日本語", "a\u00a0b"}},
+		{text: "日本語のテキスト", width: 35, want: []string{"日本語", "のテキ", "スト"}},
+		// No break before closing punctuation.
+		{text: "日本語。テスト", width: 30, want: []string{"日本", "語。テ", "スト"}},
+		{text: "well-known fact", width: 60, want: []string{"well-", "known", "fact"}},
+		{text: "one\r\ntwo  three", width: 100, want: []string{"one", "two  three"}},
+	} {
+		got := wrapText(t, test.text, test.width, TextElementConfig{FontSize: 10})
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%q: want lines %q, got %q", test.text, test.want, got)
+		}
+	}
+}
+
+func TestTextOverflow(t *testing.T) {
+	for _, test := range []struct {
+		text   string
+		width  float32
+		config TextElementConfig
+		want   []string
+	}{
+		{text: "one two three", width: 85, config: TextElementConfig{MaxLines: 1}, want: []string{"one two"}},
+		{text: "one two three four", width: 80, config: TextElementConfig{MaxLines: 2, Overflow: TextOverflowEllipsis}, want: []string{"one two", "three…"}},
+		{text: "abcdefghij", width: 55, config: TextElementConfig{WrapMode: TextWrapNone, Overflow: TextOverflowEllipsis}, want: []string{"abcd…"}},
+		{text: "/usr/local/share/file.txt", width: 105, config: TextElementConfig{MaxLines: 1, Overflow: TextOverflowEllipsisMiddle}, want: []string{"/usr…e.txt"}},
+		{text: "/usr/file.txt", width: 200, config: TextElementConfig{MaxLines: 1, Overflow: TextOverflowEllipsisMiddle}, want: []string{"/usr/file.txt"}},
+		// The end of the last kept paragraph is kept, not that of the paragraphs that were cut.
+		{text: "first line\nsecond paragraph\nthird", width: 80, config: TextElementConfig{MaxLines: 2, Overflow: TextOverflowEllipsisMiddle}, want: []string{"first", "line"}},
+		{text: "one\ntwo three\nfour", width: 100, config: TextElementConfig{MaxLines: 2, Overflow: TextOverflowEllipsisMiddle}, want: []string{"one", "two three"}},
+	} {
+		test.config.FontSize = 10
+		got := wrapText(t, test.text, test.width, test.config)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%q %v: want lines %q, got %q", test.text, test.config.Overflow, test.want, got)
+		}
+	}
+}
+
+func TestTextJustify(t *testing.T) {
+	config := TextElementConfig{FontSize: 10, TextAlignment: TextAlignJustify}
+	cmds := layoutText(t, "aa b cc dd\neee f", 80, config)
+	wantLines := []string{"aa b cc", "dd", "eee f"}
+	// "aa b cc" is 70 units wide with 2 spaces, leaving 5 units of extra space per gap.
+	// The line ending in a newline and the last line are not stretched.
+	wantSpacing := []float32{5, 0, 0}
+	if len(cmds) != len(wantLines) {
+		t.Fatalf("want %d lines, got %d", len(wantLines), len(cmds))
+	}
+	for i, cmd := range cmds {
+		data := cmd.RenderData.(*TextRenderData)
+		if string(data.Contents) != wantLines[i] || data.WordSpacing != wantSpacing[i] {
+			t.Errorf("line %d: want %q with word spacing %v, got %q with %v", i, wantLines[i], wantSpacing[i], data.Contents, data.WordSpacing)
+		}
+		if cmd.BoundingBox.X != 0 {
+			t.Errorf("line %d: justified text should start at the left edge, got x=%v", i, cmd.BoundingBox.X)
+		}
+	}
+	if cmds[0].BoundingBox.Width != 80 {
+		t.Errorf("justified line should fill the container, got width %v", cmds[0].BoundingBox.Width)
+	}
+}
+
+func TestRichText(t *testing.T) {
+	regular := TextElementConfig{FontSize: 10}
+	bold := TextElementConfig{FontSize: 20, FontID: 1}
+	cmds := layoutTextElement(t, 100, func(context *Context) error {
+		return context.RichText([]TextSpan{
+			{Text: "hello ", Config: &regular},
+			{Text: "bold", Config: &bold, UserData: "bold"},
+			{Text: ", world", Config: &regular},
+		})
+	})
+	// "bold" would fit on the first line but may not be separated from the comma that follows.
+	want := []struct {
+		text     string
+		x, y     float32
+		fontSize uint16
+	}{
+		{"hello", 0, 0, 10},
+		{"bold", 0, 10, 20},
+		{",", 40, 20, 10}, // Bottom aligned with "bold".
+		{"world", 0, 30, 10},
+	}
+	if len(cmds) != len(want) {
+		t.Fatalf("want %d text commands, got %d", len(want), len(cmds))
+	}
+	ids := make(map[uint32]bool)
+	for i, cmd := range cmds {
+		data := cmd.RenderData.(*TextRenderData)
+		if string(data.Contents) != want[i].text || cmd.BoundingBox.X != want[i].x || cmd.BoundingBox.Y != want[i].y || data.FontSize != want[i].fontSize {
+			t.Errorf("fragment %d: want %q at (%v,%v) size %d, got %q at (%v,%v) size %d", i,
+				want[i].text, want[i].x, want[i].y, want[i].fontSize, data.Contents, cmd.BoundingBox.X, cmd.BoundingBox.Y, data.FontSize)
+		}
+		if (cmd.UserData == "bold") != (want[i].fontSize == 20) {
+			t.Errorf("fragment %d: unexpected user data %v", i, cmd.UserData)
+		}
+		if ids[cmd.ID] {
+			t.Errorf("fragment %d: duplicate ID %d", i, cmd.ID)
+		}
+		ids[cmd.ID] = true
+	}
+}
+
+func TestRichTextNilConfig(t *testing.T) {
+	bold := TextElementConfig{FontSize: 20, FontID: 1}
+	cmds := layoutTextElement(t, 200, func(context *Context) error {
+		return context.RichText([]TextSpan{{Text: "plain "}, {Text: "bold ", Config: &bold}, {Text: "same"}})
+	})
+	want := []uint16{0, 20, 20}
+	if len(cmds) != len(want) {
+		t.Fatalf("want %d text commands, got %d", len(want), len(cmds))
+	}
+	for i, cmd := range cmds {
+		if size := cmd.RenderData.(*TextRenderData).FontSize; size != want[i] {
+			t.Errorf("fragment %d: want font size %d, got %d", i, want[i], size)
+		}
+	}
+}
+
+func TestTextHitTest(t *testing.T) {
+	context := newTextTestContext(t)
+	config := TextElementConfig{FontSize: 10}
+	layoutTextContainer(t, context, ElementDeclaration{
+		ID:     ID("editor"),
+		Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, 80)}},
+	}, func(context *Context) error {
+		return context.Text("hello world foo", &config) // Wraps into "hello", "world" and "foo".
+	})
+	id := ID("editor")
+	for _, test := range []struct {
+		position Vector2
+		want     int
+	}{
+		{Vector2{X: 23, Y: 5}, 2},
+		{Vector2{X: 27, Y: 5}, 3},
+		{Vector2{X: 500, Y: 15}, 11},
+		{Vector2{X: -5, Y: 25}, 12},
+		{Vector2{X: 16, Y: 200}, 14},
+	} {
+		got, ok := context.TextHitTest(id, test.position)
+		if !ok || got != test.want {
+			t.Errorf("hit %v: want offset %d, got %d (ok=%v)", test.position, test.want, got, ok)
+		}
+	}
+	for _, test := range []struct {
+		offset int
+		want   BoundingBox
+	}{
+		{0, BoundingBox{Dimensions: Dimensions{Height: 10}}},
+		{5, BoundingBox{Vector2: Vector2{X: 50}, Dimensions: Dimensions{Height: 10}}},
+		{6, BoundingBox{Vector2: Vector2{Y: 10}, Dimensions: Dimensions{Height: 10}}},
+		{14, BoundingBox{Vector2: Vector2{X: 20, Y: 20}, Dimensions: Dimensions{Height: 10}}},
+	} {
+		got := context.TextCaretRect(id, test.offset)
+		if got != test.want {
+			t.Errorf("caret at %d: want %+v, got %+v", test.offset, test.want, got)
+		}
+	}
+	got := context.TextSelectionRects(id, 8, 3, nil)
+	want := []BoundingBox{
+		{Vector2: Vector2{X: 30}, Dimensions: Dimensions{Width: 20, Height: 10}},
+		{Vector2: Vector2{Y: 10}, Dimensions: Dimensions{Width: 20, Height: 10}},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("selection: want %+v, got %+v", want, got)
+	}
+	if _, ok := context.TextHitTest(ID("missing"), Vector2{}); ok {
+		t.Error("hit test of a missing element should fail")
+	}
+}
+
+func TestTextCacheStats(t *testing.T) {
+	context := Context{MaxMeasureTextCacheWordCount: 64, MeasureTextCacheMaxAge: 1}
+	err := context.Initialize(Config{Layout: Dimensions{Width: 100, Height: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var measured int
+	context.MeasureTextFunction = func(text string, config *TextElementConfig, userData any) Dimensions {
+		measured++
+		return Dimensions{Width: 10 * floatn(len(text)), Height: 10}
+	}
+	config := TextElementConfig{FontSize: 10}
+	layout := func(text string) {
+		layoutTextContainer(t, &context, ElementDeclaration{}, func(context *Context) error {
+			if text == "" {
+				return nil
+			}
+			return context.Text(text, &config)
+		})
+	}
+	layout("hello world")
+	stats := context.TextCacheStats()
+	if stats.Misses != 1 || stats.Entries != 1 || stats.WordSlotsUsed != 2 || stats.WordSlotsCapacity != 64 {
+		t.Errorf("after first layout: %+v", stats)
+	}
+	measuredBefore := measured
+	layout("hello world")
+	if stats := context.TextCacheStats(); stats.Misses != 1 || stats.Hits != 1 || measured != measuredBefore {
+		t.Errorf("cached text was measured again: %+v", stats)
+	}
+
+	// Text of another line height is cached separately.
+	tall := TextElementConfig{FontSize: 10, LineHeight: 20}
+	layoutTextContainer(t, &context, ElementDeclaration{}, func(context *Context) error {
+		return context.Text("hello world", &tall)
+	})
+	if stats := context.TextCacheStats(); stats.Misses != 2 || stats.Entries != 2 {
+		t.Errorf("line height not part of the cache key: %+v", stats)
+	}
+
+	context.ResetMeasureTextCache()
+	if stats := context.TextCacheStats(); stats != (TextCacheStats{WordSlotsCapacity: 64}) {
+		t.Errorf("after reset: %+v", stats)
+	}
+	measuredBefore = measured
+	layout("hello world")
+	if measured == measuredBefore {
+		t.Error("text not measured again after reset")
+	}
+
+	// Unused measurements are evicted from every bucket when the cache runs out of capacity.
+	long := strings.Repeat("a ", 25)
+	layout(long)
+	layout("")
+	layout("")
+	layout(strings.Repeat("b ", 25))
+	if stats := context.TextCacheStats(); stats.Evictions != 2 || stats.Entries != 1 || len(context.Warnings) != 0 {
+		t.Errorf("expired measurements not evicted: %+v, warnings %v", stats, context.Warnings)
+	}
+}
+
+// batchMeasurer measures characters as 10 units wide and records its calls.
+type batchMeasurer struct {
+	batches [][]string
+}
+
+func (m *batchMeasurer) MeasureTextBatch(texts []string, config *TextElementConfig, dimensions []Dimensions) {
+	m.batches = append(m.batches, slices.Clone(texts))
+	for i, text := range texts {
+		dimensions[i] = Dimensions{Width: 10 * floatn(utf8.RuneCountInString(text)), Height: 10}
+	}
+}
+
+func TestBatchTextMeasurer(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{Layout: Dimensions{Width: 100, Height: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var measurer batchMeasurer
+	context.BatchTextMeasurer = &measurer
+	config := TextElementConfig{FontSize: 10}
+	cmds := layoutTextContainer(t, &context, ElementDeclaration{
+		Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, 100)}},
+	}, func(context *Context) error {
+		return context.Text("hello big　world", &config)
+	})
+	want := [][]string{{" ", "hello", "big", "　", "world"}}
+	if !slices.EqualFunc(measurer.batches, want, slices.Equal[[]string]) {
+		t.Errorf("want batches %q, got %q", want, measurer.batches)
+	}
+	var lines []string
+	for _, cmd := range cmds {
+		if cmd.CommandType == RenderCommandTypeText {
+			lines = append(lines, string(cmd.RenderData.(*TextRenderData).Contents))
+		}
+	}
+	if want := []string{"hello big", "world"}; !slices.Equal(lines, want) {
+		t.Errorf("want lines %q, got %q", want, lines)
+	}
+}
+
+func TestAlignYBaseline(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{Layout: Dimensions{Width: 200, Height: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fonts ascend 80% of their size above the baseline.
+	context.MeasureTextMetricsFunction = func(text string, config *TextElementConfig, userData any) TextMetrics {
+		size := floatn(config.FontSize)
+		return TextMetrics{
+			Dimensions: Dimensions{Width: 10 * floatn(len(text)), Height: size},
+			Ascent:     0.8 * size,
+			Descent:    0.2 * size,
+		}
+	}
+	small, large := TextElementConfig{FontSize: 10}, TextElementConfig{FontSize: 20}
+	cmds := layoutTextContainer(t, &context, ElementDeclaration{
+		ID:     ID("row"),
+		Layout: LayoutConfig{ChildAlignment: ChildAlignment{Y: AlignYBaseline}},
+	}, func(context *Context) error {
+		context.Text("label", &small)
+		context.Text("title", &large)
+		context.Clay(ElementDeclaration{Layout: LayoutConfig{Padding: Padding{Top: 5, Bottom: 5}}}, func(context *Context) error {
+			return context.Text("input", &small)
+		})
+		return context.RichText([]TextSpan{{Text: "a", Config: &small}, {Text: "B", Config: &large}})
+	})
+	want := map[string]float32{"label": 8, "title": 0, "input": 8, "a": 8, "B": 0}
+	for _, cmd := range cmds {
+		if cmd.CommandType != RenderCommandTypeText {
+			continue
+		}
+		text := string(cmd.RenderData.(*TextRenderData).Contents)
+		if y, ok := want[text]; !ok || cmd.BoundingBox.Y != y {
+			t.Errorf("%q: want y %v, got %v", text, want[text], cmd.BoundingBox.Y)
+		}
+	}
+	// The padded input extends 7 below the baseline at 16.
+	if got := context.GetElementData(ID("row")).BoundingBox.Height; got != 23 {
+		t.Errorf("row height: want 23, got %v", got)
+	}
+}
+
+func TestAlignYBaselineNested(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{Layout: Dimensions{Width: 300, Height: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fonts ascend 80% of their size above the baseline.
+	context.MeasureTextMetricsFunction = func(text string, config *TextElementConfig, userData any) TextMetrics {
+		size := floatn(config.FontSize)
+		return TextMetrics{
+			Dimensions: Dimensions{Width: 10 * floatn(len(text)), Height: size},
+			Ascent:     0.8 * size,
+			Descent:    0.2 * size,
+		}
+	}
+	small, large := TextElementConfig{FontSize: 10}, TextElementConfig{FontSize: 20}
+	cmds := layoutTextContainer(t, &context, ElementDeclaration{
+		Layout: LayoutConfig{ChildAlignment: ChildAlignment{Y: AlignYBaseline}},
+	}, func(context *Context) error {
+		context.Text("label", &large)
+		// The baseline of an input box is that of its text where the box centers it.
+		context.Clay(ElementDeclaration{
+			ID:     ID("input"),
+			Layout: LayoutConfig{Sizing: Sizing{Height: NewSizingAxis(SizingFixed, 60)}, ChildAlignment: ChildAlignment{Y: AlignYCenter}},
+		}, func(context *Context) error {
+			return context.Text("value", &large)
+		})
+		// The baseline of a nested row is the one its children are shifted to.
+		context.Clay(ElementDeclaration{
+			ID:     ID("nested"),
+			Layout: LayoutConfig{ChildAlignment: ChildAlignment{Y: AlignYBaseline}},
+		}, func(context *Context) error {
+			context.Text("a", &small)
+			return context.Text("B", &large)
+		})
+		// The first line of a reversed column is its last child.
+		return context.Clay(ElementDeclaration{
+			ID:     ID("reversed"),
+			Layout: LayoutConfig{Sizing: Sizing{Height: NewSizingAxis(SizingFixed, 40)}, LayoutDirection: TopToBottom, Reverse: true},
+		}, func(context *Context) error {
+			context.Text("lo", &small)
+			return context.Text("hi", &large)
+		})
+	})
+	want := map[string]float32{"label": 20, "value": 20, "a": 28, "B": 20, "hi": 20, "lo": 40}
+	for _, cmd := range cmds {
+		if cmd.CommandType != RenderCommandTypeText {
+			continue
+		}
+		text := string(cmd.RenderData.(*TextRenderData).Contents)
+		if y, ok := want[text]; !ok || cmd.BoundingBox.Y != y {
+			t.Errorf("%q: want y %v, got %v", text, want[text], cmd.BoundingBox.Y)
+		}
+	}
+	for id, want := range map[string]float32{"input": 0, "nested": 20, "reversed": 10} {
+		if got := context.GetElementData(ID(id)).BoundingBox.Y; got != want {
+			t.Errorf("%s: want y %v, got %v", id, want, got)
+		}
+	}
+}
+
+// batchMetricsMeasurer measures characters as 10 units wide with fonts ascending 80% of their size.
+type batchMetricsMeasurer struct {
+	batchMeasurer
+}
+
+func (m *batchMetricsMeasurer) MeasureTextMetricsBatch(texts []string, config *TextElementConfig, metrics []TextMetrics) {
+	size := floatn(config.FontSize)
+	for i, text := range texts {
+		metrics[i] = TextMetrics{
+			Dimensions: Dimensions{Width: 10 * floatn(len(text)), Height: size},
+			Ascent:     0.8 * size,
+			Descent:    0.2 * size,
+		}
+	}
+}
+
+func TestAlignYBaselineBatch(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{Layout: Dimensions{Width: 200, Height: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var measurer batchMetricsMeasurer
+	context.BatchTextMeasurer = &measurer
+	small, large := TextElementConfig{FontSize: 10}, TextElementConfig{FontSize: 20}
+	cmds := layoutTextContainer(t, &context, ElementDeclaration{
+		Layout: LayoutConfig{ChildAlignment: ChildAlignment{Y: AlignYBaseline}},
+	}, func(context *Context) error {
+		context.Text("label", &small)
+		return context.Text("title", &large)
+	})
+	if len(measurer.batches) != 0 {
+		t.Errorf("MeasureTextBatch called instead of MeasureTextMetricsBatch: %q", measurer.batches)
+	}
+	want := map[string]float32{"label": 8, "title": 0}
+	for _, cmd := range cmds {
+		if cmd.CommandType != RenderCommandTypeText {
+			continue
+		}
+		text := string(cmd.RenderData.(*TextRenderData).Contents)
+		if y, ok := want[text]; !ok || cmd.BoundingBox.Y != y {
+			t.Errorf("%q: want y %v, got %v", text, want[text], cmd.BoundingBox.Y)
+		}
+	}
+}
+
+func TestLayoutWrap(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{Layout: Dimensions{Width: 200, Height: 200}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = context.BeginLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	box := func(id string, width SizingAxis, height float32) {
+		context.Clay(ElementDeclaration{ID: ID(id), Layout: LayoutConfig{Sizing: Sizing{Width: width, Height: NewSizingAxis(SizingFixed, height)}}})
+	}
+	err = context.Clay(ElementDeclaration{Layout: LayoutConfig{LayoutDirection: TopToBottom}}, func(context *Context) error {
+		// Two cards fit on each line of the gallery, the growing card fills the last line.
+		context.Clay(ElementDeclaration{
+			ID:     ID("gallery"),
+			Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, 100)}, ChildGap: 10, Wrap: true},
+		}, func(context *Context) error {
+			for i := 0; i < 4; i++ {
+				box("card"+strconv.Itoa(i), NewSizingAxis(SizingFixed, 30), 20)
+			}
+			box("wide", NewSizingAxis(SizingGrow, 30, 0), 20)
+			return nil
+		})
+		// Cells wrap into a second column that widens the element.
+		context.Clay(ElementDeclaration{
+			ID:     ID("column"),
+			Layout: LayoutConfig{Sizing: Sizing{Height: NewSizingAxis(SizingFixed, 50)}, ChildGap: 10, LayoutDirection: TopToBottom, Wrap: true},
+		}, func(context *Context) error {
+			for i := 0; i < 3; i++ {
+				box("cell"+strconv.Itoa(i), NewSizingAxis(SizingFixed, 20), 20)
+			}
+			return nil
+		})
+		// The growing cell fills the width of its own column, not that of the first one.
+		return context.Clay(ElementDeclaration{
+			ID:     ID("columns"),
+			Layout: LayoutConfig{Sizing: Sizing{Height: NewSizingAxis(SizingFixed, 50)}, ChildGap: 10, LayoutDirection: TopToBottom, Wrap: true},
+		}, func(context *Context) error {
+			box("tall", NewSizingAxis(SizingFixed, 80), 30)
+			box("narrow", NewSizingAxis(SizingGrow, 10, 0), 30)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = context.EndLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]BoundingBox{
+		"gallery": {Dimensions: Dimensions{Width: 100, Height: 80}},
+		"card0":   {Dimensions: Dimensions{Width: 30, Height: 20}},
+		"card1":   {Vector2: Vector2{X: 40}, Dimensions: Dimensions{Width: 30, Height: 20}},
+		"card2":   {Vector2: Vector2{Y: 30}, Dimensions: Dimensions{Width: 30, Height: 20}},
+		"card3":   {Vector2: Vector2{X: 40, Y: 30}, Dimensions: Dimensions{Width: 30, Height: 20}},
+		"wide":    {Vector2: Vector2{Y: 60}, Dimensions: Dimensions{Width: 100, Height: 20}},
+		"column":  {Vector2: Vector2{Y: 80}, Dimensions: Dimensions{Width: 50, Height: 50}},
+		"cell0":   {Vector2: Vector2{Y: 80}, Dimensions: Dimensions{Width: 20, Height: 20}},
+		"cell1":   {Vector2: Vector2{Y: 110}, Dimensions: Dimensions{Width: 20, Height: 20}},
+		"cell2":   {Vector2: Vector2{X: 30, Y: 80}, Dimensions: Dimensions{Width: 20, Height: 20}},
+		"columns": {Vector2: Vector2{Y: 130}, Dimensions: Dimensions{Width: 100, Height: 50}},
+		"tall":    {Vector2: Vector2{Y: 130}, Dimensions: Dimensions{Width: 80, Height: 30}},
+		"narrow":  {Vector2: Vector2{X: 90, Y: 130}, Dimensions: Dimensions{Width: 10, Height: 30}},
+	} {
+		if got := context.GetElementData(ID(id)).BoundingBox; got != want {
+			t.Errorf("%s: want %+v, got %+v", id, want, got)
+		}
+	}
+}
+
+func TestGridLayout(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{Layout: Dimensions{Width: 300, Height: 200}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = context.BeginLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed := func(width, height float32) Sizing {
+		return Sizing{Width: NewSizingAxis(SizingFixed, width), Height: NewSizingAxis(SizingFixed, height)}
+	}
+	cell := func(id string, layout LayoutConfig) {
+		context.Clay(ElementDeclaration{ID: ID(id), Layout: layout})
+	}
+	err = context.Clay(ElementDeclaration{
+		ID: ID("grid"),
+		Layout: LayoutConfig{
+			LayoutDirection: Grid,
+			Sizing:          Sizing{Width: NewSizingAxis(SizingFixed, 200)},
+			GridColumns:     []SizingAxis{NewSizingAxis(SizingFixed, 50), NewSizingAxis(SizingGrow, 0, 0), {}},
+			ChildGap:        10,
+			ChildAlignment:  ChildAlignment{Y: AlignYCenter},
+			Wrap:            true, // No effect on grids.
+		},
+	}, func(context *Context) error {
+		cell("a", LayoutConfig{Sizing: fixed(20, 10)})
+		cell("b", LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingFixed, 10)}})
+		cell("c", LayoutConfig{Sizing: fixed(30, 20)})
+		cell("d", LayoutConfig{Sizing: fixed(100, 10), ColumnSpan: 2})
+		cell("e", LayoutConfig{Sizing: fixed(10, 10)})
+		// "i" skips the cell of "f" in the last row.
+		cell("f", LayoutConfig{Sizing: fixed(20, 30), RowSpan: 2})
+		cell("g", LayoutConfig{Sizing: fixed(10, 10)})
+		cell("h", LayoutConfig{Sizing: fixed(10, 10)})
+		cell("i", LayoutConfig{Sizing: fixed(10, 10)})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = context.EndLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Columns are 50, 100 and 30 wide, rows 20, 10, 10 and 10 high.
+	for id, want := range map[string]BoundingBox{
+		"grid": {Dimensions: Dimensions{Width: 200, Height: 80}},
+		"a":    {Vector2: Vector2{Y: 5}, Dimensions: Dimensions{Width: 20, Height: 10}},
+		"b":    {Vector2: Vector2{X: 60, Y: 5}, Dimensions: Dimensions{Width: 100, Height: 10}},
+		"c":    {Vector2: Vector2{X: 170}, Dimensions: Dimensions{Width: 30, Height: 20}},
+		"d":    {Vector2: Vector2{Y: 30}, Dimensions: Dimensions{Width: 100, Height: 10}},
+		"e":    {Vector2: Vector2{X: 170, Y: 30}, Dimensions: Dimensions{Width: 10, Height: 10}},
+		"f":    {Vector2: Vector2{Y: 50}, Dimensions: Dimensions{Width: 20, Height: 30}},
+		"g":    {Vector2: Vector2{X: 60, Y: 50}, Dimensions: Dimensions{Width: 10, Height: 10}},
+		"h":    {Vector2: Vector2{X: 170, Y: 50}, Dimensions: Dimensions{Width: 10, Height: 10}},
+		"i":    {Vector2: Vector2{X: 60, Y: 70}, Dimensions: Dimensions{Width: 10, Height: 10}},
+	} {
+		if got := context.GetElementData(ID(id)).BoundingBox; got != want {
+			t.Errorf("%s: want %+v, got %+v", id, want, got)
+		}
+	}
+}
+
+func TestDistribute(t *testing.T) {
+	for _, test := range []struct {
+		distribute LayoutDistribution
+		width      float32
+		want       [3]float32
+	}{
+		{distribute: DistributeBetween, width: 200, want: [3]float32{0, 70, 150}},
+		{distribute: DistributeAround, width: 200, want: [3]float32{10, 70, 140}},
+		{distribute: DistributeEvenly, width: 200, want: [3]float32{15, 70, 135}},
+		// Children too wide for the element are still ChildGap apart.
+		{distribute: DistributeBetween, width: 100, want: [3]float32{0, 40, 90}},
+	} {
+		var context Context
+		err := context.Initialize(Config{Layout: Dimensions{Width: 300, Height: 100}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = context.BeginLayout()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = context.Clay(ElementDeclaration{
+			Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, test.width)}, ChildGap: 10, Distribute: test.distribute},
+		}, func(context *Context) error {
+			for i, width := range []float32{30, 40, 50} {
+				context.Clay(ElementDeclaration{
+					ID:     ID("item" + strconv.Itoa(i)),
+					Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, width), Height: NewSizingAxis(SizingFixed, 10)}},
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = context.EndLayout()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, want := range test.want {
+			if got := context.GetElementData(ID("item" + strconv.Itoa(i))).BoundingBox.X; got != want {
+				t.Errorf("%v in %v: item %d want x %v, got %v", test.distribute, test.width, i, want, got)
+			}
+		}
+	}
+}
+
+func TestSelfAndMargin(t *testing.T) {
+	var context Context
+	err := context.Initialize(Config{Layout: Dimensions{Width: 300, Height: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = context.BeginLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = context.Clay(ElementDeclaration{
+		ID:     ID("parent"),
+		Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, 200)}, ChildAlignment: ChildAlignment{Y: AlignYCenter}},
+	}, func(context *Context) error {
+		context.Clay(ElementDeclaration{
+			ID: ID("margin"),
+			Layout: LayoutConfig{
+				Sizing: Sizing{Width: NewSizingAxis(SizingFixed, 20), Height: NewSizingAxis(SizingFixed, 10)},
+				Margin: Padding{Left: 5, Right: 5, Top: 2, Bottom: 2},
+			},
+		})
+		context.Clay(ElementDeclaration{
+			ID:     ID("end"),
+			Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, 30), Height: NewSizingAxis(SizingFixed, 30)}, Self: AlignSelfEnd},
+		})
+		context.Clay(ElementDeclaration{
+			ID: ID("grow"),
+			Layout: LayoutConfig{
+				Sizing: Sizing{Width: NewSizingAxis(SizingGrow, 0, 0), Height: NewSizingAxis(SizingFixed, 10)},
+				Self:   AlignSelfStart,
+				Margin: Padding{Left: 10, Right: 10},
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = context.Clay(ElementDeclaration{
+		ID:     ID("percentRow"),
+		Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, 100)}},
+	}, func(context *Context) error {
+		return context.Clay(ElementDeclaration{
+			ID: ID("percent"),
+			Layout: LayoutConfig{
+				Sizing: Sizing{Width: NewSizingAxis(SizingPercent, 1), Height: NewSizingAxis(SizingFixed, 10)},
+				Margin: Padding{Left: 10, Right: 10},
+			},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = context.EndLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		id   string
+		want BoundingBox
+	}{
+		{id: "parent", want: BoundingBox{Dimensions: Dimensions{Width: 200, Height: 30}}},
+		// Margins are kept outside the element and centered with it.
+		{id: "margin", want: BoundingBox{Vector2: Vector2{X: 5, Y: 10}, Dimensions: Dimensions{Width: 20, Height: 10}}},
+		{id: "end", want: BoundingBox{Vector2: Vector2{X: 30}, Dimensions: Dimensions{Width: 30, Height: 30}}},
+		// Grow elements fill what is left after margins.
+		{id: "grow", want: BoundingBox{Vector2: Vector2{X: 70}, Dimensions: Dimensions{Width: 120, Height: 10}}},
+		// Percentages are of the space left after margins.
+		{id: "percentRow", want: BoundingBox{Vector2: Vector2{X: 200}, Dimensions: Dimensions{Width: 100, Height: 10}}},
+		{id: "percent", want: BoundingBox{Vector2: Vector2{X: 210}, Dimensions: Dimensions{Width: 80, Height: 10}}},
+	} {
+		if got := context.GetElementData(ID(test.id)).BoundingBox; got != test.want {
+			t.Errorf("%s: want %+v, got %+v", test.id, test.want, got)
+		}
+	}
+}
+
+func TestReverseAndMirrored(t *testing.T) {
+	fixed := func(width, height float32) Sizing {
+		return Sizing{Width: NewSizingAxis(SizingFixed, width), Height: NewSizingAxis(SizingFixed, height)}
+	}
+	for _, test := range []struct {
+		name     string
+		mirrored bool
+		layout   LayoutConfig
+		self     []SelfAlignment // Self alignment of the items, auto if not set.
+		want     []BoundingBox
+	}{
+		{
+			// The first child is anchored to the right.
+			name:   "right to left",
+			layout: LayoutConfig{Sizing: fixed(200, 50), ChildGap: 10, Reverse: true},
+			want: []BoundingBox{
+				{Vector2: Vector2{X: 170}, Dimensions: Dimensions{Width: 30, Height: 10}},
+				{Vector2: Vector2{X: 120}, Dimensions: Dimensions{Width: 40, Height: 20}},
+				{Vector2: Vector2{X: 60}, Dimensions: Dimensions{Width: 50, Height: 30}},
+			},
+		},
+		{
+			// AlignXRight is the start of the children of reversed elements.
+			name:   "right to left aligned right",
+			layout: LayoutConfig{Sizing: fixed(200, 50), ChildGap: 10, ChildAlignment: ChildAlignment{X: AlignXRight}, Reverse: true},
+			want: []BoundingBox{
+				{Vector2: Vector2{X: 110}, Dimensions: Dimensions{Width: 30, Height: 10}},
+				{Vector2: Vector2{X: 60}, Dimensions: Dimensions{Width: 40, Height: 20}},
+				{Dimensions: Dimensions{Width: 50, Height: 30}},
+			},
+		},
+		{
+			// The first child is anchored to the bottom.
+			name:   "bottom to top",
+			layout: LayoutConfig{Sizing: fixed(200, 100), LayoutDirection: TopToBottom, Reverse: true},
+			want: []BoundingBox{
+				{Vector2: Vector2{Y: 90}, Dimensions: Dimensions{Width: 30, Height: 10}},
+				{Vector2: Vector2{Y: 70}, Dimensions: Dimensions{Width: 40, Height: 20}},
+				{Vector2: Vector2{Y: 40}, Dimensions: Dimensions{Width: 50, Height: 30}},
+			},
+		},
+		{
+			// Padding and alignment swap sides and the element is placed right to left in the root.
+			name:     "mirrored",
+			mirrored: true,
+			layout:   LayoutConfig{Sizing: fixed(200, 50), Padding: Padding{Left: 5}, ChildGap: 10},
+			want: []BoundingBox{
+				{Vector2: Vector2{X: 265}, Dimensions: Dimensions{Width: 30, Height: 10}},
+				{Vector2: Vector2{X: 215}, Dimensions: Dimensions{Width: 40, Height: 20}},
+				{Vector2: Vector2{X: 155}, Dimensions: Dimensions{Width: 50, Height: 30}},
+			},
+		},
+		{
+			// Self alignment across a mirrored column starts at its right like the auto aligned child.
+			name:     "mirrored column",
+			mirrored: true,
+			layout:   LayoutConfig{Sizing: fixed(200, 100), LayoutDirection: TopToBottom},
+			self:     []SelfAlignment{AlignSelfStart, AlignSelfEnd},
+			want: []BoundingBox{
+				{Vector2: Vector2{X: 270}, Dimensions: Dimensions{Width: 30, Height: 10}},
+				{Vector2: Vector2{X: 100, Y: 10}, Dimensions: Dimensions{Width: 40, Height: 20}},
+				{Vector2: Vector2{X: 250, Y: 30}, Dimensions: Dimensions{Width: 50, Height: 30}},
+			},
+		},
+	} {
+		var context Context
+		err := context.Initialize(Config{Layout: Dimensions{Width: 300, Height: 100}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		context.Mirrored = test.mirrored
+		err = context.BeginLayout()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = context.Clay(ElementDeclaration{Layout: test.layout}, func(context *Context) error {
+			for i := range test.want {
+				layout := LayoutConfig{Sizing: fixed(float32(30+10*i), float32(10+10*i))}
+				if i < len(test.self) {
+					layout.Self = test.self[i]
+				}
+				context.Clay(ElementDeclaration{ID: ID("item" + strconv.Itoa(i)), Layout: layout})
+			}
+			return context.Clay(ElementDeclaration{
+				ID:       ID("floating"),
+				Layout:   LayoutConfig{Sizing: fixed(20, 20)},
+				Floating: FloatingElementConfig{AttachTo: AttachToParent, Offset: Vector2{X: 10}},
+			})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = context.EndLayout()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, want := range test.want {
+			if got := context.GetElementData(ID("item" + strconv.Itoa(i))).BoundingBox; got != want {
+				t.Errorf("%s: item %d want %+v, got %+v", test.name, i, want, got)
+			}
+		}
+		// Floating elements attach to the left of their parent, or its right when mirrored.
+		wantX := float32(10)
+		if test.mirrored {
+			wantX = 270
+		}
+		if got := context.GetElementData(ID("floating")).BoundingBox.X; got != wantX {
+			t.Errorf("%s: floating want x %v, got %v", test.name, wantX, got)
+		}
+	}
+
+	// Borders between children follow them to the end of reversed elements.
+	cmds := layoutTextContainer(t, newTextTestContext(t), ElementDeclaration{
+		Layout: LayoutConfig{Sizing: fixed(200, 10), ChildGap: 10, Reverse: true},
+		Border: BorderElementConfig{Color: Color{A: 255}, Width: BorderWidth{BetweenChildren: 2}},
+	}, func(context *Context) error {
+		context.Clay(ElementDeclaration{Layout: LayoutConfig{Sizing: fixed(30, 10)}})
+		return context.Clay(ElementDeclaration{Layout: LayoutConfig{Sizing: fixed(40, 10)}})
+	})
+	var borders []float32
+	for _, cmd := range cmds {
+		if cmd.CommandType == RenderCommandTypeRectangle {
+			borders = append(borders, cmd.BoundingBox.X)
+		}
+	}
+	if want := []float32{165}; !slices.Equal(borders, want) {
+		t.Errorf("borders between children: want x %v, got %v", want, borders)
+	}
+}
+
+func TestBidiText(t *testing.T) {
+	type run struct {
+		text        string
+		x, y        float32
+		rightToLeft bool
+	}
+	runs := func(cmds []RenderCommand) (got []run) {
+		for _, cmd := range cmds {
+			data := cmd.RenderData.(*TextRenderData)
+			got = append(got, run{string(data.Contents), cmd.BoundingBox.X, cmd.BoundingBox.Y, data.RightToLeft})
+		}
+		return got
+	}
+	config := TextElementConfig{FontSize: 10}
+	for _, test := range []struct {
+		name  string
+		width float32
+		fn    func(context *Context) error
+		want  []run
+	}{
+		{
+			// Numbers and Latin text are left to right runs within a right-to-left paragraph.
+			name:  "mixed",
+			width: 100,
+			fn:    func(context *Context) error { return context.Text("אב 12 cd", &config) },
+			want:  []run{{"cd", 0, 0, false}, {" ", 20, 0, true}, {"12", 30, 0, false}, {"אב ", 50, 0, true}},
+		},
+		{
+			// TextAlignLeft is the start of the line in a right-to-left paragraph.
+			name:  "align",
+			width: 50,
+			fn:    func(context *Context) error { return context.Text("אבג דהו", &config) },
+			want:  []run{{"אבג", 20, 0, true}, {"דהו", 20, 10, true}},
+		},
+		{
+			name:  "ltr",
+			width: 100,
+			fn:    func(context *Context) error { return context.Text("ab אב", &config) },
+			want:  []run{{"ab ", 0, 0, false}, {"אב", 30, 0, true}},
+		},
+		{
+			name:  "rich",
+			width: 100,
+			fn: func(context *Context) error {
+				return context.RichText([]TextSpan{{Text: "אב ", Config: &config}, {Text: "cd", Config: &config}})
+			},
+			want: []run{{"cd", 0, 0, false}, {"אב ", 20, 0, true}},
+		},
+	} {
+		got := runs(layoutTextElement(t, test.width, test.fn))
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: want runs %+v, got %+v", test.name, test.want, got)
+		}
+	}
+
+	context := newTextTestContext(t)
+	layoutTextContainer(t, context, ElementDeclaration{ID: ID("bidi")}, func(context *Context) error {
+		return context.Text("אב 12 cd", &config)
+	})
+	id := ID("bidi")
+	if got, _ := context.TextHitTest(id, Vector2{X: 53, Y: 5}); got != 5 {
+		t.Errorf("hit in right-to-left run: want offset 5, got %d", got)
+	}
+	if got := context.TextCaretRect(id, 2); got.X != 70 {
+		t.Errorf("caret in right-to-left run: want x 70, got %v", got.X)
+	}
+	rects := context.TextSelectionRects(id, 2, 6, nil)
+	// The selection of "ב 1" is split by the unselected "2" on screen.
+	want := []BoundingBox{
+		{Vector2: Vector2{X: 30}, Dimensions: Dimensions{Width: 10, Height: 10}},
+		{Vector2: Vector2{X: 50}, Dimensions: Dimensions{Width: 20, Height: 10}},
+	}
+	if !slices.Equal(rects, want) {
+		t.Errorf("selection: want %+v, got %+v", want, rects)
+	}
+
+	// The widths of reordered runs are cached like the measurements of text.
+	measure := context.MeasureTextFunction
+	var measured []string
+	context.MeasureTextFunction = func(text string, config *TextElementConfig, userData any) Dimensions {
+		measured = append(measured, text)
+		return measure(text, config, userData)
+	}
+	layoutTextContainer(t, context, ElementDeclaration{ID: ID("bidi")}, func(context *Context) error {
+		return context.Text("אב 12 cd", &config)
+	})
+	if len(measured) != 0 {
+		t.Errorf("bidi text measured again: %q", measured)
+	}
+}
+
+// wrapText lays out text in a container of the given width and returns the rendered lines.
+// Characters are measured as 10 units wide.
+func wrapText(t *testing.T, text string, width float32, config TextElementConfig) []string {
+	t.Helper()
+	var lines []string
+	var lineY, lineEnd float32
+	for i, cmd := range layoutText(t, text, width, config) {
+		// The parts of a shortened line are separate commands.
+		contents := string(cmd.RenderData.(*TextRenderData).Contents)
+		if i > 0 && cmd.BoundingBox.Y == lineY {
+			lines[len(lines)-1] += contents
+		} else {
+			lines = append(lines, contents)
+		}
+		lineY, lineEnd = cmd.BoundingBox.Y, cmd.BoundingBox.X+cmd.BoundingBox.Width
+		if lineEnd > width {
+			t.Errorf("line %q overflows: %+v", lines[len(lines)-1], cmd.BoundingBox)
+		}
+	}
+	return lines
+}
+
+// layoutText lays out text like wrapText and returns the text render commands.
+func layoutText(t *testing.T, text string, width float32, config TextElementConfig) []RenderCommand {
+	t.Helper()
+	return layoutTextElement(t, width, func(context *Context) error {
+		return context.Text(text, &config)
+	})
+}
+
+// layoutTextElement lays out the text declared by fn in a container of the given width and returns the text render commands.
+// Characters are measured as 10 units wide and as tall as the font size, at least 10 units.
+func layoutTextElement(t *testing.T, width float32, fn func(context *Context) error) []RenderCommand {
+	t.Helper()
+	context := newTextTestContext(t)
+	cmds := layoutTextContainer(t, context, ElementDeclaration{
+		Layout: LayoutConfig{Sizing: Sizing{Width: NewSizingAxis(SizingFixed, width)}},
+	}, fn)
+	var textCmds []RenderCommand
+	for _, cmd := range cmds {
+		if cmd.CommandType == RenderCommandTypeText {
+			textCmds = append(textCmds, cmd)
+		}
+	}
+	return textCmds
+}
+
+// newTextTestContext returns a 100x100 Context measuring characters as 10 units wide
+// and as tall as the font size, at least 10 units.
+func newTextTestContext(t *testing.T) *Context {
+	t.Helper()
+	var context Context
+	err := context.Initialize(Config{
+		Layout: Dimensions{Width: 100, Height: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	context.MeasureTextFunction = func(text string, config *TextElementConfig, userData any) Dimensions {
+		if strings.ContainsRune(text, utf8.RuneError) {
+			t.Errorf("measured text with split rune: %q", text)
+		}
+		return Dimensions{Width: 10 * floatn(utf8.RuneCountInString(text)), Height: max(10, floatn(config.FontSize))}
+	}
+	return &context
+}
+
+// layoutTextContainer lays out a single container declaring its children with fn and returns the render commands.
+func layoutTextContainer(t *testing.T, context *Context, decl ElementDeclaration, fn func(context *Context) error) []RenderCommand {
+	t.Helper()
+	err := context.BeginLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = context.Clay(decl, fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds, err := context.EndLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cmds
+}
+
+func TestLineBreakConformance(t *testing.T) {
+	fp, err := os.Open("testdata/LineBreakTest.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	scanner := bufio.NewScanner(fp)
+	var cases, failures int
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		data, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(data)
+		if len(fields) == 0 {
+			continue
+		}
+		cases++
+		// Fields alternate between break markers and code points: × 0023 ÷ 0020 ÷
+		var text strings.Builder
+		var want []int
+		for i := 1; i < len(fields); i += 2 {
+			cp, err := strconv.ParseUint(fields[i], 16, 32)
+			if err != nil {
+				t.Fatalf("line %d: %v", lineNum, err)
+			}
+			text.WriteRune(rune(cp))
+			if fields[i+1] == "÷" {
+				want = append(want, text.Len())
+			}
+		}
+		lb := lineBreaker{text: text.String()}
+		var got []int
+		for end := 0; end < text.Len(); {
+			end, _ = lb.next()
+			got = append(got, end)
+		}
+		if !slices.Equal(got, want) {
+			failures++
+			if failures <= 10 {
+				t.Errorf("line %d: %s\nwant breaks %v, got %v", lineNum, scanner.Text(), want, got)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if failures > 0 {
+		t.Errorf("%d of %d test cases failed", failures, cases)
+	}
+}
+
+// TestBidiConformance checks the resolved levels and the visual order of the test cases in
+// testdata/BidiCharacterTest.txt, a subset in the format of BidiCharacterTest.txt of the Unicode
+// version of bidi_tables.go. The full file can replace it, see the header of the subset.
+// Only paragraphs of automatic direction are tested, the direction of text is not configurable.
+func TestBidiConformance(t *testing.T) {
+	fp, err := os.Open("testdata/BidiCharacterTest.txt")
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skip("testdata/BidiCharacterTest.txt not found")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	var b bidiText
+	b.init(nil)
+	scanner := bufio.NewScanner(fp)
+	var cases, failures int
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		data, _, _ := strings.Cut(scanner.Text(), "#")
+		// Fields are the code points, paragraph direction, paragraph level, levels and visual order.
+		fields := strings.Split(data, ";")
+		if len(fields) != 5 || strings.TrimSpace(fields[1]) != "2" {
+			continue
+		}
+		var text strings.Builder
+		separators := 0
+		for _, field := range strings.Fields(fields[0]) {
+			cp, err := strconv.ParseUint(field, 16, 32)
+			if err != nil {
+				t.Fatalf("line %d: %v", lineNum, err)
+			}
+			text.WriteRune(rune(cp))
+			if bidiClassOf(rune(cp)) == bcB {
+				separators++
+			}
+		}
+		if separators > 0 {
+			continue // Text is resolved as a single paragraph per line.
+		}
+		cases++
+		b.reset()
+		if !b.add(text.String(), 0) {
+			t.Fatalf("line %d: text too long", lineNum)
+		}
+		b.resolve()
+		// Levels of each character, taken from the line runs which have rule L1 applied.
+		levels := make([]string, len(b.runes))
+		var order []string
+		for _, run := range b.lineRuns(0, b.length) {
+			first, last := b.runeIndex(run.start), b.runeIndex(run.end)
+			for k := first; k < last; k++ {
+				i := k
+				if run.level%2 == 1 {
+					i = first + last - 1 - k
+				}
+				if b.classes[i].isRemoved() {
+					levels[i] = "x"
+					continue
+				}
+				levels[i] = strconv.Itoa(int(run.level))
+				order = append(order, strconv.Itoa(i))
+			}
+		}
+		wantLevel := strings.TrimSpace(fields[2])
+		gotLevel := strconv.Itoa(int(b.paragraphLevel(0)))
+		wantLevels, wantOrder := strings.Fields(fields[3]), strings.Fields(fields[4])
+		if gotLevel != wantLevel || !slices.Equal(levels, wantLevels) || !slices.Equal(order, wantOrder) {
+			failures++
+			if failures <= 10 {
+				t.Errorf("line %d: %s\nwant paragraph level %s, levels %v, order %v\ngot paragraph level %s, levels %v, order %v",
+					lineNum, scanner.Text(), wantLevel, wantLevels, wantOrder, gotLevel, levels, order)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if failures > 0 {
+		t.Errorf("%d of %d test cases failed", failures, cases)
+	}
+}
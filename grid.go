@@ -0,0 +1,279 @@
+package glay
+
+// placeGridChildren places the children of a closed Grid element in the first free cells, row by
+// row, and reserves the sizes of its tracks.
+func (context *Context) placeGridChildren(element *LayoutElement) {
+	layoutConfig := element.LayoutConfig
+	columns := max(arrlen(layoutConfig.GridColumns), 1)
+	if arrcap(context.gridFreeRows) < columns {
+		context.elementsCapacityExceeded()
+		return
+	}
+	freeRows := context.gridFreeRows[:columns]
+	clear(freeRows)
+	rows := arrlen(layoutConfig.GridRows)
+	var row, column intn
+	for _, child := range element.Children() {
+		childElement := &context.LayoutElements[child]
+		area := gridArea{
+			columns: min(max(intn(childElement.LayoutConfig.ColumnSpan), 1), columns),
+			rows:    max(intn(childElement.LayoutConfig.RowSpan), 1),
+		}
+		for {
+			if column+area.columns > columns {
+				column = 0
+				row++
+			}
+			free := true
+			for _, freeRow := range freeRows[column : column+area.columns] {
+				free = free && row >= freeRow
+			}
+			if free {
+				break
+			}
+			column++
+		}
+		area.column, area.row = column, row
+		for i := range freeRows[column : column+area.columns] {
+			freeRows[column+intn(i)] = row + area.rows
+		}
+		childElement.gridArea = area
+		rows = max(rows, row+area.rows)
+		column += area.columns
+	}
+	if arrfree(context.gridTracks) < columns+rows {
+		context.elementsCapacityExceeded()
+		return
+	}
+	element.gridTracks = arrextend(context.gridTracks, columns+rows)[arrlen(context.gridTracks):]
+	context.gridTracks = arrextend(context.gridTracks, columns+rows)
+}
+
+// axis returns the first cell and the number of cells of the area along the axis.
+func (area gridArea) axis(xaxis bool) (cell, span intn) {
+	if xaxis {
+		return area.column, area.columns
+	}
+	return area.row, area.rows
+}
+
+// gridAxisTracks returns the track sizes of a Grid element along the axis and their definitions.
+// Elements whose tracks could not be reserved have none.
+func (le *LayoutElement) gridAxisTracks(xaxis bool) (tracks []floatn, definitions []SizingAxis) {
+	if len(le.gridTracks) == 0 {
+		return nil, nil
+	}
+	columns := max(arrlen(le.LayoutConfig.GridColumns), 1)
+	if xaxis {
+		return le.gridTracks[:columns], le.LayoutConfig.GridColumns
+	}
+	return le.gridTracks[columns:], le.LayoutConfig.GridRows
+}
+
+// gridTrackDefinition returns the sizing of track i, which fits its content if it is not defined.
+func gridTrackDefinition(definitions []SizingAxis, i intn) SizingAxis {
+	if i < arrlen(definitions) {
+		return definitions[i]
+	}
+	return SizingAxis{}
+}
+
+// gridTrackResizable reports whether track i is sized to its content.
+func gridTrackResizable(definitions []SizingAxis, i intn) bool {
+	sizing := gridTrackDefinition(definitions, i).Type
+	return sizing == SizingFit || sizing == SizingGrow
+}
+
+func gridTrackMax(definition SizingAxis) floatn {
+	if definition.MinMax.Max <= 0 {
+		return maxfloat
+	}
+	return definition.MinMax.Max
+}
+
+// gridTrackOffset returns the offset of track i from the first track.
+func gridTrackOffset(tracks []floatn, i intn, childGap floatn) (offset floatn) {
+	for _, track := range tracks[:i] {
+		offset += track + childGap
+	}
+	return offset
+}
+
+// gridTracksSize returns the size of all tracks and the gaps between them.
+func gridTracksSize(tracks []floatn, childGap floatn) floatn {
+	if len(tracks) == 0 {
+		return 0
+	}
+	return gridTrackOffset(tracks, arrlen(tracks), childGap) - childGap
+}
+
+// sizeGridTracks sizes the tracks of a Grid element along the axis to fit its children, or their
+// minimum dimensions if minimum is set, and returns the size of the tracks and gaps. Percent tracks
+// take their share of the available size, then fit and grow tracks shrink to fit in it or grow
+// tracks expand to fill what is left of it. A negative available size leaves tracks to their content.
+func (context *Context) sizeGridTracks(element *LayoutElement, xaxis bool, available floatn, minimum bool) floatn {
+	tracks, definitions := element.gridAxisTracks(xaxis)
+	childGap := floatn(element.LayoutConfig.ChildGap)
+	for i := range tracks {
+		definition := gridTrackDefinition(definitions, intn(i))
+		if definition.Type == SizingPercent {
+			tracks[i] = max(available-floatn(len(tracks)-1)*childGap, 0) * definition.Percent
+		} else {
+			tracks[i] = definition.MinMax.Min
+		}
+	}
+	if tracks == nil {
+		return 0
+	}
+	children := element.Children()
+	childSize := func(childElement *LayoutElement) floatn {
+		if minimum {
+			return childElement.MinDimensions.SizeAxis(xaxis) + childElement.LayoutConfig.Margin.SizeAxis(xaxis)
+		}
+		return childElement.outerSize(xaxis)
+	}
+	// Fit tracks to the children in a single cell first, then to those spanning several.
+	for _, child := range children {
+		childElement := &context.LayoutElements[child]
+		cell, span := childElement.gridArea.axis(xaxis)
+		if span == 1 && gridTrackResizable(definitions, cell) {
+			tracks[cell] = max(tracks[cell], min(childSize(childElement), gridTrackMax(gridTrackDefinition(definitions, cell))))
+		}
+	}
+	for _, child := range children {
+		childElement := &context.LayoutElements[child]
+		cell, span := childElement.gridArea.axis(xaxis)
+		if span == 1 {
+			continue
+		}
+		spanned := tracks[cell : cell+span]
+		extra := childSize(childElement) - gridTracksSize(spanned, childGap)
+		var resizable intn
+		for i := range spanned {
+			if gridTrackResizable(definitions, cell+intn(i)) {
+				resizable++
+			}
+		}
+		if extra <= 0 || resizable == 0 {
+			continue
+		}
+		for i := range spanned {
+			if gridTrackResizable(definitions, cell+intn(i)) {
+				spanned[i] += extra / floatn(resizable)
+			}
+		}
+	}
+	if available < 0 {
+		return gridTracksSize(tracks, childGap)
+	}
+
+	// Tracks too large for the available size shrink down to their content, the largest first.
+	sizeToDistribute := available - gridTracksSize(tracks, childGap)
+	for sizeToDistribute < -eps {
+		var largest, secondLargest floatn
+		var count intn
+		for i, track := range tracks {
+			if !gridTrackResizable(definitions, intn(i)) || track <= context.gridTrackMin(element, intn(i), xaxis)+eps {
+				continue
+			}
+			if floatequal(track, largest) {
+				count++
+			} else if track > largest {
+				secondLargest = largest
+				largest = track
+				count = 1
+			} else {
+				secondLargest = max(secondLargest, track)
+			}
+		}
+		if count == 0 {
+			break
+		}
+		sizeToAdd := max(secondLargest-largest, sizeToDistribute/floatn(count))
+		for i, track := range tracks {
+			minSize := context.gridTrackMin(element, intn(i), xaxis)
+			if !gridTrackResizable(definitions, intn(i)) || track <= minSize+eps || !floatequal(track, largest) {
+				continue
+			}
+			tracks[i] = max(track+sizeToAdd, minSize)
+			sizeToDistribute -= tracks[i] - track
+		}
+	}
+	// Grow tracks expand to fill the available size, the smallest first.
+	for sizeToDistribute > eps {
+		smallest, secondSmallest := maxfloat, maxfloat
+		var count intn
+		for i, track := range tracks {
+			definition := gridTrackDefinition(definitions, intn(i))
+			if definition.Type != SizingGrow || track >= gridTrackMax(definition) {
+				continue
+			}
+			if floatequal(track, smallest) {
+				count++
+			} else if track < smallest {
+				secondSmallest = smallest
+				smallest = track
+				count = 1
+			} else {
+				secondSmallest = min(secondSmallest, track)
+			}
+		}
+		if count == 0 {
+			break
+		}
+		sizeToAdd := min(secondSmallest-smallest, sizeToDistribute/floatn(count))
+		for i, track := range tracks {
+			definition := gridTrackDefinition(definitions, intn(i))
+			if definition.Type != SizingGrow || track >= gridTrackMax(definition) || !floatequal(track, smallest) {
+				continue
+			}
+			tracks[i] = min(track+sizeToAdd, gridTrackMax(definition))
+			sizeToDistribute -= tracks[i] - track
+		}
+	}
+	return gridTracksSize(tracks, childGap)
+}
+
+// gridTrackMin returns the minimum size of track i of a Grid element along the axis, that of its
+// definition or of the children only in its cells.
+func (context *Context) gridTrackMin(element *LayoutElement, i intn, xaxis bool) floatn {
+	_, definitions := element.gridAxisTracks(xaxis)
+	size := gridTrackDefinition(definitions, i).MinMax.Min
+	for _, child := range element.Children() {
+		childElement := &context.LayoutElements[child]
+		if cell, span := childElement.gridArea.axis(xaxis); cell == i && span == 1 {
+			size = max(size, childElement.MinDimensions.SizeAxis(xaxis)+childElement.LayoutConfig.Margin.SizeAxis(xaxis))
+		}
+	}
+	return size
+}
+
+// gridCell returns the offset from the first track of a Grid element to the cells of the child
+// along the axis and their size, gaps included.
+func (context *Context) gridCell(element, child *LayoutElement, xaxis bool) (offset, size floatn) {
+	tracks, _ := element.gridAxisTracks(xaxis)
+	if tracks == nil {
+		return 0, child.outerSize(xaxis)
+	}
+	childGap := floatn(element.LayoutConfig.ChildGap)
+	cell, span := child.gridArea.axis(xaxis)
+	return gridTrackOffset(tracks, cell, childGap), gridTracksSize(tracks[cell:cell+span], childGap)
+}
+
+// sizeGrid sizes the tracks of a Grid element along the axis to the element, then its children to
+// their cells.
+func (context *Context) sizeGrid(element *LayoutElement, xaxis bool) {
+	available := element.Dimensions.SizeAxis(xaxis) - element.LayoutConfig.Padding.SizeAxis(xaxis)
+	context.sizeGridTracks(element, xaxis, available, false)
+	for _, child := range element.Children() {
+		childElement := &context.LayoutElements[child]
+		_, cellSize := context.gridCell(element, childElement, xaxis)
+		sizing := childElement.LayoutConfig.Sizing.SizingAxis(xaxis)
+		if sizing.Type == SizingPercent {
+			*childElement.Dimensions.SizeAxisPtr(xaxis) = (cellSize - childElement.LayoutConfig.Margin.SizeAxis(xaxis)) * sizing.Percent
+			childElement.UpdateAspectRatioBox()
+		} else if childElement.resizableAlongAxis(xaxis) {
+			childElement.sizeOffAxis(cellSize, xaxis)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package glay
+
+import "unicode/utf8"
+
+//go:generate go run gen_linebreak.go -version 15.0.0 -o linebreak_tables.go
+
+// lbClass is a Line_Break property value of the Unicode Line Breaking Algorithm (UAX #14)
+// after the resolution of rule LB1: AI, SG and XX are resolved to AL, CJ to NS and SA to CM or AL.
+// Some classes are split further where rules depend on other properties of the character.
+type lbClass uint8
+
+const (
+	lbNone lbClass = iota // Start of text, not a Line_Break value.
+	lbBK
+	lbCR
+	lbLF
+	lbNL
+	lbSP
+	lbZW
+	lbZWJ
+	lbCM
+	lbWJ
+	lbGL
+	lbCL
+	lbCP
+	lbCPEA // CP with East_Asian_Width F, W or H, excluded from LB30.
+	lbEX
+	lbIS
+	lbSY
+	lbOP
+	lbOPEA // OP with East_Asian_Width F, W or H, excluded from LB30.
+	lbQU
+	lbNS
+	lbB2
+	lbBA
+	lbHY
+	lbBB
+	lbCB
+	lbHL
+	lbIN
+	lbNU
+	lbPR
+	lbPO
+	lbAL
+	lbID
+	lbIDCn // Unassigned Extended_Pictographic code points, see LB30b.
+	lbEB
+	lbEM
+	lbH2
+	lbH3
+	lbJL
+	lbJV
+	lbJT
+	lbRI
+)
+
+type lineBreakRange struct {
+	lo, hi rune
+	class  lbClass
+}
+
+// lineBreakClass returns the resolved line break class of r.
+func lineBreakClass(r rune) lbClass {
+	lo, hi := 0, len(lineBreakRanges)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		switch rng := &lineBreakRanges[mid]; {
+		case r < rng.lo:
+			hi = mid
+		case r > rng.hi:
+			lo = mid + 1
+		default:
+			return rng.class
+		}
+	}
+	return lbAL
+}
+
+func (c lbClass) isOP() bool { return c == lbOP || c == lbOPEA }
+func (c lbClass) isCP() bool { return c == lbCP || c == lbCPEA }
+func (c lbClass) isID() bool { return c == lbID || c == lbIDCn }
+
+// isHardBreak reports whether c is one of the classes that end a line, see LB4 and LB5.
+func (c lbClass) isHardBreak() bool { return c == lbBK || c == lbCR || c == lbLF || c == lbNL }
+
+type lineBreakAction uint8
+
+const (
+	lineBreakProhibited lineBreakAction = iota
+	lineBreakAllowed
+	lineBreakMandatory
+)
+
+// lineBreaker finds the line break opportunities of UTF-8 text with the Unicode Line Breaking
+// Algorithm (UAX #14), including the tailoring of numbers in LB25 described in its Example 7.
+type lineBreaker struct {
+	text string
+	pos  int // Offset of the next rune to be classified.
+	// Class of the previous character after LB9 and LB10, lbNone at the start of text.
+	prev lbClass
+	// Class of the character before prev, used by LB21a.
+	prevprev lbClass
+	// Class of the last character that is not SP, used by the "X SP* ×" rules.
+	base lbClass
+	// The previous character is a ZWJ, see LB8a.
+	afterZWJ bool
+	// prev ends a sequence matching NU (NU | SY | IS)*, see LB25.
+	inNumber bool
+	// prev is a CL or CP that ends a number, see LB25.
+	numberClosed bool
+	// Count of consecutive regional indicators ending at prev, see LB30a.
+	riCount int
+}
+
+// next returns the end offset of the next line segment, which is the position of the next
+// break opportunity. mandatory reports whether the segment ends with a forced line break.
+// The end of text is always a break opportunity. next returns len(text) once the text is consumed.
+func (lb *lineBreaker) next() (end int, mandatory bool) {
+	start := lb.pos
+	for lb.pos < len(lb.text) {
+		r, size := utf8.DecodeRuneInString(lb.text[lb.pos:])
+		class := lineBreakClass(r)
+		action := lineBreakProhibited
+		// Segments are never empty, which also implements LB2: Never break at the start of text.
+		if lb.pos > start {
+			action = lb.action(class, lb.pos+size)
+		}
+		if action != lineBreakProhibited {
+			return lb.pos, action == lineBreakMandatory
+		}
+		lb.consume(class)
+		lb.pos += size
+	}
+	// LB3: Always break at the end of text.
+	return len(lb.text), lb.prev.isHardBreak()
+}
+
+// consume updates the state after a character of the given class.
+func (lb *lineBreaker) consume(class lbClass) {
+	isMark := class == lbCM || class == lbZWJ
+	lb.afterZWJ = class == lbZWJ
+	if isMark && lb.prev != lbNone && lb.prev != lbSP && lb.prev != lbZW && !lb.prev.isHardBreak() {
+		return // LB9: Treat X (CM | ZWJ)* as X.
+	}
+	if isMark {
+		class = lbAL // LB10: Treat any remaining CM or ZWJ as AL.
+	}
+	lb.numberClosed = lb.inNumber && class.isCP() || lb.inNumber && class == lbCL
+	lb.inNumber = class == lbNU || lb.inNumber && (class == lbSY || class == lbIS)
+	if class == lbRI {
+		lb.riCount++
+	} else {
+		lb.riCount = 0
+	}
+	lb.prevprev = lb.prev
+	lb.prev = class
+	if class != lbSP {
+		lb.base = class
+	}
+}
+
+// peek returns the class of the character starting at offset pos with combining marks skipped as in LB9,
+// or lbNone at the end of text.
+func (lb *lineBreaker) peek(pos int) lbClass {
+	for pos < len(lb.text) {
+		r, size := utf8.DecodeRuneInString(lb.text[pos:])
+		class := lineBreakClass(r)
+		if class != lbCM && class != lbZWJ {
+			return class
+		}
+		pos += size
+	}
+	return lbNone
+}
+
+// action returns whether a line may be broken between prev and a character of the given class.
+// after is the offset following the character, used for rules that look ahead.
+func (lb *lineBreaker) action(class lbClass, after int) lineBreakAction {
+	prev := lb.prev
+	switch {
+	case prev == lbBK: // LB4
+		return lineBreakMandatory
+	case prev == lbCR && class == lbLF: // LB5
+		return lineBreakProhibited
+	case prev == lbCR || prev == lbLF || prev == lbNL:
+		return lineBreakMandatory
+	case class.isHardBreak(): // LB6
+		return lineBreakProhibited
+	case class == lbSP || class == lbZW: // LB7
+		return lineBreakProhibited
+	case lb.base == lbZW && (prev == lbZW || prev == lbSP): // LB8
+		return lineBreakAllowed
+	case lb.afterZWJ: // LB8a
+		return lineBreakProhibited
+	case class == lbCM || class == lbZWJ:
+		if prev != lbSP && prev != lbZW {
+			return lineBreakProhibited // LB9
+		}
+		class = lbAL // LB10
+	}
+	spaces := prev == lbSP
+	base := lb.base
+	switch {
+	case class == lbWJ || prev == lbWJ: // LB11
+		return lineBreakProhibited
+	case prev == lbGL: // LB12
+		return lineBreakProhibited
+	case class == lbGL && prev != lbSP && prev != lbBA && prev != lbHY: // LB12a
+		return lineBreakProhibited
+	case class == lbCL || class.isCP() || class == lbEX || class == lbIS || class == lbSY: // LB13
+		return lineBreakProhibited
+	case base.isOP(): // LB14
+		return lineBreakProhibited
+	case base == lbQU && class.isOP(): // LB15
+		return lineBreakProhibited
+	case (base == lbCL || base.isCP()) && class == lbNS: // LB16
+		return lineBreakProhibited
+	case base == lbB2 && class == lbB2: // LB17
+		return lineBreakProhibited
+	case spaces: // LB18
+		return lineBreakAllowed
+	case class == lbQU || prev == lbQU: // LB19
+		return lineBreakProhibited
+	case class == lbCB || prev == lbCB: // LB20
+		return lineBreakAllowed
+	case class == lbBA || class == lbHY || class == lbNS || prev == lbBB: // LB21
+		return lineBreakProhibited
+	case lb.prevprev == lbHL && (prev == lbHY || prev == lbBA): // LB21a
+		return lineBreakProhibited
+	case prev == lbSY && class == lbHL: // LB21b
+		return lineBreakProhibited
+	case class == lbIN: // LB22
+		return lineBreakProhibited
+	case (prev == lbAL || prev == lbHL) && class == lbNU, prev == lbNU && (class == lbAL || class == lbHL): // LB23
+		return lineBreakProhibited
+	case prev == lbPR && (class.isID() || class == lbEB || class == lbEM), (prev.isID() || prev == lbEB || prev == lbEM) && class == lbPO: // LB23a
+		return lineBreakProhibited
+	case (prev == lbPR || prev == lbPO) && (class == lbAL || class == lbHL), (prev == lbAL || prev == lbHL) && (class == lbPR || class == lbPO): // LB24
+		return lineBreakProhibited
+	case lb.continuesNumber(class, after): // LB25
+		return lineBreakProhibited
+	case prev == lbJL && (class == lbJL || class == lbJV || class == lbH2 || class == lbH3), // LB26
+		(prev == lbJV || prev == lbH2) && (class == lbJV || class == lbJT),
+		(prev == lbJT || prev == lbH3) && class == lbJT:
+		return lineBreakProhibited
+	case (prev == lbJL || prev == lbJV || prev == lbJT || prev == lbH2 || prev == lbH3) && class == lbPO, // LB27
+		prev == lbPR && (class == lbJL || class == lbJV || class == lbJT || class == lbH2 || class == lbH3):
+		return lineBreakProhibited
+	case (prev == lbAL || prev == lbHL) && (class == lbAL || class == lbHL): // LB28
+		return lineBreakProhibited
+	case prev == lbIS && (class == lbAL || class == lbHL): // LB29
+		return lineBreakProhibited
+	case (prev == lbAL || prev == lbHL || prev == lbNU) && class == lbOP, prev == lbCP && (class == lbAL || class == lbHL || class == lbNU): // LB30
+		return lineBreakProhibited
+	case prev == lbRI && class == lbRI && lb.riCount%2 == 1: // LB30a
+		return lineBreakProhibited
+	case (prev == lbEB || prev == lbIDCn) && class == lbEM: // LB30b
+		return lineBreakProhibited
+	}
+	return lineBreakAllowed // LB31
+}
+
+// continuesNumber implements the LB25 rules of the numbers tailoring:
+//
+//	(PR | PO) × (OP | HY)? NU
+//	(OP | HY) × NU
+//	NU (NU | SY | IS)* × (NU | SY | IS | CL | CP)
+//	NU (NU | SY | IS)* (CL | CP)? × (PR | PO)
+func (lb *lineBreaker) continuesNumber(class lbClass, after int) bool {
+	prev := lb.prev
+	switch {
+	case (prev == lbPR || prev == lbPO) && class == lbNU:
+		return true
+	case (prev == lbPR || prev == lbPO) && (class.isOP() || class == lbHY):
+		return lb.peek(after) == lbNU
+	case (prev.isOP() || prev == lbHY) && class == lbNU:
+		return true
+	case lb.inNumber && (class == lbNU || class == lbSY || class == lbIS || class == lbCL || class.isCP()):
+		return true
+	case (lb.inNumber || lb.numberClosed) && (class == lbPR || class == lbPO):
+		return true
+	}
+	return false
+}
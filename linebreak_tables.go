@@ -0,0 +1,1955 @@
+// Code generated by gen_linebreak.go from Unicode 15.0.0 data. DO NOT EDIT.
+
+package glay
+
+// lineBreakRanges holds the resolved line break classes of all code points that are not of class AL, sorted.
+var lineBreakRanges = [...]lineBreakRange{
+	{0x0000, 0x0008, lbCM},
+	{0x0009, 0x0009, lbBA},
+	{0x000A, 0x000A, lbLF},
+	{0x000B, 0x000C, lbBK},
+	{0x000D, 0x000D, lbCR},
+	{0x000E, 0x001F, lbCM},
+	{0x0020, 0x0020, lbSP},
+	{0x0021, 0x0021, lbEX},
+	{0x0022, 0x0022, lbQU},
+	{0x0024, 0x0024, lbPR},
+	{0x0025, 0x0025, lbPO},
+	{0x0027, 0x0027, lbQU},
+	{0x0028, 0x0028, lbOP},
+	{0x0029, 0x0029, lbCP},
+	{0x002B, 0x002B, lbPR},
+	{0x002C, 0x002C, lbIS},
+	{0x002D, 0x002D, lbHY},
+	{0x002E, 0x002E, lbIS},
+	{0x002F, 0x002F, lbSY},
+	{0x0030, 0x0039, lbNU},
+	{0x003A, 0x003B, lbIS},
+	{0x003F, 0x003F, lbEX},
+	{0x005B, 0x005B, lbOP},
+	{0x005C, 0x005C, lbPR},
+	{0x005D, 0x005D, lbCP},
+	{0x007B, 0x007B, lbOP},
+	{0x007C, 0x007C, lbBA},
+	{0x007D, 0x007D, lbCL},
+	{0x007F, 0x0084, lbCM},
+	{0x0085, 0x0085, lbNL},
+	{0x0086, 0x009F, lbCM},
+	{0x00A0, 0x00A0, lbGL},
+	{0x00A1, 0x00A1, lbOP},
+	{0x00A2, 0x00A2, lbPO},
+	{0x00A3, 0x00A5, lbPR},
+	{0x00AB, 0x00AB, lbQU},
+	{0x00AD, 0x00AD, lbBA},
+	{0x00B0, 0x00B0, lbPO},
+	{0x00B1, 0x00B1, lbPR},
+	{0x00B4, 0x00B4, lbBB},
+	{0x00BB, 0x00BB, lbQU},
+	{0x00BF, 0x00BF, lbOP},
+	{0x02C8, 0x02C8, lbBB},
+	{0x02CC, 0x02CC, lbBB},
+	{0x02DF, 0x02DF, lbBB},
+	{0x0300, 0x034E, lbCM},
+	{0x034F, 0x034F, lbGL},
+	{0x0350, 0x035B, lbCM},
+	{0x035C, 0x0362, lbGL},
+	{0x0363, 0x036F, lbCM},
+	{0x037E, 0x037E, lbIS},
+	{0x0483, 0x0489, lbCM},
+	{0x0589, 0x0589, lbIS},
+	{0x058A, 0x058A, lbBA},
+	{0x058F, 0x058F, lbPR},
+	{0x0591, 0x05BD, lbCM},
+	{0x05BE, 0x05BE, lbBA},
+	{0x05BF, 0x05BF, lbCM},
+	{0x05C1, 0x05C2, lbCM},
+	{0x05C4, 0x05C5, lbCM},
+	{0x05C6, 0x05C6, lbEX},
+	{0x05C7, 0x05C7, lbCM},
+	{0x05D0, 0x05EA, lbHL},
+	{0x05EF, 0x05F2, lbHL},
+	{0x0609, 0x060B, lbPO},
+	{0x060C, 0x060D, lbIS},
+	{0x0610, 0x061A, lbCM},
+	{0x061B, 0x061B, lbEX},
+	{0x061C, 0x061C, lbCM},
+	{0x061D, 0x061F, lbEX},
+	{0x064B, 0x065F, lbCM},
+	{0x0660, 0x0669, lbNU},
+	{0x066A, 0x066A, lbPO},
+	{0x066B, 0x066C, lbNU},
+	{0x0670, 0x0670, lbCM},
+	{0x06D4, 0x06D4, lbEX},
+	{0x06D6, 0x06DC, lbCM},
+	{0x06DF, 0x06E4, lbCM},
+	{0x06E7, 0x06E8, lbCM},
+	{0x06EA, 0x06ED, lbCM},
+	{0x06F0, 0x06F9, lbNU},
+	{0x0711, 0x0711, lbCM},
+	{0x0730, 0x074A, lbCM},
+	{0x07A6, 0x07B0, lbCM},
+	{0x07C0, 0x07C9, lbNU},
+	{0x07EB, 0x07F3, lbCM},
+	{0x07F8, 0x07F8, lbIS},
+	{0x07F9, 0x07F9, lbEX},
+	{0x07FD, 0x07FD, lbCM},
+	{0x07FE, 0x07FF, lbPR},
+	{0x0816, 0x0819, lbCM},
+	{0x081B, 0x0823, lbCM},
+	{0x0825, 0x0827, lbCM},
+	{0x0829, 0x082D, lbCM},
+	{0x0859, 0x085B, lbCM},
+	{0x0898, 0x089F, lbCM},
+	{0x08CA, 0x08E1, lbCM},
+	{0x08E3, 0x0903, lbCM},
+	{0x093A, 0x093C, lbCM},
+	{0x093E, 0x094F, lbCM},
+	{0x0951, 0x0957, lbCM},
+	{0x0962, 0x0963, lbCM},
+	{0x0964, 0x0965, lbBA},
+	{0x0966, 0x096F, lbNU},
+	{0x0981, 0x0983, lbCM},
+	{0x09BC, 0x09BC, lbCM},
+	{0x09BE, 0x09C4, lbCM},
+	{0x09C7, 0x09C8, lbCM},
+	{0x09CB, 0x09CD, lbCM},
+	{0x09D7, 0x09D7, lbCM},
+	{0x09E2, 0x09E3, lbCM},
+	{0x09E6, 0x09EF, lbNU},
+	{0x09F2, 0x09F3, lbPO},
+	{0x09F9, 0x09F9, lbPO},
+	{0x09FB, 0x09FB, lbPR},
+	{0x09FE, 0x09FE, lbCM},
+	{0x0A01, 0x0A03, lbCM},
+	{0x0A3C, 0x0A3C, lbCM},
+	{0x0A3E, 0x0A42, lbCM},
+	{0x0A47, 0x0A48, lbCM},
+	{0x0A4B, 0x0A4D, lbCM},
+	{0x0A51, 0x0A51, lbCM},
+	{0x0A66, 0x0A6F, lbNU},
+	{0x0A70, 0x0A71, lbCM},
+	{0x0A75, 0x0A75, lbCM},
+	{0x0A81, 0x0A83, lbCM},
+	{0x0ABC, 0x0ABC, lbCM},
+	{0x0ABE, 0x0AC5, lbCM},
+	{0x0AC7, 0x0AC9, lbCM},
+	{0x0ACB, 0x0ACD, lbCM},
+	{0x0AE2, 0x0AE3, lbCM},
+	{0x0AE6, 0x0AEF, lbNU},
+	{0x0AF1, 0x0AF1, lbPR},
+	{0x0AFA, 0x0AFF, lbCM},
+	{0x0B01, 0x0B03, lbCM},
+	{0x0B3C, 0x0B3C, lbCM},
+	{0x0B3E, 0x0B44, lbCM},
+	{0x0B47, 0x0B48, lbCM},
+	{0x0B4B, 0x0B4D, lbCM},
+	{0x0B55, 0x0B57, lbCM},
+	{0x0B62, 0x0B63, lbCM},
+	{0x0B66, 0x0B6F, lbNU},
+	{0x0B82, 0x0B82, lbCM},
+	{0x0BBE, 0x0BC2, lbCM},
+	{0x0BC6, 0x0BC8, lbCM},
+	{0x0BCA, 0x0BCD, lbCM},
+	{0x0BD7, 0x0BD7, lbCM},
+	{0x0BE6, 0x0BEF, lbNU},
+	{0x0BF9, 0x0BF9, lbPR},
+	{0x0C00, 0x0C04, lbCM},
+	{0x0C3C, 0x0C3C, lbCM},
+	{0x0C3E, 0x0C44, lbCM},
+	{0x0C46, 0x0C48, lbCM},
+	{0x0C4A, 0x0C4D, lbCM},
+	{0x0C55, 0x0C56, lbCM},
+	{0x0C62, 0x0C63, lbCM},
+	{0x0C66, 0x0C6F, lbNU},
+	{0x0C77, 0x0C77, lbBB},
+	{0x0C81, 0x0C83, lbCM},
+	{0x0C84, 0x0C84, lbBB},
+	{0x0CBC, 0x0CBC, lbCM},
+	{0x0CBE, 0x0CC4, lbCM},
+	{0x0CC6, 0x0CC8, lbCM},
+	{0x0CCA, 0x0CCD, lbCM},
+	{0x0CD5, 0x0CD6, lbCM},
+	{0x0CE2, 0x0CE3, lbCM},
+	{0x0CE6, 0x0CEF, lbNU},
+	{0x0CF3, 0x0CF3, lbCM},
+	{0x0D00, 0x0D03, lbCM},
+	{0x0D3B, 0x0D3C, lbCM},
+	{0x0D3E, 0x0D44, lbCM},
+	{0x0D46, 0x0D48, lbCM},
+	{0x0D4A, 0x0D4D, lbCM},
+	{0x0D57, 0x0D57, lbCM},
+	{0x0D62, 0x0D63, lbCM},
+	{0x0D66, 0x0D6F, lbNU},
+	{0x0D79, 0x0D79, lbPO},
+	{0x0D81, 0x0D83, lbCM},
+	{0x0DCA, 0x0DCA, lbCM},
+	{0x0DCF, 0x0DD4, lbCM},
+	{0x0DD6, 0x0DD6, lbCM},
+	{0x0DD8, 0x0DDF, lbCM},
+	{0x0DE6, 0x0DEF, lbNU},
+	{0x0DF2, 0x0DF3, lbCM},
+	{0x0E31, 0x0E31, lbCM},
+	{0x0E34, 0x0E3A, lbCM},
+	{0x0E3F, 0x0E3F, lbPR},
+	{0x0E47, 0x0E4E, lbCM},
+	{0x0E50, 0x0E59, lbNU},
+	{0x0E5A, 0x0E5B, lbBA},
+	{0x0EB1, 0x0EB1, lbCM},
+	{0x0EB4, 0x0EBC, lbCM},
+	{0x0EC8, 0x0ECE, lbCM},
+	{0x0ED0, 0x0ED9, lbNU},
+	{0x0F01, 0x0F04, lbBB},
+	{0x0F06, 0x0F07, lbBB},
+	{0x0F08, 0x0F08, lbGL},
+	{0x0F09, 0x0F0A, lbBB},
+	{0x0F0B, 0x0F0B, lbBA},
+	{0x0F0C, 0x0F0C, lbGL},
+	{0x0F0D, 0x0F11, lbEX},
+	{0x0F12, 0x0F12, lbGL},
+	{0x0F14, 0x0F14, lbEX},
+	{0x0F18, 0x0F19, lbCM},
+	{0x0F20, 0x0F29, lbNU},
+	{0x0F34, 0x0F34, lbBA},
+	{0x0F35, 0x0F35, lbCM},
+	{0x0F37, 0x0F37, lbCM},
+	{0x0F39, 0x0F39, lbCM},
+	{0x0F3A, 0x0F3A, lbOP},
+	{0x0F3B, 0x0F3B, lbCL},
+	{0x0F3C, 0x0F3C, lbOP},
+	{0x0F3D, 0x0F3D, lbCL},
+	{0x0F3E, 0x0F3F, lbCM},
+	{0x0F71, 0x0F7E, lbCM},
+	{0x0F7F, 0x0F7F, lbBA},
+	{0x0F80, 0x0F84, lbCM},
+	{0x0F85, 0x0F85, lbBA},
+	{0x0F86, 0x0F87, lbCM},
+	{0x0F8D, 0x0F97, lbCM},
+	{0x0F99, 0x0FBC, lbCM},
+	{0x0FBE, 0x0FBF, lbBA},
+	{0x0FC6, 0x0FC6, lbCM},
+	{0x0FD0, 0x0FD1, lbBB},
+	{0x0FD2, 0x0FD2, lbBA},
+	{0x0FD3, 0x0FD3, lbBB},
+	{0x0FD9, 0x0FDA, lbGL},
+	{0x102B, 0x103E, lbCM},
+	{0x1040, 0x1049, lbNU},
+	{0x104A, 0x104B, lbBA},
+	{0x1056, 0x1059, lbCM},
+	{0x105E, 0x1060, lbCM},
+	{0x1062, 0x1064, lbCM},
+	{0x1067, 0x106D, lbCM},
+	{0x1071, 0x1074, lbCM},
+	{0x1082, 0x108D, lbCM},
+	{0x108F, 0x108F, lbCM},
+	{0x1090, 0x1099, lbNU},
+	{0x109A, 0x109D, lbCM},
+	{0x1100, 0x115F, lbJL},
+	{0x1160, 0x11A7, lbJV},
+	{0x11A8, 0x11FF, lbJT},
+	{0x135D, 0x135F, lbCM},
+	{0x1361, 0x1361, lbBA},
+	{0x1400, 0x1400, lbBA},
+	{0x1680, 0x1680, lbBA},
+	{0x169B, 0x169B, lbOP},
+	{0x169C, 0x169C, lbCL},
+	{0x16EB, 0x16ED, lbBA},
+	{0x1712, 0x1715, lbCM},
+	{0x1732, 0x1734, lbCM},
+	{0x1735, 0x1736, lbBA},
+	{0x1752, 0x1753, lbCM},
+	{0x1772, 0x1773, lbCM},
+	{0x17B4, 0x17D3, lbCM},
+	{0x17D4, 0x17D5, lbBA},
+	{0x17D6, 0x17D6, lbNS},
+	{0x17D8, 0x17D8, lbBA},
+	{0x17DA, 0x17DA, lbBA},
+	{0x17DB, 0x17DB, lbPR},
+	{0x17DD, 0x17DD, lbCM},
+	{0x17E0, 0x17E9, lbNU},
+	{0x1802, 0x1803, lbEX},
+	{0x1804, 0x1805, lbBA},
+	{0x1806, 0x1806, lbBB},
+	{0x1808, 0x1809, lbEX},
+	{0x180B, 0x180D, lbCM},
+	{0x180E, 0x180E, lbGL},
+	{0x180F, 0x180F, lbCM},
+	{0x1810, 0x1819, lbNU},
+	{0x1885, 0x1886, lbCM},
+	{0x18A9, 0x18A9, lbCM},
+	{0x1920, 0x192B, lbCM},
+	{0x1930, 0x193B, lbCM},
+	{0x1944, 0x1945, lbEX},
+	{0x1946, 0x194F, lbNU},
+	{0x19D0, 0x19D9, lbNU},
+	{0x1A17, 0x1A1B, lbCM},
+	{0x1A55, 0x1A5E, lbCM},
+	{0x1A60, 0x1A7C, lbCM},
+	{0x1A7F, 0x1A7F, lbCM},
+	{0x1A80, 0x1A89, lbNU},
+	{0x1A90, 0x1A99, lbNU},
+	{0x1AB0, 0x1ACE, lbCM},
+	{0x1B00, 0x1B04, lbCM},
+	{0x1B34, 0x1B44, lbCM},
+	{0x1B50, 0x1B59, lbNU},
+	{0x1B5A, 0x1B5B, lbBA},
+	{0x1B5D, 0x1B60, lbBA},
+	{0x1B6B, 0x1B73, lbCM},
+	{0x1B7D, 0x1B7E, lbBA},
+	{0x1B80, 0x1B82, lbCM},
+	{0x1BA1, 0x1BAD, lbCM},
+	{0x1BB0, 0x1BB9, lbNU},
+	{0x1BE6, 0x1BF3, lbCM},
+	{0x1C24, 0x1C37, lbCM},
+	{0x1C3B, 0x1C3F, lbBA},
+	{0x1C40, 0x1C49, lbNU},
+	{0x1C50, 0x1C59, lbNU},
+	{0x1C7E, 0x1C7F, lbBA},
+	{0x1CD0, 0x1CD2, lbCM},
+	{0x1CD4, 0x1CE8, lbCM},
+	{0x1CED, 0x1CED, lbCM},
+	{0x1CF4, 0x1CF4, lbCM},
+	{0x1CF7, 0x1CF9, lbCM},
+	{0x1DC0, 0x1DCC, lbCM},
+	{0x1DCD, 0x1DCD, lbGL},
+	{0x1DCE, 0x1DFB, lbCM},
+	{0x1DFC, 0x1DFC, lbGL},
+	{0x1DFD, 0x1DFF, lbCM},
+	{0x1FFD, 0x1FFD, lbBB},
+	{0x2000, 0x2006, lbBA},
+	{0x2007, 0x2007, lbGL},
+	{0x2008, 0x200A, lbBA},
+	{0x200B, 0x200B, lbZW},
+	{0x200C, 0x200C, lbCM},
+	{0x200D, 0x200D, lbZWJ},
+	{0x200E, 0x200F, lbCM},
+	{0x2010, 0x2010, lbBA},
+	{0x2011, 0x2011, lbGL},
+	{0x2012, 0x2013, lbBA},
+	{0x2014, 0x2014, lbB2},
+	{0x2018, 0x2019, lbQU},
+	{0x201A, 0x201A, lbOP},
+	{0x201B, 0x201D, lbQU},
+	{0x201E, 0x201E, lbOP},
+	{0x201F, 0x201F, lbQU},
+	{0x2024, 0x2026, lbIN},
+	{0x2027, 0x2027, lbBA},
+	{0x2028, 0x2029, lbBK},
+	{0x202A, 0x202E, lbCM},
+	{0x202F, 0x202F, lbGL},
+	{0x2030, 0x2037, lbPO},
+	{0x2039, 0x203A, lbQU},
+	{0x203C, 0x203D, lbNS},
+	{0x2044, 0x2044, lbIS},
+	{0x2045, 0x2045, lbOP},
+	{0x2046, 0x2046, lbCL},
+	{0x2047, 0x2049, lbNS},
+	{0x2056, 0x2056, lbBA},
+	{0x2057, 0x2057, lbPO},
+	{0x2058, 0x205B, lbBA},
+	{0x205D, 0x205F, lbBA},
+	{0x2060, 0x2060, lbWJ},
+	{0x2066, 0x206F, lbCM},
+	{0x207D, 0x207D, lbOP},
+	{0x207E, 0x207E, lbCL},
+	{0x208D, 0x208D, lbOP},
+	{0x208E, 0x208E, lbCL},
+	{0x20A0, 0x20A6, lbPR},
+	{0x20A7, 0x20A7, lbPO},
+	{0x20A8, 0x20B5, lbPR},
+	{0x20B6, 0x20B6, lbPO},
+	{0x20B7, 0x20BA, lbPR},
+	{0x20BB, 0x20BB, lbPO},
+	{0x20BC, 0x20BD, lbPR},
+	{0x20BE, 0x20BE, lbPO},
+	{0x20BF, 0x20BF, lbPR},
+	{0x20C0, 0x20C0, lbPO},
+	{0x20C1, 0x20CF, lbPR},
+	{0x20D0, 0x20F0, lbCM},
+	{0x2103, 0x2103, lbPO},
+	{0x2109, 0x2109, lbPO},
+	{0x2116, 0x2116, lbPR},
+	{0x2212, 0x2213, lbPR},
+	{0x22EF, 0x22EF, lbIN},
+	{0x2308, 0x2308, lbOP},
+	{0x2309, 0x2309, lbCL},
+	{0x230A, 0x230A, lbOP},
+	{0x230B, 0x230B, lbCL},
+	{0x231A, 0x231B, lbID},
+	{0x2329, 0x2329, lbOPEA},
+	{0x232A, 0x232A, lbCL},
+	{0x23F0, 0x23F3, lbID},
+	{0x2600, 0x2603, lbID},
+	{0x2614, 0x2615, lbID},
+	{0x2618, 0x2618, lbID},
+	{0x261A, 0x261C, lbID},
+	{0x261D, 0x261D, lbEB},
+	{0x261E, 0x261F, lbID},
+	{0x2639, 0x263B, lbID},
+	{0x2668, 0x2668, lbID},
+	{0x267F, 0x267F, lbID},
+	{0x26BD, 0x26C8, lbID},
+	{0x26CD, 0x26CD, lbID},
+	{0x26CF, 0x26D1, lbID},
+	{0x26D3, 0x26D4, lbID},
+	{0x26D8, 0x26D9, lbID},
+	{0x26DC, 0x26DC, lbID},
+	{0x26DF, 0x26E1, lbID},
+	{0x26EA, 0x26EA, lbID},
+	{0x26F1, 0x26F5, lbID},
+	{0x26F7, 0x26F8, lbID},
+	{0x26F9, 0x26F9, lbEB},
+	{0x26FA, 0x26FA, lbID},
+	{0x26FD, 0x2704, lbID},
+	{0x2708, 0x2709, lbID},
+	{0x270A, 0x270D, lbEB},
+	{0x275B, 0x2760, lbQU},
+	{0x2762, 0x2763, lbEX},
+	{0x2764, 0x2764, lbID},
+	{0x2768, 0x2768, lbOP},
+	{0x2769, 0x2769, lbCL},
+	{0x276A, 0x276A, lbOP},
+	{0x276B, 0x276B, lbCL},
+	{0x276C, 0x276C, lbOP},
+	{0x276D, 0x276D, lbCL},
+	{0x276E, 0x276E, lbOP},
+	{0x276F, 0x276F, lbCL},
+	{0x2770, 0x2770, lbOP},
+	{0x2771, 0x2771, lbCL},
+	{0x2772, 0x2772, lbOP},
+	{0x2773, 0x2773, lbCL},
+	{0x2774, 0x2774, lbOP},
+	{0x2775, 0x2775, lbCL},
+	{0x27C5, 0x27C5, lbOP},
+	{0x27C6, 0x27C6, lbCL},
+	{0x27E6, 0x27E6, lbOP},
+	{0x27E7, 0x27E7, lbCL},
+	{0x27E8, 0x27E8, lbOP},
+	{0x27E9, 0x27E9, lbCL},
+	{0x27EA, 0x27EA, lbOP},
+	{0x27EB, 0x27EB, lbCL},
+	{0x27EC, 0x27EC, lbOP},
+	{0x27ED, 0x27ED, lbCL},
+	{0x27EE, 0x27EE, lbOP},
+	{0x27EF, 0x27EF, lbCL},
+	{0x2983, 0x2983, lbOP},
+	{0x2984, 0x2984, lbCL},
+	{0x2985, 0x2985, lbOP},
+	{0x2986, 0x2986, lbCL},
+	{0x2987, 0x2987, lbOP},
+	{0x2988, 0x2988, lbCL},
+	{0x2989, 0x2989, lbOP},
+	{0x298A, 0x298A, lbCL},
+	{0x298B, 0x298B, lbOP},
+	{0x298C, 0x298C, lbCL},
+	{0x298D, 0x298D, lbOP},
+	{0x298E, 0x298E, lbCL},
+	{0x298F, 0x298F, lbOP},
+	{0x2990, 0x2990, lbCL},
+	{0x2991, 0x2991, lbOP},
+	{0x2992, 0x2992, lbCL},
+	{0x2993, 0x2993, lbOP},
+	{0x2994, 0x2994, lbCL},
+	{0x2995, 0x2995, lbOP},
+	{0x2996, 0x2996, lbCL},
+	{0x2997, 0x2997, lbOP},
+	{0x2998, 0x2998, lbCL},
+	{0x29D8, 0x29D8, lbOP},
+	{0x29D9, 0x29D9, lbCL},
+	{0x29DA, 0x29DA, lbOP},
+	{0x29DB, 0x29DB, lbCL},
+	{0x29FC, 0x29FC, lbOP},
+	{0x29FD, 0x29FD, lbCL},
+	{0x2CEF, 0x2CF1, lbCM},
+	{0x2CF9, 0x2CF9, lbEX},
+	{0x2CFA, 0x2CFC, lbBA},
+	{0x2CFE, 0x2CFE, lbEX},
+	{0x2CFF, 0x2CFF, lbBA},
+	{0x2D70, 0x2D70, lbBA},
+	{0x2D7F, 0x2D7F, lbCM},
+	{0x2DE0, 0x2DFF, lbCM},
+	{0x2E00, 0x2E0D, lbQU},
+	{0x2E0E, 0x2E15, lbBA},
+	{0x2E17, 0x2E17, lbBA},
+	{0x2E18, 0x2E18, lbOP},
+	{0x2E19, 0x2E19, lbBA},
+	{0x2E1C, 0x2E1D, lbQU},
+	{0x2E20, 0x2E21, lbQU},
+	{0x2E22, 0x2E22, lbOP},
+	{0x2E23, 0x2E23, lbCL},
+	{0x2E24, 0x2E24, lbOP},
+	{0x2E25, 0x2E25, lbCL},
+	{0x2E26, 0x2E26, lbOP},
+	{0x2E27, 0x2E27, lbCL},
+	{0x2E28, 0x2E28, lbOP},
+	{0x2E29, 0x2E29, lbCL},
+	{0x2E2A, 0x2E2D, lbBA},
+	{0x2E2E, 0x2E2E, lbEX},
+	{0x2E30, 0x2E31, lbBA},
+	{0x2E33, 0x2E34, lbBA},
+	{0x2E3A, 0x2E3B, lbB2},
+	{0x2E3C, 0x2E3E, lbBA},
+	{0x2E40, 0x2E41, lbBA},
+	{0x2E42, 0x2E42, lbOP},
+	{0x2E43, 0x2E4A, lbBA},
+	{0x2E4C, 0x2E4C, lbBA},
+	{0x2E4E, 0x2E4F, lbBA},
+	{0x2E53, 0x2E54, lbEX},
+	{0x2E55, 0x2E55, lbOP},
+	{0x2E56, 0x2E56, lbCL},
+	{0x2E57, 0x2E57, lbOP},
+	{0x2E58, 0x2E58, lbCL},
+	{0x2E59, 0x2E59, lbOP},
+	{0x2E5A, 0x2E5A, lbCL},
+	{0x2E5B, 0x2E5B, lbOP},
+	{0x2E5C, 0x2E5C, lbCL},
+	{0x2E5D, 0x2E5D, lbBA},
+	{0x2E80, 0x2E99, lbID},
+	{0x2E9B, 0x2EF3, lbID},
+	{0x2F00, 0x2FD5, lbID},
+	{0x2FF0, 0x2FFB, lbID},
+	{0x3000, 0x3000, lbBA},
+	{0x3001, 0x3002, lbCL},
+	{0x3003, 0x3004, lbID},
+	{0x3005, 0x3005, lbNS},
+	{0x3006, 0x3007, lbID},
+	{0x3008, 0x3008, lbOPEA},
+	{0x3009, 0x3009, lbCL},
+	{0x300A, 0x300A, lbOPEA},
+	{0x300B, 0x300B, lbCL},
+	{0x300C, 0x300C, lbOPEA},
+	{0x300D, 0x300D, lbCL},
+	{0x300E, 0x300E, lbOPEA},
+	{0x300F, 0x300F, lbCL},
+	{0x3010, 0x3010, lbOPEA},
+	{0x3011, 0x3011, lbCL},
+	{0x3012, 0x3013, lbID},
+	{0x3014, 0x3014, lbOPEA},
+	{0x3015, 0x3015, lbCL},
+	{0x3016, 0x3016, lbOPEA},
+	{0x3017, 0x3017, lbCL},
+	{0x3018, 0x3018, lbOPEA},
+	{0x3019, 0x3019, lbCL},
+	{0x301A, 0x301A, lbOPEA},
+	{0x301B, 0x301B, lbCL},
+	{0x301C, 0x301C, lbNS},
+	{0x301D, 0x301D, lbOPEA},
+	{0x301E, 0x301F, lbCL},
+	{0x3020, 0x3029, lbID},
+	{0x302A, 0x302F, lbCM},
+	{0x3030, 0x3034, lbID},
+	{0x3035, 0x3035, lbCM},
+	{0x3036, 0x303A, lbID},
+	{0x303B, 0x303C, lbNS},
+	{0x303D, 0x303F, lbID},
+	{0x3041, 0x3041, lbNS},
+	{0x3042, 0x3042, lbID},
+	{0x3043, 0x3043, lbNS},
+	{0x3044, 0x3044, lbID},
+	{0x3045, 0x3045, lbNS},
+	{0x3046, 0x3046, lbID},
+	{0x3047, 0x3047, lbNS},
+	{0x3048, 0x3048, lbID},
+	{0x3049, 0x3049, lbNS},
+	{0x304A, 0x3062, lbID},
+	{0x3063, 0x3063, lbNS},
+	{0x3064, 0x3082, lbID},
+	{0x3083, 0x3083, lbNS},
+	{0x3084, 0x3084, lbID},
+	{0x3085, 0x3085, lbNS},
+	{0x3086, 0x3086, lbID},
+	{0x3087, 0x3087, lbNS},
+	{0x3088, 0x308D, lbID},
+	{0x308E, 0x308E, lbNS},
+	{0x308F, 0x3094, lbID},
+	{0x3095, 0x3096, lbNS},
+	{0x3099, 0x309A, lbCM},
+	{0x309B, 0x309E, lbNS},
+	{0x309F, 0x309F, lbID},
+	{0x30A0, 0x30A1, lbNS},
+	{0x30A2, 0x30A2, lbID},
+	{0x30A3, 0x30A3, lbNS},
+	{0x30A4, 0x30A4, lbID},
+	{0x30A5, 0x30A5, lbNS},
+	{0x30A6, 0x30A6, lbID},
+	{0x30A7, 0x30A7, lbNS},
+	{0x30A8, 0x30A8, lbID},
+	{0x30A9, 0x30A9, lbNS},
+	{0x30AA, 0x30C2, lbID},
+	{0x30C3, 0x30C3, lbNS},
+	{0x30C4, 0x30E2, lbID},
+	{0x30E3, 0x30E3, lbNS},
+	{0x30E4, 0x30E4, lbID},
+	{0x30E5, 0x30E5, lbNS},
+	{0x30E6, 0x30E6, lbID},
+	{0x30E7, 0x30E7, lbNS},
+	{0x30E8, 0x30ED, lbID},
+	{0x30EE, 0x30EE, lbNS},
+	{0x30EF, 0x30F4, lbID},
+	{0x30F5, 0x30F6, lbNS},
+	{0x30F7, 0x30FA, lbID},
+	{0x30FB, 0x30FE, lbNS},
+	{0x30FF, 0x30FF, lbID},
+	{0x3105, 0x312F, lbID},
+	{0x3131, 0x318E, lbID},
+	{0x3190, 0x31E3, lbID},
+	{0x31F0, 0x31FF, lbNS},
+	{0x3200, 0x321E, lbID},
+	{0x3220, 0x3247, lbID},
+	{0x3250, 0x4DBF, lbID},
+	{0x4E00, 0xA014, lbID},
+	{0xA015, 0xA015, lbNS},
+	{0xA016, 0xA48C, lbID},
+	{0xA490, 0xA4C6, lbID},
+	{0xA4FE, 0xA4FF, lbBA},
+	{0xA60D, 0xA60D, lbBA},
+	{0xA60E, 0xA60E, lbEX},
+	{0xA60F, 0xA60F, lbBA},
+	{0xA620, 0xA629, lbNU},
+	{0xA66F, 0xA672, lbCM},
+	{0xA674, 0xA67D, lbCM},
+	{0xA69E, 0xA69F, lbCM},
+	{0xA6F0, 0xA6F1, lbCM},
+	{0xA6F3, 0xA6F7, lbBA},
+	{0xA802, 0xA802, lbCM},
+	{0xA806, 0xA806, lbCM},
+	{0xA80B, 0xA80B, lbCM},
+	{0xA823, 0xA827, lbCM},
+	{0xA82C, 0xA82C, lbCM},
+	{0xA838, 0xA838, lbPO},
+	{0xA874, 0xA875, lbBB},
+	{0xA876, 0xA877, lbEX},
+	{0xA880, 0xA881, lbCM},
+	{0xA8B4, 0xA8C5, lbCM},
+	{0xA8CE, 0xA8CF, lbBA},
+	{0xA8D0, 0xA8D9, lbNU},
+	{0xA8E0, 0xA8F1, lbCM},
+	{0xA8FC, 0xA8FC, lbBB},
+	{0xA8FF, 0xA8FF, lbCM},
+	{0xA900, 0xA909, lbNU},
+	{0xA926, 0xA92D, lbCM},
+	{0xA92E, 0xA92F, lbBA},
+	{0xA947, 0xA953, lbCM},
+	{0xA960, 0xA97C, lbJL},
+	{0xA980, 0xA983, lbCM},
+	{0xA9B3, 0xA9C0, lbCM},
+	{0xA9C7, 0xA9C9, lbBA},
+	{0xA9D0, 0xA9D9, lbNU},
+	{0xA9E5, 0xA9E5, lbCM},
+	{0xA9F0, 0xA9F9, lbNU},
+	{0xAA29, 0xAA36, lbCM},
+	{0xAA43, 0xAA43, lbCM},
+	{0xAA4C, 0xAA4D, lbCM},
+	{0xAA50, 0xAA59, lbNU},
+	{0xAA5D, 0xAA5F, lbBA},
+	{0xAA7B, 0xAA7D, lbCM},
+	{0xAAB0, 0xAAB0, lbCM},
+	{0xAAB2, 0xAAB4, lbCM},
+	{0xAAB7, 0xAAB8, lbCM},
+	{0xAABE, 0xAABF, lbCM},
+	{0xAAC1, 0xAAC1, lbCM},
+	{0xAAEB, 0xAAEF, lbCM},
+	{0xAAF0, 0xAAF1, lbBA},
+	{0xAAF5, 0xAAF6, lbCM},
+	{0xABE3, 0xABEA, lbCM},
+	{0xABEB, 0xABEB, lbBA},
+	{0xABEC, 0xABED, lbCM},
+	{0xABF0, 0xABF9, lbNU},
+	{0xAC00, 0xAC00, lbH2},
+	{0xAC01, 0xAC1B, lbH3},
+	{0xAC1C, 0xAC1C, lbH2},
+	{0xAC1D, 0xAC37, lbH3},
+	{0xAC38, 0xAC38, lbH2},
+	{0xAC39, 0xAC53, lbH3},
+	{0xAC54, 0xAC54, lbH2},
+	{0xAC55, 0xAC6F, lbH3},
+	{0xAC70, 0xAC70, lbH2},
+	{0xAC71, 0xAC8B, lbH3},
+	{0xAC8C, 0xAC8C, lbH2},
+	{0xAC8D, 0xACA7, lbH3},
+	{0xACA8, 0xACA8, lbH2},
+	{0xACA9, 0xACC3, lbH3},
+	{0xACC4, 0xACC4, lbH2},
+	{0xACC5, 0xACDF, lbH3},
+	{0xACE0, 0xACE0, lbH2},
+	{0xACE1, 0xACFB, lbH3},
+	{0xACFC, 0xACFC, lbH2},
+	{0xACFD, 0xAD17, lbH3},
+	{0xAD18, 0xAD18, lbH2},
+	{0xAD19, 0xAD33, lbH3},
+	{0xAD34, 0xAD34, lbH2},
+	{0xAD35, 0xAD4F, lbH3},
+	{0xAD50, 0xAD50, lbH2},
+	{0xAD51, 0xAD6B, lbH3},
+	{0xAD6C, 0xAD6C, lbH2},
+	{0xAD6D, 0xAD87, lbH3},
+	{0xAD88, 0xAD88, lbH2},
+	{0xAD89, 0xADA3, lbH3},
+	{0xADA4, 0xADA4, lbH2},
+	{0xADA5, 0xADBF, lbH3},
+	{0xADC0, 0xADC0, lbH2},
+	{0xADC1, 0xADDB, lbH3},
+	{0xADDC, 0xADDC, lbH2},
+	{0xADDD, 0xADF7, lbH3},
+	{0xADF8, 0xADF8, lbH2},
+	{0xADF9, 0xAE13, lbH3},
+	{0xAE14, 0xAE14, lbH2},
+	{0xAE15, 0xAE2F, lbH3},
+	{0xAE30, 0xAE30, lbH2},
+	{0xAE31, 0xAE4B, lbH3},
+	{0xAE4C, 0xAE4C, lbH2},
+	{0xAE4D, 0xAE67, lbH3},
+	{0xAE68, 0xAE68, lbH2},
+	{0xAE69, 0xAE83, lbH3},
+	{0xAE84, 0xAE84, lbH2},
+	{0xAE85, 0xAE9F, lbH3},
+	{0xAEA0, 0xAEA0, lbH2},
+	{0xAEA1, 0xAEBB, lbH3},
+	{0xAEBC, 0xAEBC, lbH2},
+	{0xAEBD, 0xAED7, lbH3},
+	{0xAED8, 0xAED8, lbH2},
+	{0xAED9, 0xAEF3, lbH3},
+	{0xAEF4, 0xAEF4, lbH2},
+	{0xAEF5, 0xAF0F, lbH3},
+	{0xAF10, 0xAF10, lbH2},
+	{0xAF11, 0xAF2B, lbH3},
+	{0xAF2C, 0xAF2C, lbH2},
+	{0xAF2D, 0xAF47, lbH3},
+	{0xAF48, 0xAF48, lbH2},
+	{0xAF49, 0xAF63, lbH3},
+	{0xAF64, 0xAF64, lbH2},
+	{0xAF65, 0xAF7F, lbH3},
+	{0xAF80, 0xAF80, lbH2},
+	{0xAF81, 0xAF9B, lbH3},
+	{0xAF9C, 0xAF9C, lbH2},
+	{0xAF9D, 0xAFB7, lbH3},
+	{0xAFB8, 0xAFB8, lbH2},
+	{0xAFB9, 0xAFD3, lbH3},
+	{0xAFD4, 0xAFD4, lbH2},
+	{0xAFD5, 0xAFEF, lbH3},
+	{0xAFF0, 0xAFF0, lbH2},
+	{0xAFF1, 0xB00B, lbH3},
+	{0xB00C, 0xB00C, lbH2},
+	{0xB00D, 0xB027, lbH3},
+	{0xB028, 0xB028, lbH2},
+	{0xB029, 0xB043, lbH3},
+	{0xB044, 0xB044, lbH2},
+	{0xB045, 0xB05F, lbH3},
+	{0xB060, 0xB060, lbH2},
+	{0xB061, 0xB07B, lbH3},
+	{0xB07C, 0xB07C, lbH2},
+	{0xB07D, 0xB097, lbH3},
+	{0xB098, 0xB098, lbH2},
+	{0xB099, 0xB0B3, lbH3},
+	{0xB0B4, 0xB0B4, lbH2},
+	{0xB0B5, 0xB0CF, lbH3},
+	{0xB0D0, 0xB0D0, lbH2},
+	{0xB0D1, 0xB0EB, lbH3},
+	{0xB0EC, 0xB0EC, lbH2},
+	{0xB0ED, 0xB107, lbH3},
+	{0xB108, 0xB108, lbH2},
+	{0xB109, 0xB123, lbH3},
+	{0xB124, 0xB124, lbH2},
+	{0xB125, 0xB13F, lbH3},
+	{0xB140, 0xB140, lbH2},
+	{0xB141, 0xB15B, lbH3},
+	{0xB15C, 0xB15C, lbH2},
+	{0xB15D, 0xB177, lbH3},
+	{0xB178, 0xB178, lbH2},
+	{0xB179, 0xB193, lbH3},
+	{0xB194, 0xB194, lbH2},
+	{0xB195, 0xB1AF, lbH3},
+	{0xB1B0, 0xB1B0, lbH2},
+	{0xB1B1, 0xB1CB, lbH3},
+	{0xB1CC, 0xB1CC, lbH2},
+	{0xB1CD, 0xB1E7, lbH3},
+	{0xB1E8, 0xB1E8, lbH2},
+	{0xB1E9, 0xB203, lbH3},
+	{0xB204, 0xB204, lbH2},
+	{0xB205, 0xB21F, lbH3},
+	{0xB220, 0xB220, lbH2},
+	{0xB221, 0xB23B, lbH3},
+	{0xB23C, 0xB23C, lbH2},
+	{0xB23D, 0xB257, lbH3},
+	{0xB258, 0xB258, lbH2},
+	{0xB259, 0xB273, lbH3},
+	{0xB274, 0xB274, lbH2},
+	{0xB275, 0xB28F, lbH3},
+	{0xB290, 0xB290, lbH2},
+	{0xB291, 0xB2AB, lbH3},
+	{0xB2AC, 0xB2AC, lbH2},
+	{0xB2AD, 0xB2C7, lbH3},
+	{0xB2C8, 0xB2C8, lbH2},
+	{0xB2C9, 0xB2E3, lbH3},
+	{0xB2E4, 0xB2E4, lbH2},
+	{0xB2E5, 0xB2FF, lbH3},
+	{0xB300, 0xB300, lbH2},
+	{0xB301, 0xB31B, lbH3},
+	{0xB31C, 0xB31C, lbH2},
+	{0xB31D, 0xB337, lbH3},
+	{0xB338, 0xB338, lbH2},
+	{0xB339, 0xB353, lbH3},
+	{0xB354, 0xB354, lbH2},
+	{0xB355, 0xB36F, lbH3},
+	{0xB370, 0xB370, lbH2},
+	{0xB371, 0xB38B, lbH3},
+	{0xB38C, 0xB38C, lbH2},
+	{0xB38D, 0xB3A7, lbH3},
+	{0xB3A8, 0xB3A8, lbH2},
+	{0xB3A9, 0xB3C3, lbH3},
+	{0xB3C4, 0xB3C4, lbH2},
+	{0xB3C5, 0xB3DF, lbH3},
+	{0xB3E0, 0xB3E0, lbH2},
+	{0xB3E1, 0xB3FB, lbH3},
+	{0xB3FC, 0xB3FC, lbH2},
+	{0xB3FD, 0xB417, lbH3},
+	{0xB418, 0xB418, lbH2},
+	{0xB419, 0xB433, lbH3},
+	{0xB434, 0xB434, lbH2},
+	{0xB435, 0xB44F, lbH3},
+	{0xB450, 0xB450, lbH2},
+	{0xB451, 0xB46B, lbH3},
+	{0xB46C, 0xB46C, lbH2},
+	{0xB46D, 0xB487, lbH3},
+	{0xB488, 0xB488, lbH2},
+	{0xB489, 0xB4A3, lbH3},
+	{0xB4A4, 0xB4A4, lbH2},
+	{0xB4A5, 0xB4BF, lbH3},
+	{0xB4C0, 0xB4C0, lbH2},
+	{0xB4C1, 0xB4DB, lbH3},
+	{0xB4DC, 0xB4DC, lbH2},
+	{0xB4DD, 0xB4F7, lbH3},
+	{0xB4F8, 0xB4F8, lbH2},
+	{0xB4F9, 0xB513, lbH3},
+	{0xB514, 0xB514, lbH2},
+	{0xB515, 0xB52F, lbH3},
+	{0xB530, 0xB530, lbH2},
+	{0xB531, 0xB54B, lbH3},
+	{0xB54C, 0xB54C, lbH2},
+	{0xB54D, 0xB567, lbH3},
+	{0xB568, 0xB568, lbH2},
+	{0xB569, 0xB583, lbH3},
+	{0xB584, 0xB584, lbH2},
+	{0xB585, 0xB59F, lbH3},
+	{0xB5A0, 0xB5A0, lbH2},
+	{0xB5A1, 0xB5BB, lbH3},
+	{0xB5BC, 0xB5BC, lbH2},
+	{0xB5BD, 0xB5D7, lbH3},
+	{0xB5D8, 0xB5D8, lbH2},
+	{0xB5D9, 0xB5F3, lbH3},
+	{0xB5F4, 0xB5F4, lbH2},
+	{0xB5F5, 0xB60F, lbH3},
+	{0xB610, 0xB610, lbH2},
+	{0xB611, 0xB62B, lbH3},
+	{0xB62C, 0xB62C, lbH2},
+	{0xB62D, 0xB647, lbH3},
+	{0xB648, 0xB648, lbH2},
+	{0xB649, 0xB663, lbH3},
+	{0xB664, 0xB664, lbH2},
+	{0xB665, 0xB67F, lbH3},
+	{0xB680, 0xB680, lbH2},
+	{0xB681, 0xB69B, lbH3},
+	{0xB69C, 0xB69C, lbH2},
+	{0xB69D, 0xB6B7, lbH3},
+	{0xB6B8, 0xB6B8, lbH2},
+	{0xB6B9, 0xB6D3, lbH3},
+	{0xB6D4, 0xB6D4, lbH2},
+	{0xB6D5, 0xB6EF, lbH3},
+	{0xB6F0, 0xB6F0, lbH2},
+	{0xB6F1, 0xB70B, lbH3},
+	{0xB70C, 0xB70C, lbH2},
+	{0xB70D, 0xB727, lbH3},
+	{0xB728, 0xB728, lbH2},
+	{0xB729, 0xB743, lbH3},
+	{0xB744, 0xB744, lbH2},
+	{0xB745, 0xB75F, lbH3},
+	{0xB760, 0xB760, lbH2},
+	{0xB761, 0xB77B, lbH3},
+	{0xB77C, 0xB77C, lbH2},
+	{0xB77D, 0xB797, lbH3},
+	{0xB798, 0xB798, lbH2},
+	{0xB799, 0xB7B3, lbH3},
+	{0xB7B4, 0xB7B4, lbH2},
+	{0xB7B5, 0xB7CF, lbH3},
+	{0xB7D0, 0xB7D0, lbH2},
+	{0xB7D1, 0xB7EB, lbH3},
+	{0xB7EC, 0xB7EC, lbH2},
+	{0xB7ED, 0xB807, lbH3},
+	{0xB808, 0xB808, lbH2},
+	{0xB809, 0xB823, lbH3},
+	{0xB824, 0xB824, lbH2},
+	{0xB825, 0xB83F, lbH3},
+	{0xB840, 0xB840, lbH2},
+	{0xB841, 0xB85B, lbH3},
+	{0xB85C, 0xB85C, lbH2},
+	{0xB85D, 0xB877, lbH3},
+	{0xB878, 0xB878, lbH2},
+	{0xB879, 0xB893, lbH3},
+	{0xB894, 0xB894, lbH2},
+	{0xB895, 0xB8AF, lbH3},
+	{0xB8B0, 0xB8B0, lbH2},
+	{0xB8B1, 0xB8CB, lbH3},
+	{0xB8CC, 0xB8CC, lbH2},
+	{0xB8CD, 0xB8E7, lbH3},
+	{0xB8E8, 0xB8E8, lbH2},
+	{0xB8E9, 0xB903, lbH3},
+	{0xB904, 0xB904, lbH2},
+	{0xB905, 0xB91F, lbH3},
+	{0xB920, 0xB920, lbH2},
+	{0xB921, 0xB93B, lbH3},
+	{0xB93C, 0xB93C, lbH2},
+	{0xB93D, 0xB957, lbH3},
+	{0xB958, 0xB958, lbH2},
+	{0xB959, 0xB973, lbH3},
+	{0xB974, 0xB974, lbH2},
+	{0xB975, 0xB98F, lbH3},
+	{0xB990, 0xB990, lbH2},
+	{0xB991, 0xB9AB, lbH3},
+	{0xB9AC, 0xB9AC, lbH2},
+	{0xB9AD, 0xB9C7, lbH3},
+	{0xB9C8, 0xB9C8, lbH2},
+	{0xB9C9, 0xB9E3, lbH3},
+	{0xB9E4, 0xB9E4, lbH2},
+	{0xB9E5, 0xB9FF, lbH3},
+	{0xBA00, 0xBA00, lbH2},
+	{0xBA01, 0xBA1B, lbH3},
+	{0xBA1C, 0xBA1C, lbH2},
+	{0xBA1D, 0xBA37, lbH3},
+	{0xBA38, 0xBA38, lbH2},
+	{0xBA39, 0xBA53, lbH3},
+	{0xBA54, 0xBA54, lbH2},
+	{0xBA55, 0xBA6F, lbH3},
+	{0xBA70, 0xBA70, lbH2},
+	{0xBA71, 0xBA8B, lbH3},
+	{0xBA8C, 0xBA8C, lbH2},
+	{0xBA8D, 0xBAA7, lbH3},
+	{0xBAA8, 0xBAA8, lbH2},
+	{0xBAA9, 0xBAC3, lbH3},
+	{0xBAC4, 0xBAC4, lbH2},
+	{0xBAC5, 0xBADF, lbH3},
+	{0xBAE0, 0xBAE0, lbH2},
+	{0xBAE1, 0xBAFB, lbH3},
+	{0xBAFC, 0xBAFC, lbH2},
+	{0xBAFD, 0xBB17, lbH3},
+	{0xBB18, 0xBB18, lbH2},
+	{0xBB19, 0xBB33, lbH3},
+	{0xBB34, 0xBB34, lbH2},
+	{0xBB35, 0xBB4F, lbH3},
+	{0xBB50, 0xBB50, lbH2},
+	{0xBB51, 0xBB6B, lbH3},
+	{0xBB6C, 0xBB6C, lbH2},
+	{0xBB6D, 0xBB87, lbH3},
+	{0xBB88, 0xBB88, lbH2},
+	{0xBB89, 0xBBA3, lbH3},
+	{0xBBA4, 0xBBA4, lbH2},
+	{0xBBA5, 0xBBBF, lbH3},
+	{0xBBC0, 0xBBC0, lbH2},
+	{0xBBC1, 0xBBDB, lbH3},
+	{0xBBDC, 0xBBDC, lbH2},
+	{0xBBDD, 0xBBF7, lbH3},
+	{0xBBF8, 0xBBF8, lbH2},
+	{0xBBF9, 0xBC13, lbH3},
+	{0xBC14, 0xBC14, lbH2},
+	{0xBC15, 0xBC2F, lbH3},
+	{0xBC30, 0xBC30, lbH2},
+	{0xBC31, 0xBC4B, lbH3},
+	{0xBC4C, 0xBC4C, lbH2},
+	{0xBC4D, 0xBC67, lbH3},
+	{0xBC68, 0xBC68, lbH2},
+	{0xBC69, 0xBC83, lbH3},
+	{0xBC84, 0xBC84, lbH2},
+	{0xBC85, 0xBC9F, lbH3},
+	{0xBCA0, 0xBCA0, lbH2},
+	{0xBCA1, 0xBCBB, lbH3},
+	{0xBCBC, 0xBCBC, lbH2},
+	{0xBCBD, 0xBCD7, lbH3},
+	{0xBCD8, 0xBCD8, lbH2},
+	{0xBCD9, 0xBCF3, lbH3},
+	{0xBCF4, 0xBCF4, lbH2},
+	{0xBCF5, 0xBD0F, lbH3},
+	{0xBD10, 0xBD10, lbH2},
+	{0xBD11, 0xBD2B, lbH3},
+	{0xBD2C, 0xBD2C, lbH2},
+	{0xBD2D, 0xBD47, lbH3},
+	{0xBD48, 0xBD48, lbH2},
+	{0xBD49, 0xBD63, lbH3},
+	{0xBD64, 0xBD64, lbH2},
+	{0xBD65, 0xBD7F, lbH3},
+	{0xBD80, 0xBD80, lbH2},
+	{0xBD81, 0xBD9B, lbH3},
+	{0xBD9C, 0xBD9C, lbH2},
+	{0xBD9D, 0xBDB7, lbH3},
+	{0xBDB8, 0xBDB8, lbH2},
+	{0xBDB9, 0xBDD3, lbH3},
+	{0xBDD4, 0xBDD4, lbH2},
+	{0xBDD5, 0xBDEF, lbH3},
+	{0xBDF0, 0xBDF0, lbH2},
+	{0xBDF1, 0xBE0B, lbH3},
+	{0xBE0C, 0xBE0C, lbH2},
+	{0xBE0D, 0xBE27, lbH3},
+	{0xBE28, 0xBE28, lbH2},
+	{0xBE29, 0xBE43, lbH3},
+	{0xBE44, 0xBE44, lbH2},
+	{0xBE45, 0xBE5F, lbH3},
+	{0xBE60, 0xBE60, lbH2},
+	{0xBE61, 0xBE7B, lbH3},
+	{0xBE7C, 0xBE7C, lbH2},
+	{0xBE7D, 0xBE97, lbH3},
+	{0xBE98, 0xBE98, lbH2},
+	{0xBE99, 0xBEB3, lbH3},
+	{0xBEB4, 0xBEB4, lbH2},
+	{0xBEB5, 0xBECF, lbH3},
+	{0xBED0, 0xBED0, lbH2},
+	{0xBED1, 0xBEEB, lbH3},
+	{0xBEEC, 0xBEEC, lbH2},
+	{0xBEED, 0xBF07, lbH3},
+	{0xBF08, 0xBF08, lbH2},
+	{0xBF09, 0xBF23, lbH3},
+	{0xBF24, 0xBF24, lbH2},
+	{0xBF25, 0xBF3F, lbH3},
+	{0xBF40, 0xBF40, lbH2},
+	{0xBF41, 0xBF5B, lbH3},
+	{0xBF5C, 0xBF5C, lbH2},
+	{0xBF5D, 0xBF77, lbH3},
+	{0xBF78, 0xBF78, lbH2},
+	{0xBF79, 0xBF93, lbH3},
+	{0xBF94, 0xBF94, lbH2},
+	{0xBF95, 0xBFAF, lbH3},
+	{0xBFB0, 0xBFB0, lbH2},
+	{0xBFB1, 0xBFCB, lbH3},
+	{0xBFCC, 0xBFCC, lbH2},
+	{0xBFCD, 0xBFE7, lbH3},
+	{0xBFE8, 0xBFE8, lbH2},
+	{0xBFE9, 0xC003, lbH3},
+	{0xC004, 0xC004, lbH2},
+	{0xC005, 0xC01F, lbH3},
+	{0xC020, 0xC020, lbH2},
+	{0xC021, 0xC03B, lbH3},
+	{0xC03C, 0xC03C, lbH2},
+	{0xC03D, 0xC057, lbH3},
+	{0xC058, 0xC058, lbH2},
+	{0xC059, 0xC073, lbH3},
+	{0xC074, 0xC074, lbH2},
+	{0xC075, 0xC08F, lbH3},
+	{0xC090, 0xC090, lbH2},
+	{0xC091, 0xC0AB, lbH3},
+	{0xC0AC, 0xC0AC, lbH2},
+	{0xC0AD, 0xC0C7, lbH3},
+	{0xC0C8, 0xC0C8, lbH2},
+	{0xC0C9, 0xC0E3, lbH3},
+	{0xC0E4, 0xC0E4, lbH2},
+	{0xC0E5, 0xC0FF, lbH3},
+	{0xC100, 0xC100, lbH2},
+	{0xC101, 0xC11B, lbH3},
+	{0xC11C, 0xC11C, lbH2},
+	{0xC11D, 0xC137, lbH3},
+	{0xC138, 0xC138, lbH2},
+	{0xC139, 0xC153, lbH3},
+	{0xC154, 0xC154, lbH2},
+	{0xC155, 0xC16F, lbH3},
+	{0xC170, 0xC170, lbH2},
+	{0xC171, 0xC18B, lbH3},
+	{0xC18C, 0xC18C, lbH2},
+	{0xC18D, 0xC1A7, lbH3},
+	{0xC1A8, 0xC1A8, lbH2},
+	{0xC1A9, 0xC1C3, lbH3},
+	{0xC1C4, 0xC1C4, lbH2},
+	{0xC1C5, 0xC1DF, lbH3},
+	{0xC1E0, 0xC1E0, lbH2},
+	{0xC1E1, 0xC1FB, lbH3},
+	{0xC1FC, 0xC1FC, lbH2},
+	{0xC1FD, 0xC217, lbH3},
+	{0xC218, 0xC218, lbH2},
+	{0xC219, 0xC233, lbH3},
+	{0xC234, 0xC234, lbH2},
+	{0xC235, 0xC24F, lbH3},
+	{0xC250, 0xC250, lbH2},
+	{0xC251, 0xC26B, lbH3},
+	{0xC26C, 0xC26C, lbH2},
+	{0xC26D, 0xC287, lbH3},
+	{0xC288, 0xC288, lbH2},
+	{0xC289, 0xC2A3, lbH3},
+	{0xC2A4, 0xC2A4, lbH2},
+	{0xC2A5, 0xC2BF, lbH3},
+	{0xC2C0, 0xC2C0, lbH2},
+	{0xC2C1, 0xC2DB, lbH3},
+	{0xC2DC, 0xC2DC, lbH2},
+	{0xC2DD, 0xC2F7, lbH3},
+	{0xC2F8, 0xC2F8, lbH2},
+	{0xC2F9, 0xC313, lbH3},
+	{0xC314, 0xC314, lbH2},
+	{0xC315, 0xC32F, lbH3},
+	{0xC330, 0xC330, lbH2},
+	{0xC331, 0xC34B, lbH3},
+	{0xC34C, 0xC34C, lbH2},
+	{0xC34D, 0xC367, lbH3},
+	{0xC368, 0xC368, lbH2},
+	{0xC369, 0xC383, lbH3},
+	{0xC384, 0xC384, lbH2},
+	{0xC385, 0xC39F, lbH3},
+	{0xC3A0, 0xC3A0, lbH2},
+	{0xC3A1, 0xC3BB, lbH3},
+	{0xC3BC, 0xC3BC, lbH2},
+	{0xC3BD, 0xC3D7, lbH3},
+	{0xC3D8, 0xC3D8, lbH2},
+	{0xC3D9, 0xC3F3, lbH3},
+	{0xC3F4, 0xC3F4, lbH2},
+	{0xC3F5, 0xC40F, lbH3},
+	{0xC410, 0xC410, lbH2},
+	{0xC411, 0xC42B, lbH3},
+	{0xC42C, 0xC42C, lbH2},
+	{0xC42D, 0xC447, lbH3},
+	{0xC448, 0xC448, lbH2},
+	{0xC449, 0xC463, lbH3},
+	{0xC464, 0xC464, lbH2},
+	{0xC465, 0xC47F, lbH3},
+	{0xC480, 0xC480, lbH2},
+	{0xC481, 0xC49B, lbH3},
+	{0xC49C, 0xC49C, lbH2},
+	{0xC49D, 0xC4B7, lbH3},
+	{0xC4B8, 0xC4B8, lbH2},
+	{0xC4B9, 0xC4D3, lbH3},
+	{0xC4D4, 0xC4D4, lbH2},
+	{0xC4D5, 0xC4EF, lbH3},
+	{0xC4F0, 0xC4F0, lbH2},
+	{0xC4F1, 0xC50B, lbH3},
+	{0xC50C, 0xC50C, lbH2},
+	{0xC50D, 0xC527, lbH3},
+	{0xC528, 0xC528, lbH2},
+	{0xC529, 0xC543, lbH3},
+	{0xC544, 0xC544, lbH2},
+	{0xC545, 0xC55F, lbH3},
+	{0xC560, 0xC560, lbH2},
+	{0xC561, 0xC57B, lbH3},
+	{0xC57C, 0xC57C, lbH2},
+	{0xC57D, 0xC597, lbH3},
+	{0xC598, 0xC598, lbH2},
+	{0xC599, 0xC5B3, lbH3},
+	{0xC5B4, 0xC5B4, lbH2},
+	{0xC5B5, 0xC5CF, lbH3},
+	{0xC5D0, 0xC5D0, lbH2},
+	{0xC5D1, 0xC5EB, lbH3},
+	{0xC5EC, 0xC5EC, lbH2},
+	{0xC5ED, 0xC607, lbH3},
+	{0xC608, 0xC608, lbH2},
+	{0xC609, 0xC623, lbH3},
+	{0xC624, 0xC624, lbH2},
+	{0xC625, 0xC63F, lbH3},
+	{0xC640, 0xC640, lbH2},
+	{0xC641, 0xC65B, lbH3},
+	{0xC65C, 0xC65C, lbH2},
+	{0xC65D, 0xC677, lbH3},
+	{0xC678, 0xC678, lbH2},
+	{0xC679, 0xC693, lbH3},
+	{0xC694, 0xC694, lbH2},
+	{0xC695, 0xC6AF, lbH3},
+	{0xC6B0, 0xC6B0, lbH2},
+	{0xC6B1, 0xC6CB, lbH3},
+	{0xC6CC, 0xC6CC, lbH2},
+	{0xC6CD, 0xC6E7, lbH3},
+	{0xC6E8, 0xC6E8, lbH2},
+	{0xC6E9, 0xC703, lbH3},
+	{0xC704, 0xC704, lbH2},
+	{0xC705, 0xC71F, lbH3},
+	{0xC720, 0xC720, lbH2},
+	{0xC721, 0xC73B, lbH3},
+	{0xC73C, 0xC73C, lbH2},
+	{0xC73D, 0xC757, lbH3},
+	{0xC758, 0xC758, lbH2},
+	{0xC759, 0xC773, lbH3},
+	{0xC774, 0xC774, lbH2},
+	{0xC775, 0xC78F, lbH3},
+	{0xC790, 0xC790, lbH2},
+	{0xC791, 0xC7AB, lbH3},
+	{0xC7AC, 0xC7AC, lbH2},
+	{0xC7AD, 0xC7C7, lbH3},
+	{0xC7C8, 0xC7C8, lbH2},
+	{0xC7C9, 0xC7E3, lbH3},
+	{0xC7E4, 0xC7E4, lbH2},
+	{0xC7E5, 0xC7FF, lbH3},
+	{0xC800, 0xC800, lbH2},
+	{0xC801, 0xC81B, lbH3},
+	{0xC81C, 0xC81C, lbH2},
+	{0xC81D, 0xC837, lbH3},
+	{0xC838, 0xC838, lbH2},
+	{0xC839, 0xC853, lbH3},
+	{0xC854, 0xC854, lbH2},
+	{0xC855, 0xC86F, lbH3},
+	{0xC870, 0xC870, lbH2},
+	{0xC871, 0xC88B, lbH3},
+	{0xC88C, 0xC88C, lbH2},
+	{0xC88D, 0xC8A7, lbH3},
+	{0xC8A8, 0xC8A8, lbH2},
+	{0xC8A9, 0xC8C3, lbH3},
+	{0xC8C4, 0xC8C4, lbH2},
+	{0xC8C5, 0xC8DF, lbH3},
+	{0xC8E0, 0xC8E0, lbH2},
+	{0xC8E1, 0xC8FB, lbH3},
+	{0xC8FC, 0xC8FC, lbH2},
+	{0xC8FD, 0xC917, lbH3},
+	{0xC918, 0xC918, lbH2},
+	{0xC919, 0xC933, lbH3},
+	{0xC934, 0xC934, lbH2},
+	{0xC935, 0xC94F, lbH3},
+	{0xC950, 0xC950, lbH2},
+	{0xC951, 0xC96B, lbH3},
+	{0xC96C, 0xC96C, lbH2},
+	{0xC96D, 0xC987, lbH3},
+	{0xC988, 0xC988, lbH2},
+	{0xC989, 0xC9A3, lbH3},
+	{0xC9A4, 0xC9A4, lbH2},
+	{0xC9A5, 0xC9BF, lbH3},
+	{0xC9C0, 0xC9C0, lbH2},
+	{0xC9C1, 0xC9DB, lbH3},
+	{0xC9DC, 0xC9DC, lbH2},
+	{0xC9DD, 0xC9F7, lbH3},
+	{0xC9F8, 0xC9F8, lbH2},
+	{0xC9F9, 0xCA13, lbH3},
+	{0xCA14, 0xCA14, lbH2},
+	{0xCA15, 0xCA2F, lbH3},
+	{0xCA30, 0xCA30, lbH2},
+	{0xCA31, 0xCA4B, lbH3},
+	{0xCA4C, 0xCA4C, lbH2},
+	{0xCA4D, 0xCA67, lbH3},
+	{0xCA68, 0xCA68, lbH2},
+	{0xCA69, 0xCA83, lbH3},
+	{0xCA84, 0xCA84, lbH2},
+	{0xCA85, 0xCA9F, lbH3},
+	{0xCAA0, 0xCAA0, lbH2},
+	{0xCAA1, 0xCABB, lbH3},
+	{0xCABC, 0xCABC, lbH2},
+	{0xCABD, 0xCAD7, lbH3},
+	{0xCAD8, 0xCAD8, lbH2},
+	{0xCAD9, 0xCAF3, lbH3},
+	{0xCAF4, 0xCAF4, lbH2},
+	{0xCAF5, 0xCB0F, lbH3},
+	{0xCB10, 0xCB10, lbH2},
+	{0xCB11, 0xCB2B, lbH3},
+	{0xCB2C, 0xCB2C, lbH2},
+	{0xCB2D, 0xCB47, lbH3},
+	{0xCB48, 0xCB48, lbH2},
+	{0xCB49, 0xCB63, lbH3},
+	{0xCB64, 0xCB64, lbH2},
+	{0xCB65, 0xCB7F, lbH3},
+	{0xCB80, 0xCB80, lbH2},
+	{0xCB81, 0xCB9B, lbH3},
+	{0xCB9C, 0xCB9C, lbH2},
+	{0xCB9D, 0xCBB7, lbH3},
+	{0xCBB8, 0xCBB8, lbH2},
+	{0xCBB9, 0xCBD3, lbH3},
+	{0xCBD4, 0xCBD4, lbH2},
+	{0xCBD5, 0xCBEF, lbH3},
+	{0xCBF0, 0xCBF0, lbH2},
+	{0xCBF1, 0xCC0B, lbH3},
+	{0xCC0C, 0xCC0C, lbH2},
+	{0xCC0D, 0xCC27, lbH3},
+	{0xCC28, 0xCC28, lbH2},
+	{0xCC29, 0xCC43, lbH3},
+	{0xCC44, 0xCC44, lbH2},
+	{0xCC45, 0xCC5F, lbH3},
+	{0xCC60, 0xCC60, lbH2},
+	{0xCC61, 0xCC7B, lbH3},
+	{0xCC7C, 0xCC7C, lbH2},
+	{0xCC7D, 0xCC97, lbH3},
+	{0xCC98, 0xCC98, lbH2},
+	{0xCC99, 0xCCB3, lbH3},
+	{0xCCB4, 0xCCB4, lbH2},
+	{0xCCB5, 0xCCCF, lbH3},
+	{0xCCD0, 0xCCD0, lbH2},
+	{0xCCD1, 0xCCEB, lbH3},
+	{0xCCEC, 0xCCEC, lbH2},
+	{0xCCED, 0xCD07, lbH3},
+	{0xCD08, 0xCD08, lbH2},
+	{0xCD09, 0xCD23, lbH3},
+	{0xCD24, 0xCD24, lbH2},
+	{0xCD25, 0xCD3F, lbH3},
+	{0xCD40, 0xCD40, lbH2},
+	{0xCD41, 0xCD5B, lbH3},
+	{0xCD5C, 0xCD5C, lbH2},
+	{0xCD5D, 0xCD77, lbH3},
+	{0xCD78, 0xCD78, lbH2},
+	{0xCD79, 0xCD93, lbH3},
+	{0xCD94, 0xCD94, lbH2},
+	{0xCD95, 0xCDAF, lbH3},
+	{0xCDB0, 0xCDB0, lbH2},
+	{0xCDB1, 0xCDCB, lbH3},
+	{0xCDCC, 0xCDCC, lbH2},
+	{0xCDCD, 0xCDE7, lbH3},
+	{0xCDE8, 0xCDE8, lbH2},
+	{0xCDE9, 0xCE03, lbH3},
+	{0xCE04, 0xCE04, lbH2},
+	{0xCE05, 0xCE1F, lbH3},
+	{0xCE20, 0xCE20, lbH2},
+	{0xCE21, 0xCE3B, lbH3},
+	{0xCE3C, 0xCE3C, lbH2},
+	{0xCE3D, 0xCE57, lbH3},
+	{0xCE58, 0xCE58, lbH2},
+	{0xCE59, 0xCE73, lbH3},
+	{0xCE74, 0xCE74, lbH2},
+	{0xCE75, 0xCE8F, lbH3},
+	{0xCE90, 0xCE90, lbH2},
+	{0xCE91, 0xCEAB, lbH3},
+	{0xCEAC, 0xCEAC, lbH2},
+	{0xCEAD, 0xCEC7, lbH3},
+	{0xCEC8, 0xCEC8, lbH2},
+	{0xCEC9, 0xCEE3, lbH3},
+	{0xCEE4, 0xCEE4, lbH2},
+	{0xCEE5, 0xCEFF, lbH3},
+	{0xCF00, 0xCF00, lbH2},
+	{0xCF01, 0xCF1B, lbH3},
+	{0xCF1C, 0xCF1C, lbH2},
+	{0xCF1D, 0xCF37, lbH3},
+	{0xCF38, 0xCF38, lbH2},
+	{0xCF39, 0xCF53, lbH3},
+	{0xCF54, 0xCF54, lbH2},
+	{0xCF55, 0xCF6F, lbH3},
+	{0xCF70, 0xCF70, lbH2},
+	{0xCF71, 0xCF8B, lbH3},
+	{0xCF8C, 0xCF8C, lbH2},
+	{0xCF8D, 0xCFA7, lbH3},
+	{0xCFA8, 0xCFA8, lbH2},
+	{0xCFA9, 0xCFC3, lbH3},
+	{0xCFC4, 0xCFC4, lbH2},
+	{0xCFC5, 0xCFDF, lbH3},
+	{0xCFE0, 0xCFE0, lbH2},
+	{0xCFE1, 0xCFFB, lbH3},
+	{0xCFFC, 0xCFFC, lbH2},
+	{0xCFFD, 0xD017, lbH3},
+	{0xD018, 0xD018, lbH2},
+	{0xD019, 0xD033, lbH3},
+	{0xD034, 0xD034, lbH2},
+	{0xD035, 0xD04F, lbH3},
+	{0xD050, 0xD050, lbH2},
+	{0xD051, 0xD06B, lbH3},
+	{0xD06C, 0xD06C, lbH2},
+	{0xD06D, 0xD087, lbH3},
+	{0xD088, 0xD088, lbH2},
+	{0xD089, 0xD0A3, lbH3},
+	{0xD0A4, 0xD0A4, lbH2},
+	{0xD0A5, 0xD0BF, lbH3},
+	{0xD0C0, 0xD0C0, lbH2},
+	{0xD0C1, 0xD0DB, lbH3},
+	{0xD0DC, 0xD0DC, lbH2},
+	{0xD0DD, 0xD0F7, lbH3},
+	{0xD0F8, 0xD0F8, lbH2},
+	{0xD0F9, 0xD113, lbH3},
+	{0xD114, 0xD114, lbH2},
+	{0xD115, 0xD12F, lbH3},
+	{0xD130, 0xD130, lbH2},
+	{0xD131, 0xD14B, lbH3},
+	{0xD14C, 0xD14C, lbH2},
+	{0xD14D, 0xD167, lbH3},
+	{0xD168, 0xD168, lbH2},
+	{0xD169, 0xD183, lbH3},
+	{0xD184, 0xD184, lbH2},
+	{0xD185, 0xD19F, lbH3},
+	{0xD1A0, 0xD1A0, lbH2},
+	{0xD1A1, 0xD1BB, lbH3},
+	{0xD1BC, 0xD1BC, lbH2},
+	{0xD1BD, 0xD1D7, lbH3},
+	{0xD1D8, 0xD1D8, lbH2},
+	{0xD1D9, 0xD1F3, lbH3},
+	{0xD1F4, 0xD1F4, lbH2},
+	{0xD1F5, 0xD20F, lbH3},
+	{0xD210, 0xD210, lbH2},
+	{0xD211, 0xD22B, lbH3},
+	{0xD22C, 0xD22C, lbH2},
+	{0xD22D, 0xD247, lbH3},
+	{0xD248, 0xD248, lbH2},
+	{0xD249, 0xD263, lbH3},
+	{0xD264, 0xD264, lbH2},
+	{0xD265, 0xD27F, lbH3},
+	{0xD280, 0xD280, lbH2},
+	{0xD281, 0xD29B, lbH3},
+	{0xD29C, 0xD29C, lbH2},
+	{0xD29D, 0xD2B7, lbH3},
+	{0xD2B8, 0xD2B8, lbH2},
+	{0xD2B9, 0xD2D3, lbH3},
+	{0xD2D4, 0xD2D4, lbH2},
+	{0xD2D5, 0xD2EF, lbH3},
+	{0xD2F0, 0xD2F0, lbH2},
+	{0xD2F1, 0xD30B, lbH3},
+	{0xD30C, 0xD30C, lbH2},
+	{0xD30D, 0xD327, lbH3},
+	{0xD328, 0xD328, lbH2},
+	{0xD329, 0xD343, lbH3},
+	{0xD344, 0xD344, lbH2},
+	{0xD345, 0xD35F, lbH3},
+	{0xD360, 0xD360, lbH2},
+	{0xD361, 0xD37B, lbH3},
+	{0xD37C, 0xD37C, lbH2},
+	{0xD37D, 0xD397, lbH3},
+	{0xD398, 0xD398, lbH2},
+	{0xD399, 0xD3B3, lbH3},
+	{0xD3B4, 0xD3B4, lbH2},
+	{0xD3B5, 0xD3CF, lbH3},
+	{0xD3D0, 0xD3D0, lbH2},
+	{0xD3D1, 0xD3EB, lbH3},
+	{0xD3EC, 0xD3EC, lbH2},
+	{0xD3ED, 0xD407, lbH3},
+	{0xD408, 0xD408, lbH2},
+	{0xD409, 0xD423, lbH3},
+	{0xD424, 0xD424, lbH2},
+	{0xD425, 0xD43F, lbH3},
+	{0xD440, 0xD440, lbH2},
+	{0xD441, 0xD45B, lbH3},
+	{0xD45C, 0xD45C, lbH2},
+	{0xD45D, 0xD477, lbH3},
+	{0xD478, 0xD478, lbH2},
+	{0xD479, 0xD493, lbH3},
+	{0xD494, 0xD494, lbH2},
+	{0xD495, 0xD4AF, lbH3},
+	{0xD4B0, 0xD4B0, lbH2},
+	{0xD4B1, 0xD4CB, lbH3},
+	{0xD4CC, 0xD4CC, lbH2},
+	{0xD4CD, 0xD4E7, lbH3},
+	{0xD4E8, 0xD4E8, lbH2},
+	{0xD4E9, 0xD503, lbH3},
+	{0xD504, 0xD504, lbH2},
+	{0xD505, 0xD51F, lbH3},
+	{0xD520, 0xD520, lbH2},
+	{0xD521, 0xD53B, lbH3},
+	{0xD53C, 0xD53C, lbH2},
+	{0xD53D, 0xD557, lbH3},
+	{0xD558, 0xD558, lbH2},
+	{0xD559, 0xD573, lbH3},
+	{0xD574, 0xD574, lbH2},
+	{0xD575, 0xD58F, lbH3},
+	{0xD590, 0xD590, lbH2},
+	{0xD591, 0xD5AB, lbH3},
+	{0xD5AC, 0xD5AC, lbH2},
+	{0xD5AD, 0xD5C7, lbH3},
+	{0xD5C8, 0xD5C8, lbH2},
+	{0xD5C9, 0xD5E3, lbH3},
+	{0xD5E4, 0xD5E4, lbH2},
+	{0xD5E5, 0xD5FF, lbH3},
+	{0xD600, 0xD600, lbH2},
+	{0xD601, 0xD61B, lbH3},
+	{0xD61C, 0xD61C, lbH2},
+	{0xD61D, 0xD637, lbH3},
+	{0xD638, 0xD638, lbH2},
+	{0xD639, 0xD653, lbH3},
+	{0xD654, 0xD654, lbH2},
+	{0xD655, 0xD66F, lbH3},
+	{0xD670, 0xD670, lbH2},
+	{0xD671, 0xD68B, lbH3},
+	{0xD68C, 0xD68C, lbH2},
+	{0xD68D, 0xD6A7, lbH3},
+	{0xD6A8, 0xD6A8, lbH2},
+	{0xD6A9, 0xD6C3, lbH3},
+	{0xD6C4, 0xD6C4, lbH2},
+	{0xD6C5, 0xD6DF, lbH3},
+	{0xD6E0, 0xD6E0, lbH2},
+	{0xD6E1, 0xD6FB, lbH3},
+	{0xD6FC, 0xD6FC, lbH2},
+	{0xD6FD, 0xD717, lbH3},
+	{0xD718, 0xD718, lbH2},
+	{0xD719, 0xD733, lbH3},
+	{0xD734, 0xD734, lbH2},
+	{0xD735, 0xD74F, lbH3},
+	{0xD750, 0xD750, lbH2},
+	{0xD751, 0xD76B, lbH3},
+	{0xD76C, 0xD76C, lbH2},
+	{0xD76D, 0xD787, lbH3},
+	{0xD788, 0xD788, lbH2},
+	{0xD789, 0xD7A3, lbH3},
+	{0xD7B0, 0xD7C6, lbJV},
+	{0xD7CB, 0xD7FB, lbJT},
+	{0xF900, 0xFAFF, lbID},
+	{0xFB1D, 0xFB1D, lbHL},
+	{0xFB1E, 0xFB1E, lbCM},
+	{0xFB1F, 0xFB28, lbHL},
+	{0xFB2A, 0xFB36, lbHL},
+	{0xFB38, 0xFB3C, lbHL},
+	{0xFB3E, 0xFB3E, lbHL},
+	{0xFB40, 0xFB41, lbHL},
+	{0xFB43, 0xFB44, lbHL},
+	{0xFB46, 0xFB4F, lbHL},
+	{0xFD3E, 0xFD3E, lbCL},
+	{0xFD3F, 0xFD3F, lbOP},
+	{0xFDFC, 0xFDFC, lbPO},
+	{0xFE00, 0xFE0F, lbCM},
+	{0xFE10, 0xFE10, lbIS},
+	{0xFE11, 0xFE12, lbCL},
+	{0xFE13, 0xFE14, lbIS},
+	{0xFE15, 0xFE16, lbEX},
+	{0xFE17, 0xFE17, lbOPEA},
+	{0xFE18, 0xFE18, lbCL},
+	{0xFE19, 0xFE19, lbIN},
+	{0xFE20, 0xFE2F, lbCM},
+	{0xFE30, 0xFE34, lbID},
+	{0xFE35, 0xFE35, lbOPEA},
+	{0xFE36, 0xFE36, lbCL},
+	{0xFE37, 0xFE37, lbOPEA},
+	{0xFE38, 0xFE38, lbCL},
+	{0xFE39, 0xFE39, lbOPEA},
+	{0xFE3A, 0xFE3A, lbCL},
+	{0xFE3B, 0xFE3B, lbOPEA},
+	{0xFE3C, 0xFE3C, lbCL},
+	{0xFE3D, 0xFE3D, lbOPEA},
+	{0xFE3E, 0xFE3E, lbCL},
+	{0xFE3F, 0xFE3F, lbOPEA},
+	{0xFE40, 0xFE40, lbCL},
+	{0xFE41, 0xFE41, lbOPEA},
+	{0xFE42, 0xFE42, lbCL},
+	{0xFE43, 0xFE43, lbOPEA},
+	{0xFE44, 0xFE44, lbCL},
+	{0xFE45, 0xFE46, lbID},
+	{0xFE47, 0xFE47, lbOPEA},
+	{0xFE48, 0xFE48, lbCL},
+	{0xFE49, 0xFE4F, lbID},
+	{0xFE50, 0xFE50, lbCL},
+	{0xFE51, 0xFE51, lbID},
+	{0xFE52, 0xFE52, lbCL},
+	{0xFE54, 0xFE55, lbNS},
+	{0xFE56, 0xFE57, lbEX},
+	{0xFE58, 0xFE58, lbID},
+	{0xFE59, 0xFE59, lbOPEA},
+	{0xFE5A, 0xFE5A, lbCL},
+	{0xFE5B, 0xFE5B, lbOPEA},
+	{0xFE5C, 0xFE5C, lbCL},
+	{0xFE5D, 0xFE5D, lbOPEA},
+	{0xFE5E, 0xFE5E, lbCL},
+	{0xFE5F, 0xFE66, lbID},
+	{0xFE68, 0xFE68, lbID},
+	{0xFE69, 0xFE69, lbPR},
+	{0xFE6A, 0xFE6A, lbPO},
+	{0xFE6B, 0xFE6B, lbID},
+	{0xFEFF, 0xFEFF, lbWJ},
+	{0xFF01, 0xFF01, lbEX},
+	{0xFF02, 0xFF03, lbID},
+	{0xFF04, 0xFF04, lbPR},
+	{0xFF05, 0xFF05, lbPO},
+	{0xFF06, 0xFF07, lbID},
+	{0xFF08, 0xFF08, lbOPEA},
+	{0xFF09, 0xFF09, lbCL},
+	{0xFF0A, 0xFF0B, lbID},
+	{0xFF0C, 0xFF0C, lbCL},
+	{0xFF0D, 0xFF0D, lbID},
+	{0xFF0E, 0xFF0E, lbCL},
+	{0xFF0F, 0xFF19, lbID},
+	{0xFF1A, 0xFF1B, lbNS},
+	{0xFF1C, 0xFF1E, lbID},
+	{0xFF1F, 0xFF1F, lbEX},
+	{0xFF20, 0xFF3A, lbID},
+	{0xFF3B, 0xFF3B, lbOPEA},
+	{0xFF3C, 0xFF3C, lbID},
+	{0xFF3D, 0xFF3D, lbCL},
+	{0xFF3E, 0xFF5A, lbID},
+	{0xFF5B, 0xFF5B, lbOPEA},
+	{0xFF5C, 0xFF5C, lbID},
+	{0xFF5D, 0xFF5D, lbCL},
+	{0xFF5E, 0xFF5E, lbID},
+	{0xFF5F, 0xFF5F, lbOPEA},
+	{0xFF60, 0xFF61, lbCL},
+	{0xFF62, 0xFF62, lbOPEA},
+	{0xFF63, 0xFF64, lbCL},
+	{0xFF65, 0xFF65, lbNS},
+	{0xFF66, 0xFF66, lbID},
+	{0xFF67, 0xFF70, lbNS},
+	{0xFF71, 0xFF9D, lbID},
+	{0xFF9E, 0xFF9F, lbNS},
+	{0xFFA0, 0xFFBE, lbID},
+	{0xFFC2, 0xFFC7, lbID},
+	{0xFFCA, 0xFFCF, lbID},
+	{0xFFD2, 0xFFD7, lbID},
+	{0xFFDA, 0xFFDC, lbID},
+	{0xFFE0, 0xFFE0, lbPO},
+	{0xFFE1, 0xFFE1, lbPR},
+	{0xFFE2, 0xFFE4, lbID},
+	{0xFFE5, 0xFFE6, lbPR},
+	{0xFFF9, 0xFFFB, lbCM},
+	{0xFFFC, 0xFFFC, lbCB},
+	{0x10100, 0x10102, lbBA},
+	{0x101FD, 0x101FD, lbCM},
+	{0x102E0, 0x102E0, lbCM},
+	{0x10376, 0x1037A, lbCM},
+	{0x1039F, 0x1039F, lbBA},
+	{0x103D0, 0x103D0, lbBA},
+	{0x104A0, 0x104A9, lbNU},
+	{0x10857, 0x10857, lbBA},
+	{0x1091F, 0x1091F, lbBA},
+	{0x10A01, 0x10A03, lbCM},
+	{0x10A05, 0x10A06, lbCM},
+	{0x10A0C, 0x10A0F, lbCM},
+	{0x10A38, 0x10A3A, lbCM},
+	{0x10A3F, 0x10A3F, lbCM},
+	{0x10A50, 0x10A57, lbBA},
+	{0x10AE5, 0x10AE6, lbCM},
+	{0x10AF0, 0x10AF5, lbBA},
+	{0x10AF6, 0x10AF6, lbIN},
+	{0x10B39, 0x10B3F, lbBA},
+	{0x10D24, 0x10D27, lbCM},
+	{0x10D30, 0x10D39, lbNU},
+	{0x10EAB, 0x10EAC, lbCM},
+	{0x10EAD, 0x10EAD, lbBA},
+	{0x10EFD, 0x10EFF, lbCM},
+	{0x10F46, 0x10F50, lbCM},
+	{0x10F82, 0x10F85, lbCM},
+	{0x11000, 0x11002, lbCM},
+	{0x11038, 0x11046, lbCM},
+	{0x11047, 0x11048, lbBA},
+	{0x11066, 0x1106F, lbNU},
+	{0x11070, 0x11070, lbCM},
+	{0x11073, 0x11074, lbCM},
+	{0x1107F, 0x11082, lbCM},
+	{0x110B0, 0x110BA, lbCM},
+	{0x110BE, 0x110C1, lbBA},
+	{0x110C2, 0x110C2, lbCM},
+	{0x110F0, 0x110F9, lbNU},
+	{0x11100, 0x11102, lbCM},
+	{0x11127, 0x11134, lbCM},
+	{0x11136, 0x1113F, lbNU},
+	{0x11140, 0x11143, lbBA},
+	{0x11145, 0x11146, lbCM},
+	{0x11173, 0x11173, lbCM},
+	{0x11175, 0x11175, lbBB},
+	{0x11180, 0x11182, lbCM},
+	{0x111B3, 0x111C0, lbCM},
+	{0x111C5, 0x111C6, lbBA},
+	{0x111C8, 0x111C8, lbBA},
+	{0x111C9, 0x111CC, lbCM},
+	{0x111CE, 0x111CF, lbCM},
+	{0x111D0, 0x111D9, lbNU},
+	{0x111DB, 0x111DB, lbBB},
+	{0x111DD, 0x111DF, lbBA},
+	{0x1122C, 0x11237, lbCM},
+	{0x11238, 0x11239, lbBA},
+	{0x1123B, 0x1123C, lbBA},
+	{0x1123E, 0x1123E, lbCM},
+	{0x11241, 0x11241, lbCM},
+	{0x112A9, 0x112A9, lbBA},
+	{0x112DF, 0x112EA, lbCM},
+	{0x112F0, 0x112F9, lbNU},
+	{0x11300, 0x11303, lbCM},
+	{0x1133B, 0x1133C, lbCM},
+	{0x1133E, 0x11344, lbCM},
+	{0x11347, 0x11348, lbCM},
+	{0x1134B, 0x1134D, lbCM},
+	{0x11357, 0x11357, lbCM},
+	{0x11362, 0x11363, lbCM},
+	{0x11366, 0x1136C, lbCM},
+	{0x11370, 0x11374, lbCM},
+	{0x11435, 0x11446, lbCM},
+	{0x1144B, 0x1144E, lbBA},
+	{0x11450, 0x11459, lbNU},
+	{0x1145A, 0x1145B, lbBA},
+	{0x1145E, 0x1145E, lbCM},
+	{0x114B0, 0x114C3, lbCM},
+	{0x114D0, 0x114D9, lbNU},
+	{0x115AF, 0x115B5, lbCM},
+	{0x115B8, 0x115C0, lbCM},
+	{0x115C1, 0x115C1, lbBB},
+	{0x115C2, 0x115C3, lbBA},
+	{0x115C4, 0x115C5, lbEX},
+	{0x115C9, 0x115D7, lbBA},
+	{0x115DC, 0x115DD, lbCM},
+	{0x11630, 0x11640, lbCM},
+	{0x11641, 0x11642, lbBA},
+	{0x11650, 0x11659, lbNU},
+	{0x11660, 0x1166C, lbBB},
+	{0x116AB, 0x116B7, lbCM},
+	{0x116C0, 0x116C9, lbNU},
+	{0x1171D, 0x1172B, lbCM},
+	{0x11730, 0x11739, lbNU},
+	{0x1173C, 0x1173E, lbBA},
+	{0x1182C, 0x1183A, lbCM},
+	{0x118E0, 0x118E9, lbNU},
+	{0x11930, 0x11935, lbCM},
+	{0x11937, 0x11938, lbCM},
+	{0x1193B, 0x1193E, lbCM},
+	{0x11940, 0x11940, lbCM},
+	{0x11942, 0x11943, lbCM},
+	{0x11944, 0x11946, lbBA},
+	{0x11950, 0x11959, lbNU},
+	{0x119D1, 0x119D7, lbCM},
+	{0x119DA, 0x119E0, lbCM},
+	{0x119E2, 0x119E2, lbBB},
+	{0x119E4, 0x119E4, lbCM},
+	{0x11A01, 0x11A0A, lbCM},
+	{0x11A33, 0x11A39, lbCM},
+	{0x11A3B, 0x11A3E, lbCM},
+	{0x11A3F, 0x11A3F, lbBB},
+	{0x11A41, 0x11A44, lbBA},
+	{0x11A45, 0x11A45, lbBB},
+	{0x11A47, 0x11A47, lbCM},
+	{0x11A51, 0x11A5B, lbCM},
+	{0x11A8A, 0x11A99, lbCM},
+	{0x11A9A, 0x11A9C, lbBA},
+	{0x11A9E, 0x11AA0, lbBB},
+	{0x11AA1, 0x11AA2, lbBA},
+	{0x11B00, 0x11B09, lbBB},
+	{0x11C2F, 0x11C36, lbCM},
+	{0x11C38, 0x11C3F, lbCM},
+	{0x11C41, 0x11C45, lbBA},
+	{0x11C50, 0x11C59, lbNU},
+	{0x11C70, 0x11C70, lbBB},
+	{0x11C71, 0x11C71, lbEX},
+	{0x11C92, 0x11CA7, lbCM},
+	{0x11CA9, 0x11CB6, lbCM},
+	{0x11D31, 0x11D36, lbCM},
+	{0x11D3A, 0x11D3A, lbCM},
+	{0x11D3C, 0x11D3D, lbCM},
+	{0x11D3F, 0x11D45, lbCM},
+	{0x11D47, 0x11D47, lbCM},
+	{0x11D50, 0x11D59, lbNU},
+	{0x11D8A, 0x11D8E, lbCM},
+	{0x11D90, 0x11D91, lbCM},
+	{0x11D93, 0x11D97, lbCM},
+	{0x11DA0, 0x11DA9, lbNU},
+	{0x11EF3, 0x11EF6, lbCM},
+	{0x11F00, 0x11F01, lbCM},
+	{0x11F03, 0x11F03, lbCM},
+	{0x11F34, 0x11F3A, lbCM},
+	{0x11F3E, 0x11F42, lbCM},
+	{0x11F43, 0x11F44, lbBA},
+	{0x11F45, 0x11F4F, lbID},
+	{0x11F50, 0x11F59, lbNU},
+	{0x11FDD, 0x11FE0, lbPO},
+	{0x11FFF, 0x11FFF, lbBA},
+	{0x12470, 0x12474, lbBA},
+	{0x13258, 0x1325A, lbOP},
+	{0x1325B, 0x1325D, lbCL},
+	{0x13282, 0x13282, lbCL},
+	{0x13286, 0x13286, lbOP},
+	{0x13287, 0x13287, lbCL},
+	{0x13288, 0x13288, lbOP},
+	{0x13289, 0x13289, lbCL},
+	{0x13379, 0x13379, lbOP},
+	{0x1337A, 0x1337B, lbCL},
+	{0x13430, 0x13436, lbGL},
+	{0x13437, 0x13437, lbOP},
+	{0x13438, 0x13438, lbCL},
+	{0x13439, 0x1343B, lbGL},
+	{0x1343C, 0x1343C, lbOP},
+	{0x1343D, 0x1343D, lbCL},
+	{0x1343E, 0x1343E, lbOP},
+	{0x1343F, 0x1343F, lbCL},
+	{0x13440, 0x13440, lbCM},
+	{0x13447, 0x13455, lbCM},
+	{0x145CE, 0x145CE, lbOP},
+	{0x145CF, 0x145CF, lbCL},
+	{0x16A60, 0x16A69, lbNU},
+	{0x16A6E, 0x16A6F, lbBA},
+	{0x16AC0, 0x16AC9, lbNU},
+	{0x16AF0, 0x16AF4, lbCM},
+	{0x16AF5, 0x16AF5, lbBA},
+	{0x16B30, 0x16B36, lbCM},
+	{0x16B37, 0x16B39, lbBA},
+	{0x16B44, 0x16B44, lbBA},
+	{0x16B50, 0x16B59, lbNU},
+	{0x16E97, 0x16E98, lbBA},
+	{0x16F4F, 0x16F4F, lbCM},
+	{0x16F51, 0x16F87, lbCM},
+	{0x16F8F, 0x16F92, lbCM},
+	{0x16FE0, 0x16FE3, lbNS},
+	{0x16FE4, 0x16FE4, lbGL},
+	{0x16FF0, 0x16FF1, lbCM},
+	{0x17000, 0x187F7, lbID},
+	{0x18800, 0x18AFF, lbID},
+	{0x18D00, 0x18D08, lbID},
+	{0x1B000, 0x1B122, lbID},
+	{0x1B132, 0x1B132, lbNS},
+	{0x1B150, 0x1B152, lbNS},
+	{0x1B155, 0x1B155, lbNS},
+	{0x1B164, 0x1B167, lbNS},
+	{0x1B170, 0x1B2FB, lbID},
+	{0x1BC9D, 0x1BC9E, lbCM},
+	{0x1BC9F, 0x1BC9F, lbBA},
+	{0x1BCA0, 0x1BCA3, lbCM},
+	{0x1CF00, 0x1CF2D, lbCM},
+	{0x1CF30, 0x1CF46, lbCM},
+	{0x1D165, 0x1D169, lbCM},
+	{0x1D16D, 0x1D182, lbCM},
+	{0x1D185, 0x1D18B, lbCM},
+	{0x1D1AA, 0x1D1AD, lbCM},
+	{0x1D242, 0x1D244, lbCM},
+	{0x1D7CE, 0x1D7FF, lbNU},
+	{0x1DA00, 0x1DA36, lbCM},
+	{0x1DA3B, 0x1DA6C, lbCM},
+	{0x1DA75, 0x1DA75, lbCM},
+	{0x1DA84, 0x1DA84, lbCM},
+	{0x1DA87, 0x1DA8A, lbBA},
+	{0x1DA9B, 0x1DA9F, lbCM},
+	{0x1DAA1, 0x1DAAF, lbCM},
+	{0x1E000, 0x1E006, lbCM},
+	{0x1E008, 0x1E018, lbCM},
+	{0x1E01B, 0x1E021, lbCM},
+	{0x1E023, 0x1E024, lbCM},
+	{0x1E026, 0x1E02A, lbCM},
+	{0x1E08F, 0x1E08F, lbCM},
+	{0x1E130, 0x1E136, lbCM},
+	{0x1E140, 0x1E149, lbNU},
+	{0x1E2AE, 0x1E2AE, lbCM},
+	{0x1E2EC, 0x1E2EF, lbCM},
+	{0x1E2F0, 0x1E2F9, lbNU},
+	{0x1E2FF, 0x1E2FF, lbPR},
+	{0x1E4EC, 0x1E4EF, lbCM},
+	{0x1E4F0, 0x1E4F9, lbNU},
+	{0x1E8D0, 0x1E8D6, lbCM},
+	{0x1E944, 0x1E94A, lbCM},
+	{0x1E950, 0x1E959, lbNU},
+	{0x1E95E, 0x1E95F, lbOP},
+	{0x1ECAC, 0x1ECAC, lbPO},
+	{0x1ECB0, 0x1ECB0, lbPO},
+	{0x1F000, 0x1F02B, lbID},
+	{0x1F02C, 0x1F02F, lbIDCn},
+	{0x1F030, 0x1F093, lbID},
+	{0x1F094, 0x1F09F, lbIDCn},
+	{0x1F0A0, 0x1F0AE, lbID},
+	{0x1F0AF, 0x1F0B0, lbIDCn},
+	{0x1F0B1, 0x1F0BF, lbID},
+	{0x1F0C0, 0x1F0C0, lbIDCn},
+	{0x1F0C1, 0x1F0CF, lbID},
+	{0x1F0D0, 0x1F0D0, lbIDCn},
+	{0x1F0D1, 0x1F0F5, lbID},
+	{0x1F0F6, 0x1F0FF, lbIDCn},
+	{0x1F10D, 0x1F10F, lbID},
+	{0x1F16D, 0x1F16F, lbID},
+	{0x1F1AD, 0x1F1AD, lbID},
+	{0x1F1AE, 0x1F1E5, lbIDCn},
+	{0x1F1E6, 0x1F1FF, lbRI},
+	{0x1F200, 0x1F202, lbID},
+	{0x1F203, 0x1F20F, lbIDCn},
+	{0x1F210, 0x1F23B, lbID},
+	{0x1F23C, 0x1F23F, lbIDCn},
+	{0x1F240, 0x1F248, lbID},
+	{0x1F249, 0x1F24F, lbIDCn},
+	{0x1F250, 0x1F251, lbID},
+	{0x1F252, 0x1F25F, lbIDCn},
+	{0x1F260, 0x1F265, lbID},
+	{0x1F266, 0x1F2FF, lbIDCn},
+	{0x1F300, 0x1F384, lbID},
+	{0x1F385, 0x1F385, lbEB},
+	{0x1F386, 0x1F39B, lbID},
+	{0x1F39E, 0x1F3B4, lbID},
+	{0x1F3B7, 0x1F3BB, lbID},
+	{0x1F3BD, 0x1F3C1, lbID},
+	{0x1F3C2, 0x1F3C4, lbEB},
+	{0x1F3C5, 0x1F3C6, lbID},
+	{0x1F3C7, 0x1F3C7, lbEB},
+	{0x1F3C8, 0x1F3C9, lbID},
+	{0x1F3CA, 0x1F3CC, lbEB},
+	{0x1F3CD, 0x1F3FA, lbID},
+	{0x1F3FB, 0x1F3FF, lbEM},
+	{0x1F400, 0x1F441, lbID},
+	{0x1F442, 0x1F443, lbEB},
+	{0x1F444, 0x1F445, lbID},
+	{0x1F446, 0x1F450, lbEB},
+	{0x1F451, 0x1F465, lbID},
+	{0x1F466, 0x1F478, lbEB},
+	{0x1F479, 0x1F47B, lbID},
+	{0x1F47C, 0x1F47C, lbEB},
+	{0x1F47D, 0x1F480, lbID},
+	{0x1F481, 0x1F483, lbEB},
+	{0x1F484, 0x1F484, lbID},
+	{0x1F485, 0x1F487, lbEB},
+	{0x1F488, 0x1F48E, lbID},
+	{0x1F48F, 0x1F48F, lbEB},
+	{0x1F490, 0x1F490, lbID},
+	{0x1F491, 0x1F491, lbEB},
+	{0x1F492, 0x1F49F, lbID},
+	{0x1F4A1, 0x1F4A1, lbID},
+	{0x1F4A3, 0x1F4A3, lbID},
+	{0x1F4A5, 0x1F4A9, lbID},
+	{0x1F4AA, 0x1F4AA, lbEB},
+	{0x1F4AB, 0x1F4AE, lbID},
+	{0x1F4B0, 0x1F4B0, lbID},
+	{0x1F4B3, 0x1F4FF, lbID},
+	{0x1F507, 0x1F516, lbID},
+	{0x1F525, 0x1F531, lbID},
+	{0x1F54A, 0x1F573, lbID},
+	{0x1F574, 0x1F575, lbEB},
+	{0x1F576, 0x1F579, lbID},
+	{0x1F57A, 0x1F57A, lbEB},
+	{0x1F57B, 0x1F58F, lbID},
+	{0x1F590, 0x1F590, lbEB},
+	{0x1F591, 0x1F594, lbID},
+	{0x1F595, 0x1F596, lbEB},
+	{0x1F597, 0x1F5D3, lbID},
+	{0x1F5DC, 0x1F5F3, lbID},
+	{0x1F5FA, 0x1F644, lbID},
+	{0x1F645, 0x1F647, lbEB},
+	{0x1F648, 0x1F64A, lbID},
+	{0x1F64B, 0x1F64F, lbEB},
+	{0x1F676, 0x1F678, lbQU},
+	{0x1F679, 0x1F67B, lbNS},
+	{0x1F680, 0x1F6A2, lbID},
+	{0x1F6A3, 0x1F6A3, lbEB},
+	{0x1F6A4, 0x1F6B3, lbID},
+	{0x1F6B4, 0x1F6B6, lbEB},
+	{0x1F6B7, 0x1F6BF, lbID},
+	{0x1F6C0, 0x1F6C0, lbEB},
+	{0x1F6C1, 0x1F6CB, lbID},
+	{0x1F6CC, 0x1F6CC, lbEB},
+	{0x1F6CD, 0x1F6D7, lbID},
+	{0x1F6D8, 0x1F6DB, lbIDCn},
+	{0x1F6DC, 0x1F6EC, lbID},
+	{0x1F6ED, 0x1F6EF, lbIDCn},
+	{0x1F6F0, 0x1F6FC, lbID},
+	{0x1F6FD, 0x1F6FF, lbIDCn},
+	{0x1F774, 0x1F776, lbID},
+	{0x1F777, 0x1F77A, lbIDCn},
+	{0x1F77B, 0x1F77F, lbID},
+	{0x1F7D5, 0x1F7D9, lbID},
+	{0x1F7DA, 0x1F7DF, lbIDCn},
+	{0x1F7E0, 0x1F7EB, lbID},
+	{0x1F7EC, 0x1F7EF, lbIDCn},
+	{0x1F7F0, 0x1F7F0, lbID},
+	{0x1F7F1, 0x1F7FF, lbIDCn},
+	{0x1F80C, 0x1F80F, lbIDCn},
+	{0x1F848, 0x1F84F, lbIDCn},
+	{0x1F85A, 0x1F85F, lbIDCn},
+	{0x1F888, 0x1F88F, lbIDCn},
+	{0x1F8AE, 0x1F8AF, lbIDCn},
+	{0x1F8B0, 0x1F8B1, lbID},
+	{0x1F8B2, 0x1F8FF, lbIDCn},
+	{0x1F90C, 0x1F90C, lbEB},
+	{0x1F90D, 0x1F90E, lbID},
+	{0x1F90F, 0x1F90F, lbEB},
+	{0x1F910, 0x1F917, lbID},
+	{0x1F918, 0x1F91F, lbEB},
+	{0x1F920, 0x1F925, lbID},
+	{0x1F926, 0x1F926, lbEB},
+	{0x1F927, 0x1F92F, lbID},
+	{0x1F930, 0x1F939, lbEB},
+	{0x1F93A, 0x1F93B, lbID},
+	{0x1F93C, 0x1F93E, lbEB},
+	{0x1F93F, 0x1F976, lbID},
+	{0x1F977, 0x1F977, lbEB},
+	{0x1F978, 0x1F9B4, lbID},
+	{0x1F9B5, 0x1F9B6, lbEB},
+	{0x1F9B7, 0x1F9B7, lbID},
+	{0x1F9B8, 0x1F9B9, lbEB},
+	{0x1F9BA, 0x1F9BA, lbID},
+	{0x1F9BB, 0x1F9BB, lbEB},
+	{0x1F9BC, 0x1F9CC, lbID},
+	{0x1F9CD, 0x1F9CF, lbEB},
+	{0x1F9D0, 0x1F9D0, lbID},
+	{0x1F9D1, 0x1F9DD, lbEB},
+	{0x1F9DE, 0x1F9FF, lbID},
+	{0x1FA54, 0x1FA5F, lbIDCn},
+	{0x1FA60, 0x1FA6D, lbID},
+	{0x1FA6E, 0x1FA6F, lbIDCn},
+	{0x1FA70, 0x1FA7C, lbID},
+	{0x1FA7D, 0x1FA7F, lbIDCn},
+	{0x1FA80, 0x1FA88, lbID},
+	{0x1FA89, 0x1FA8F, lbIDCn},
+	{0x1FA90, 0x1FABD, lbID},
+	{0x1FABE, 0x1FABE, lbIDCn},
+	{0x1FABF, 0x1FAC2, lbID},
+	{0x1FAC3, 0x1FAC5, lbEB},
+	{0x1FAC6, 0x1FACD, lbIDCn},
+	{0x1FACE, 0x1FADB, lbID},
+	{0x1FADC, 0x1FADF, lbIDCn},
+	{0x1FAE0, 0x1FAE8, lbID},
+	{0x1FAE9, 0x1FAEF, lbIDCn},
+	{0x1FAF0, 0x1FAF8, lbEB},
+	{0x1FAF9, 0x1FAFF, lbIDCn},
+	{0x1FBF0, 0x1FBF9, lbNU},
+	{0x1FC00, 0x1FFFD, lbIDCn},
+	{0x20000, 0x2FFFD, lbID},
+	{0x30000, 0x3FFFD, lbID},
+	{0xE0001, 0xE0001, lbCM},
+	{0xE0020, 0xE007F, lbCM},
+	{0xE0100, 0xE01EF, lbCM},
+}
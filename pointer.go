@@ -0,0 +1,149 @@
+package glay
+
+// SetPointerState sets the pointer position and whether it is pressed for the current frame.
+// Elements under the pointer are calculated from the last computed layout, so
+// SetPointerState must be called after EndLayout and before the next BeginLayout.
+// Called while a layout is being declared, clipped elements are never under the pointer.
+//
+// Once hit-testing is done the OnHover callbacks of hovered elements are called in the
+// order of PointerOverIDs: topmost tree root first and parent elements before their children.
+func (context *Context) SetPointerState(position Vector2, pressed bool) {
+	if context.warnMaxElementsExceeded() {
+		return
+	}
+	context.PointerInfo.Position = position
+	context.updatePointerInteractionState(pressed)
+	context.PointerOverIDs = context.PointerOverIDs[:0]
+	dfsBuffer := context.LayoutElementChildrenBuffer[:0]
+	// Tree roots are sorted by z-index, walk them from the topmost down.
+	for rootIndex := arrlen(context.LayoutElementTreeRoots) - 1; rootIndex >= 0; rootIndex-- {
+		dfsBuffer = dfsBuffer[:0]
+		root := &context.LayoutElementTreeRoots[rootIndex]
+		dfsBuffer = arradd(dfsBuffer, root.LayoutElementIndex)
+		context.TreeNodeVisited[0] = false
+		found := false
+		for len(dfsBuffer) > 0 {
+			if context.TreeNodeVisited[len(dfsBuffer)-1] {
+				dfsBuffer = dfsBuffer[:len(dfsBuffer)-1]
+				continue
+			}
+			context.TreeNodeVisited[len(dfsBuffer)-1] = true
+			currentElementIndex := dfsBuffer[len(dfsBuffer)-1]
+			currentElement := &context.LayoutElements[currentElementIndex]
+			mapItem := context.HashMapItem(currentElement.ID)
+			if mapItem == nil {
+				dfsBuffer = dfsBuffer[:len(dfsBuffer)-1]
+				continue
+			}
+			clipElementID := uintn(context.LayoutElementClipElementIDs[currentElementIndex])
+			elementBox := mapItem.BoundingBox
+			elementBox.X -= root.PointerOffset.X
+			elementBox.Y -= root.PointerOffset.Y
+			if elementBox.Contains(position) && (clipElementID == 0 || context.ExternalScrollHandlingEnabled || context.clipRect(clipElementID).Contains(position)) {
+				context.PointerOverIDs = arradd(context.PointerOverIDs, mapItem.ElementID)
+				found = true
+			}
+			if currentElement.GetConfig(ElementConfigTypeText) != nil {
+				dfsBuffer = dfsBuffer[:len(dfsBuffer)-1]
+				continue
+			}
+			children := currentElement.Children()
+			for i := arrlen(children) - 1; i >= 0; i-- {
+				dfsBuffer = arradd(dfsBuffer, children[i])
+				context.TreeNodeVisited[len(dfsBuffer)-1] = false
+			}
+		}
+		rootElement := &context.LayoutElements[root.LayoutElementIndex]
+		floatingConfig, _ := rootElement.GetConfig(ElementConfigTypeFloating).(*FloatingElementConfig)
+		if found && floatingConfig != nil && floatingConfig.PointerCaptureMode == PointerCaptureModeCapture {
+			break // Floating element captures the pointer, elements below it are not hovered.
+		}
+	}
+
+	// Callbacks are called after hit-testing so that they observe the complete pointer state.
+	for i := intn(0); i < arrlen(context.PointerOverIDs); i++ {
+		mapItem := context.HashMapItem(context.PointerOverIDs[i].ID)
+		if mapItem != nil && mapItem.OnHover != nil {
+			mapItem.OnHover(mapItem.ElementID, context.PointerInfo, mapItem.OnHoverUserData)
+		}
+	}
+}
+
+func (context *Context) updatePointerInteractionState(pressed bool) {
+	state := context.PointerInfo.State
+	if pressed {
+		if state == PointerDataPressedThisFrame {
+			state = PointerDataPressed
+		} else if state != PointerDataPressed {
+			state = PointerDataPressedThisFrame
+		}
+	} else {
+		if state == PointerReleasedThisFrame {
+			state = PointerReleased
+		} else if state != PointerReleased {
+			state = PointerReleasedThisFrame
+		}
+	}
+	context.PointerInfo.State = state
+}
+
+// OnHover registers a function to be called by SetPointerState when the currently open element
+// is under the pointer. It is meant to be called inside a Clay child function.
+func (context *Context) OnHover(fn func(id ElementID, pointer MousePointerData, userData any), userData any) {
+	if context.warnMaxElementsExceeded() {
+		return
+	}
+	openLayoutElement := context.openLayoutElement()
+	if openLayoutElement.ID == 0 {
+		context.generateIDForAnonElement(openLayoutElement)
+	}
+	hashMapItem := context.HashMapItem(openLayoutElement.ID)
+	if hashMapItem == nil {
+		return
+	}
+	hashMapItem.OnHover = fn
+	hashMapItem.OnHoverUserData = userData
+}
+
+// Hovered returns true if the currently open element was under the pointer during the
+// last call to SetPointerState, which reflects the previous frame's layout.
+// It is meant to be called inside a Clay child function.
+func (context *Context) Hovered() bool {
+	if context.warnMaxElementsExceeded() {
+		return false
+	}
+	openLayoutElement := context.openLayoutElement()
+	if openLayoutElement.ID == 0 {
+		context.generateIDForAnonElement(openLayoutElement)
+	}
+	return context.PointerOver(ElementID{ID: openLayoutElement.ID})
+}
+
+// PointerOver returns true if the element with the given ID was under the pointer
+// during the last call to SetPointerState.
+func (context *Context) PointerOver(id ElementID) bool {
+	for i := range context.PointerOverIDs {
+		if context.PointerOverIDs[i].ID == id.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains returns true if the point lies within the bounding box, edges included.
+func (bb BoundingBox) Contains(point Vector2) bool {
+	return point.X >= bb.X && point.X <= bb.X+bb.Width &&
+		point.Y >= bb.Y && point.Y <= bb.Y+bb.Height
+}
+
+// intersect returns the overlap of bb and other. The result has zero size if they do not overlap.
+func (bb BoundingBox) intersect(other BoundingBox) BoundingBox {
+	x, y := max(bb.X, other.X), max(bb.Y, other.Y)
+	return BoundingBox{
+		Vector2: Vector2{X: x, Y: y},
+		Dimensions: Dimensions{
+			Width:  max(min(bb.X+bb.Width, other.X+other.Width)-x, 0),
+			Height: max(min(bb.Y+bb.Height, other.Y+other.Height)-y, 0),
+		},
+	}
+}
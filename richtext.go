@@ -0,0 +1,321 @@
+package glay
+
+// RichText adds a text element made of styled spans as a child of the currently open element.
+// The spans wrap as a single paragraph and a text render command is emitted for every part
+// of a span on a wrapped line. Parts of a line are aligned on their baseline, see [TextMetrics].
+//
+// The wrap mode, alignment, line height and line limit of the paragraph are taken from the
+// configuration of the first span. Text overflow other than [TextOverflowClip] is not applied to rich text.
+// A line may break between spans only where a break is allowed inside a span or after
+// a span ending in whitespace. A text measurement function or BatchTextMeasurer must be set
+// on the Context before calling RichText. A span with a nil Config is styled like the span
+// before it, or with the zero TextElementConfig if it is the first.
+func (context *Context) RichText(spans []TextSpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	if arrfree(context.LayoutElements) == 0 || arrfree(context.ElementConfigs) == 0 || context.warnMaxElementsExceeded() ||
+		arrfree(context.TextElementConfigs) < intn(len(spans)) || arrfree(context.textSpans) < intn(len(spans)) {
+		context.elementsCapacityExceeded()
+		return ErrElementsCapacityExceeded
+	}
+	var dimensions, minDimensions Dimensions
+	var ascent, descent floatn
+	start := arrlen(context.textSpans)
+	var offset intn
+	var rightToLeft bool
+	configsStart := arrlen(context.TextElementConfigs)
+	for i := range spans {
+		// Store a copy of the configuration so that the caller's value does not escape.
+		var spanConfig *TextElementConfig
+		if spans[i].Config != nil {
+			context.TextElementConfigs = arradd(context.TextElementConfigs, *spans[i].Config)
+			spanConfig = arrlast(context.TextElementConfigs)
+		} else if i > 0 {
+			spanConfig = arrlast(context.textSpans).Config
+		} else {
+			context.TextElementConfigs = arradd(context.TextElementConfigs, TextElementConfig{})
+			spanConfig = arrlast(context.TextElementConfigs)
+		}
+		textMeasured := context.measureTextCached(spans[i].Text, spanConfig)
+		if textMeasured == nil {
+			context.textSpans = context.textSpans[:start]
+			context.TextElementConfigs = context.TextElementConfigs[:configsStart]
+			return ErrTextMeasurementCapacityExceeded
+		}
+		context.textSpans = arradd(context.textSpans, textSpan{
+			Text:     spans[i].Text,
+			Config:   spanConfig,
+			UserData: spans[i].UserData,
+			Height:   textMeasured.unwrappedDimensions.Height,
+			Ascent:   textMeasured.ascent,
+			Descent:  textMeasured.descent,
+			offset:   offset,
+		})
+		offset += intn(len(spans[i].Text))
+		rightToLeft = rightToLeft || textMeasured.rightToLeft
+		dimensions.Width += textMeasured.unwrappedDimensions.Width
+		dimensions.Height = max(dimensions.Height, textMeasured.unwrappedDimensions.Height)
+		minDimensions.Width = max(minDimensions.Width, textMeasured.minWidth)
+		ascent = max(ascent, textMeasured.ascent)
+		descent = max(descent, textMeasured.descent)
+	}
+	textSpans := context.textSpans[start:]
+	config := textSpans[0].Config
+	dimensions.Height = max(dimensions.Height, ascent+descent)
+	preferredDimensions := dimensions
+	if config.LineHeight > 0 {
+		dimensions.Height = floatn(config.LineHeight)
+	}
+	minDimensions.Height = dimensions.Height
+	return context.addTextElement(config, TextElementData{
+		PreferredDimensions: preferredDimensions,
+		// Baseline of the text on a single line, updated once wrapped.
+		baseline:    (dimensions.Height-preferredDimensions.Height)/2 + ascent,
+		spans:       textSpans,
+		rightToLeft: rightToLeft,
+	}, dimensions, minDimensions)
+}
+
+// wrapRichText breaks the spans of a rich text element into lines no wider than the element.
+// Words of adjacent spans that are not separated by a break opportunity are kept on the same line.
+func (context *Context) wrapRichText(textElementData *TextElementData, containerElement *LayoutElement, config *TextElementConfig) {
+	spans := textElementData.spans
+	for i := range spans {
+		mtci := context.lookupTextCached(spans[i].Text, spans[i].Config)
+		if mtci == nil {
+			return // Measurement cache exhausted, the text is not rendered.
+		}
+		spans[i].words = mtci.measureWordsStartIndex
+	}
+	wrapWidth := containerElement.Dimensions.Width
+	if config.WrapMode != TextWrapWords {
+		wrapWidth = maxfloat
+	}
+	var line richTextLine
+	line.reset(context)
+	spanIndex, wordIndex := context.nextRichTextWord(spans, 0, -1)
+wrap:
+	for spanIndex < intn(len(spans)) {
+		word := &context.measuredWords[wordIndex]
+		if word.Length == 0 {
+			// Forced line break.
+			if len(line.fragments(context)) == 0 {
+				line.height = spans[spanIndex].Height
+				line.ascent, line.descent = spans[spanIndex].Ascent, spans[spanIndex].Descent
+			}
+			context.addRichTextLine(textElementData, config, &line, 0)
+			line.offset = spans[spanIndex].offset + word.StartOffset // The next line may be empty.
+			spanIndex, wordIndex = context.nextRichTextWord(spans, spanIndex, wordIndex)
+			continue
+		}
+		// Words glued across span boundaries are placed as a unit.
+		unitWidth := word.Width
+		endSpan, endWord := spanIndex, wordIndex
+		for context.richTextWordsGlued(spans, endSpan, endWord) {
+			endSpan, endWord = context.nextRichTextWord(spans, endSpan, endWord)
+			unitWidth += context.measuredWords[endWord].Width
+		}
+		if line.lastWord != nil && line.width+unitWidth > wrapWidth {
+			context.addRichTextLine(textElementData, config, &line, wrapWidth)
+		}
+		for {
+			if !context.addRichTextWord(&line, spans, spanIndex, wordIndex) {
+				break wrap
+			}
+			done := spanIndex == endSpan && wordIndex == endWord
+			spanIndex, wordIndex = context.nextRichTextWord(spans, spanIndex, wordIndex)
+			if done {
+				break
+			}
+		}
+	}
+	if line.lastWord != nil {
+		context.addRichTextLine(textElementData, config, &line, 0)
+	}
+	context.truncateWrappedLines(textElementData, config, containerElement.Dimensions.Width)
+	var height floatn
+	for i := range textElementData.WrappedLines {
+		height += textElementData.WrappedLines[i].Dimensions.Height
+	}
+	containerElement.Dimensions.Height = height
+	if lines := textElementData.WrappedLines; len(lines) > 0 {
+		textElementData.baseline = (lines[0].Dimensions.Height-lines[0].naturalHeight)/2 + lines[0].ascent
+	}
+}
+
+// richTextLine is the state of the line being built by wrapRichText.
+type richTextLine struct {
+	start    intn // Index of the first fragment of the line in Context.wrappedTextFragments.
+	width    floatn
+	height   floatn // Natural height, the tallest span on the line.
+	ascent   floatn // Greatest ascent and descent of the spans on the line.
+	descent  floatn
+	spaces   intn // Runs of breaking spaces between the words of the line.
+	offset   intn // Byte offset of the line in the text of the element.
+	lastWord *measuredWord
+	// Offset in the span text of the first word of the last fragment.
+	fragmentStart intn
+}
+
+func (line *richTextLine) reset(context *Context) {
+	*line = richTextLine{start: arrlen(context.wrappedTextFragments)}
+}
+
+func (line *richTextLine) fragments(context *Context) []wrappedTextFragment {
+	return context.wrappedTextFragments[line.start:]
+}
+
+// nextRichTextWord returns the measured word following wordIndex of the span at spanIndex.
+// A wordIndex of -1 returns the first word of the span. The span index is len(spans) after the last word.
+func (context *Context) nextRichTextWord(spans []textSpan, spanIndex, wordIndex intn) (intn, intn) {
+	if wordIndex != -1 {
+		wordIndex = context.measuredWords[wordIndex].Next
+	} else {
+		wordIndex = spans[spanIndex].words
+	}
+	for wordIndex == -1 {
+		spanIndex++
+		if spanIndex >= intn(len(spans)) {
+			break
+		}
+		wordIndex = spans[spanIndex].words
+	}
+	return spanIndex, wordIndex
+}
+
+// richTextWordsGlued reports whether the line may not be broken after the given word,
+// which is the case when the word ends its span without a trailing space and text follows.
+func (context *Context) richTextWordsGlued(spans []textSpan, spanIndex, wordIndex intn) bool {
+	word := &context.measuredWords[wordIndex]
+	if word.Next != -1 || word.SpaceLength > 0 {
+		return false
+	}
+	nextSpan, nextWord := context.nextRichTextWord(spans, spanIndex, wordIndex)
+	return nextSpan < intn(len(spans)) && context.measuredWords[nextWord].Length > 0
+}
+
+// addRichTextWord appends a word to the line, extending the last fragment if the word belongs to the same span.
+func (context *Context) addRichTextWord(line *richTextLine, spans []textSpan, spanIndex, wordIndex intn) bool {
+	word := &context.measuredWords[wordIndex]
+	span := &spans[spanIndex]
+	if line.lastWord != nil && line.lastWord.SpaceLength > 0 {
+		line.spaces++
+	}
+	fragments := line.fragments(context)
+	if len(fragments) > 0 && fragments[len(fragments)-1].Span == spanIndex {
+		fragment := &fragments[len(fragments)-1]
+		fragment.Line = span.Text[line.fragmentStart : word.StartOffset+word.Length]
+		fragment.Width += word.Width
+	} else {
+		if arrfree(context.wrappedTextFragments) == 0 {
+			context.elementsCapacityExceeded()
+			return false
+		}
+		context.wrappedTextFragments = arradd(context.wrappedTextFragments, wrappedTextFragment{
+			Line:   span.Text[word.StartOffset : word.StartOffset+word.Length],
+			Span:   spanIndex,
+			X:      line.width,
+			Width:  word.Width,
+			offset: span.offset + word.StartOffset,
+			spaces: line.spaces,
+		})
+		if len(fragments) == 0 {
+			line.offset = span.offset + word.StartOffset
+		}
+		line.fragmentStart = word.StartOffset
+	}
+	line.width += word.Width
+	line.height = max(line.height, span.Height)
+	line.ascent = max(line.ascent, span.Ascent)
+	line.descent = max(line.descent, span.Descent)
+	line.lastWord = word
+	return true
+}
+
+// addRichTextLine adds the line to the wrapped lines of the element and starts a new one.
+// The line is justified to fill justifyWidth if it is non-zero and the paragraph is justified.
+func (context *Context) addRichTextLine(textElementData *TextElementData, config *TextElementConfig, line *richTextLine, justifyWidth floatn) {
+	fragments := line.fragments(context)
+	width := line.width
+	if len(fragments) > 0 {
+		// Trailing space of the last word on the line is not rendered.
+		last := &fragments[len(fragments)-1]
+		last.Line = last.Line[:len(last.Line)-int(line.lastWord.SpaceLength)]
+		last.Width -= line.lastWord.SpaceWidth
+		width -= line.lastWord.SpaceWidth
+	}
+	var wordSpacing floatn
+	if config.TextAlignment == TextAlignJustify && justifyWidth > 0 && line.spaces > 0 && width < justifyWidth {
+		wordSpacing = (justifyWidth - width) / floatn(line.spaces)
+		for i := range fragments {
+			fragment := &fragments[i]
+			spacesAfter := line.spaces
+			if i+1 < len(fragments) {
+				spacesAfter = fragments[i+1].spaces
+			}
+			// Spaces between this fragment and the next are rendered at the end of this fragment.
+			fragment.X += wordSpacing * floatn(fragment.spaces)
+			fragment.Width += wordSpacing * floatn(spacesAfter-fragment.spaces)
+		}
+		width = justifyWidth
+	}
+	naturalHeight := max(line.height, line.ascent+line.descent)
+	height := naturalHeight
+	if config.LineHeight > 0 {
+		height = floatn(config.LineHeight)
+	}
+	context.addWrappedTextLine(textElementData, WrappedTextLine{
+		Dimensions:    Dimensions{Width: width, Height: height},
+		WordSpacing:   wordSpacing,
+		fragments:     fragments,
+		naturalHeight: naturalHeight,
+		ascent:        line.ascent,
+		offset:        line.offset,
+	})
+	line.reset(context)
+}
+
+// addRichTextRenderCommands emits a text render command for every fragment of the wrapped lines of a rich text element,
+// split into runs of a single direction in bidirectional text.
+func (context *Context) addRichTextRenderCommands(element *LayoutElement, boundingBox BoundingBox, config *TextElementConfig, zIndex int16) {
+	textElementData := element.textElementData
+	tl := textLayout{data: textElementData, config: config, boundingBox: boundingBox}
+	tl.bidi = context.resolveBidi(textElementData)
+	var yPosition floatn
+	var fragmentIndex uintn
+	for lineIndex := range textElementData.WrappedLines {
+		wrappedLine := &textElementData.WrappedLines[lineIndex]
+		lineX := context.lineX(&tl, wrappedLine)
+		// Center the natural height of the line within the line height, then align fragments on their baseline.
+		baseline := yPosition + (wrappedLine.Dimensions.Height-wrappedLine.naturalHeight)/2 + wrappedLine.ascent
+		for _, run := range context.textLineRuns(&tl, wrappedLine) {
+			span := &textElementData.spans[run.span]
+			context.addRenderCommand(RenderCommand{
+				BoundingBox: BoundingBox{
+					Vector2:    Vector2{X: lineX + run.x, Y: boundingBox.Y + baseline - span.Ascent},
+					Dimensions: Dimensions{Width: run.width, Height: span.Height},
+				},
+				RenderData: storeRenderData(&context.textRenderData, TextRenderData{
+					Contents:      unsafeStrslice(run.text),
+					TextColor:     span.Config.TextColor,
+					FontID:        span.Config.FontID,
+					FontSize:      span.Config.FontSize,
+					LetterSpacing: span.Config.LetterSpacing,
+					LineHeight:    config.LineHeight,
+					WordSpacing:   wrappedLine.WordSpacing,
+					RightToLeft:   run.rightToLeft,
+				}),
+				UserData:    span.UserData,
+				ID:          hashNumber(fragmentIndex, element.ID).ID,
+				Zindex:      zIndex,
+				CommandType: RenderCommandTypeText,
+			})
+			fragmentIndex++
+		}
+		yPosition += wrappedLine.Dimensions.Height
+		if !context.DisableCulling && (boundingBox.Y+yPosition > context.LayoutDimensions.Height) {
+			break
+		}
+	}
+}
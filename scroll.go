@@ -0,0 +1,167 @@
+package glay
+
+const (
+	scrollMomentumDecay     = 0.95
+	scrollMomentumThreshold = 0.1
+	scrollWheelMultiplier   = 10
+)
+
+// UpdateScrollContainers updates the scroll position of all scroll containers (elements with a
+// ClipElementConfig) declared in the last layout. It should be called once per frame after SetPointerState.
+//
+// If enableDragScrolling is true, pressing and dragging the pointer over a scroll container scrolls it
+// as on a touch device and releasing it imparts momentum. wheelDelta is applied to the innermost
+// scroll container under the pointer. deltaTime is the time in seconds since the last call.
+// Scroll containers that were not declared in the last layout are discarded.
+func (context *Context) UpdateScrollContainers(enableDragScrolling bool, wheelDelta Vector2, deltaTime float32) {
+	pointerActive := enableDragScrolling && (context.PointerInfo.State == PointerDataPressed || context.PointerInfo.State == PointerDataPressedThisFrame)
+	// Don't apply scroll events to ancestors of the inner element.
+	highestPriorityElementIndex := intn(-1)
+	var highestPriorityScrollData *scrollContainerDataInternal
+	for i := intn(0); i < arrlen(context.scrollContainerDatas); i++ {
+		scrollData := &context.scrollContainerDatas[i]
+		if !scrollData.OpenThisFrame {
+			context.scrollContainerDatas = arrremoveswapback(context.scrollContainerDatas, i)
+			i--
+			continue
+		}
+		scrollData.OpenThisFrame = false
+		if context.HashMapItem(scrollData.ElementID) == nil {
+			// Element isn't rendered this frame but scroll offset has been retained.
+			context.scrollContainerDatas = arrremoveswapback(context.scrollContainerDatas, i)
+			i--
+			continue
+		}
+
+		// Touch/click is released.
+		if !pointerActive && scrollData.PointerScrollActive {
+			xDiff := scrollData.ScrollPosition.X - scrollData.ScrollOrigin.X
+			if xDiff < -10 || xDiff > 10 {
+				scrollData.ScrollMomentum.X = xDiff / (scrollData.MomentumTime * 25)
+			}
+			yDiff := scrollData.ScrollPosition.Y - scrollData.ScrollOrigin.Y
+			if yDiff < -10 || yDiff > 10 {
+				scrollData.ScrollMomentum.Y = yDiff / (scrollData.MomentumTime * 25)
+			}
+			scrollData.PointerScrollActive = false
+			scrollData.PointerOrigin = Vector2{}
+			scrollData.ScrollOrigin = Vector2{}
+			scrollData.MomentumTime = 0
+		}
+
+		// Apply existing momentum.
+		scrollOccurred := wheelDelta != (Vector2{})
+		scrollData.ScrollPosition.X += scrollData.ScrollMomentum.X
+		scrollData.ScrollMomentum.X *= scrollMomentumDecay
+		if abs(scrollData.ScrollMomentum.X) < scrollMomentumThreshold || scrollOccurred {
+			scrollData.ScrollMomentum.X = 0
+		}
+		scrollData.ScrollPosition.X = clamp(-max(scrollData.ContentSize.Width-scrollData.LayoutElement.Dimensions.Width, 0), 0, scrollData.ScrollPosition.X)
+
+		scrollData.ScrollPosition.Y += scrollData.ScrollMomentum.Y
+		scrollData.ScrollMomentum.Y *= scrollMomentumDecay
+		if abs(scrollData.ScrollMomentum.Y) < scrollMomentumThreshold || scrollOccurred {
+			scrollData.ScrollMomentum.Y = 0
+		}
+		scrollData.ScrollPosition.Y = clamp(-max(scrollData.ContentSize.Height-scrollData.LayoutElement.Dimensions.Height, 0), 0, scrollData.ScrollPosition.Y)
+
+		for j := intn(0); j < arrlen(context.PointerOverIDs); j++ {
+			// PointerOverIDs is ordered parents first, so the last match is the innermost container.
+			if scrollData.LayoutElement.ID == context.PointerOverIDs[j].ID && j > highestPriorityElementIndex {
+				highestPriorityElementIndex = j
+				highestPriorityScrollData = scrollData
+			}
+		}
+	}
+	if highestPriorityScrollData == nil {
+		return
+	}
+
+	scrollData := highestPriorityScrollData
+	scrollElement := scrollData.LayoutElement
+	clipConfig := scrollElement.GetConfig(ElementConfigTypeClip).(*ClipElementConfig)
+	canScrollVertically := clipConfig.Vertical && scrollData.ContentSize.Height > scrollElement.Dimensions.Height
+	canScrollHorizontally := clipConfig.Horizontal && scrollData.ContentSize.Width > scrollElement.Dimensions.Width
+	// Handle wheel scroll.
+	if canScrollVertically {
+		scrollData.ScrollPosition.Y += wheelDelta.Y * scrollWheelMultiplier
+	}
+	if canScrollHorizontally {
+		scrollData.ScrollPosition.X += wheelDelta.X * scrollWheelMultiplier
+	}
+	// Handle click/touch scroll.
+	if pointerActive {
+		scrollData.ScrollMomentum = Vector2{}
+		if !scrollData.PointerScrollActive {
+			scrollData.PointerOrigin = context.PointerInfo.Position
+			scrollData.ScrollOrigin = scrollData.ScrollPosition
+			scrollData.PointerScrollActive = true
+		} else {
+			var scrollDelta Vector2
+			if canScrollHorizontally {
+				oldPosition := scrollData.ScrollPosition.X
+				scrollData.ScrollPosition.X = scrollData.ScrollOrigin.X + (context.PointerInfo.Position.X - scrollData.PointerOrigin.X)
+				scrollData.ScrollPosition.X = max(min(scrollData.ScrollPosition.X, 0), -(scrollData.ContentSize.Width - scrollData.BoundingBox.Width))
+				scrollDelta.X = scrollData.ScrollPosition.X - oldPosition
+			}
+			if canScrollVertically {
+				oldPosition := scrollData.ScrollPosition.Y
+				scrollData.ScrollPosition.Y = scrollData.ScrollOrigin.Y + (context.PointerInfo.Position.Y - scrollData.PointerOrigin.Y)
+				scrollData.ScrollPosition.Y = max(min(scrollData.ScrollPosition.Y, 0), -(scrollData.ContentSize.Height - scrollData.BoundingBox.Height))
+				scrollDelta.Y = scrollData.ScrollPosition.Y - oldPosition
+			}
+			if abs(scrollDelta.X) < scrollMomentumThreshold && abs(scrollDelta.Y) < scrollMomentumThreshold && scrollData.MomentumTime > 0.15 {
+				// Pointer is held still, restart momentum tracking from here.
+				scrollData.MomentumTime = 0
+				scrollData.PointerOrigin = context.PointerInfo.Position
+				scrollData.ScrollOrigin = scrollData.ScrollPosition
+			} else {
+				scrollData.MomentumTime += deltaTime
+			}
+		}
+	}
+	// Clamp any changes to scroll position to the maximum size of the contents.
+	if canScrollVertically {
+		scrollData.ScrollPosition.Y = max(min(scrollData.ScrollPosition.Y, 0), -(scrollData.ContentSize.Height - scrollElement.Dimensions.Height))
+	}
+	if canScrollHorizontally {
+		scrollData.ScrollPosition.X = max(min(scrollData.ScrollPosition.X, 0), -(scrollData.ContentSize.Width - scrollElement.Dimensions.Width))
+	}
+}
+
+// ScrollOffset returns the scroll position of the scroll container with the given ID as
+// calculated by UpdateScrollContainers. It is meant to be used as the ChildOffset of the
+// container's ClipElementConfig. A zero vector is returned for unknown containers.
+func (context *Context) ScrollOffset(id ElementID) Vector2 {
+	for i := intn(0); i < arrlen(context.scrollContainerDatas); i++ {
+		mapping := &context.scrollContainerDatas[i]
+		if mapping.ElementID == id.ID {
+			return mapping.ScrollPosition
+		}
+	}
+	return Vector2{}
+}
+
+// GetScrollContainerData returns the scroll state of the scroll container with the given ID.
+// ScrollPosition points to the container's live scroll position and may be modified
+// to scroll programmatically. Found is false if no such container was declared.
+func (context *Context) GetScrollContainerData(id ElementID) ScrollContainerData {
+	for i := intn(0); i < arrlen(context.scrollContainerDatas); i++ {
+		scrollContainerData := &context.scrollContainerDatas[i]
+		if scrollContainerData.ElementID != id.ID {
+			continue
+		}
+		clipConfig, _ := scrollContainerData.LayoutElement.GetConfig(ElementConfigTypeClip).(*ClipElementConfig)
+		if clipConfig == nil {
+			return ScrollContainerData{} // Can happen on the first frame before a scroll container is declared.
+		}
+		return ScrollContainerData{
+			ScrollPosition:            &scrollContainerData.ScrollPosition,
+			ScrollContainerDimensions: scrollContainerData.BoundingBox.Dimensions,
+			ContentDimensions:         scrollContainerData.ContentSize,
+			Config:                    *clipConfig,
+			Found:                     true,
+		}
+	}
+	return ScrollContainerData{}
+}
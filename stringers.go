@@ -1,4 +1,4 @@
-// Code generated by "stringer -linecomment -output=stringers.go -type=ElementConfigType,LayoutDirection,LayoutAlignmentX,LayoutAlignmentY,SizingType,TextElementConfigWrapMode,TextAlignment,FloatingAttachPointType,MousePointerCaptureMode,FloatingAttachToElement,RenderCommandType,Error"; DO NOT EDIT.
+// Code generated by "stringer -linecomment -output=stringers.go -type=ElementConfigType,LayoutDirection,LayoutAlignmentX,LayoutAlignmentY,SelfAlignment,LayoutDistribution,SizingType,TextElementConfigWrapMode,TextAlignment,TextOverflow,FloatingAttachPointType,MousePointerCaptureMode,FloatingAttachToElement,RenderCommandType,Error,WarningKind"; DO NOT EDIT.
 
 package glay
 
@@ -35,11 +35,12 @@ func _() {
 	var x [1]struct{}
 	_ = x[LeftToRight-0]
 	_ = x[TopToBottom-1]
+	_ = x[Grid-2]
 }
 
-const _LayoutDirection_name = "left to righttop to bottom"
+const _LayoutDirection_name = "left to righttop to bottomgrid"
 
-var _LayoutDirection_index = [...]uint8{0, 13, 26}
+var _LayoutDirection_index = [...]uint8{0, 13, 26, 30}
 
 func (i LayoutDirection) String() string {
 	if i >= LayoutDirection(len(_LayoutDirection_index)-1) {
@@ -73,11 +74,12 @@ func _() {
 	_ = x[AlignYTop-0]
 	_ = x[AlignYBottom-1]
 	_ = x[AlignYCenter-2]
+	_ = x[AlignYBaseline-3]
 }
 
-const _LayoutAlignmentY_name = "align y topalign y bottomalign y center"
+const _LayoutAlignmentY_name = "align y topalign y bottomalign y centeralign y baseline"
 
-var _LayoutAlignmentY_index = [...]uint8{0, 11, 25, 39}
+var _LayoutAlignmentY_index = [...]uint8{0, 11, 25, 39, 57}
 
 func (i LayoutAlignmentY) String() string {
 	if i >= LayoutAlignmentY(len(_LayoutAlignmentY_index)-1) {
@@ -85,6 +87,46 @@ func (i LayoutAlignmentY) String() string {
 	}
 	return _LayoutAlignmentY_name[_LayoutAlignmentY_index[i]:_LayoutAlignmentY_index[i+1]]
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[AlignSelfAuto-0]
+	_ = x[AlignSelfStart-1]
+	_ = x[AlignSelfCenter-2]
+	_ = x[AlignSelfEnd-3]
+}
+
+const _SelfAlignment_name = "align self autoalign self startalign self centeralign self end"
+
+var _SelfAlignment_index = [...]uint8{0, 15, 31, 48, 62}
+
+func (i SelfAlignment) String() string {
+	if i >= SelfAlignment(len(_SelfAlignment_index)-1) {
+		return "SelfAlignment(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _SelfAlignment_name[_SelfAlignment_index[i]:_SelfAlignment_index[i+1]]
+}
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[DistributeNone-0]
+	_ = x[DistributeBetween-1]
+	_ = x[DistributeAround-2]
+	_ = x[DistributeEvenly-3]
+}
+
+const _LayoutDistribution_name = "distribute nonedistribute betweendistribute arounddistribute evenly"
+
+var _LayoutDistribution_index = [...]uint8{0, 15, 33, 50, 67}
+
+func (i LayoutDistribution) String() string {
+	if i >= LayoutDistribution(len(_LayoutDistribution_index)-1) {
+		return "LayoutDistribution(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _LayoutDistribution_name[_LayoutDistribution_index[i]:_LayoutDistribution_index[i+1]]
+}
 func _() {
 	// An "invalid array index" compiler error signifies that the constant values have changed.
 	// Re-run the stringer command to generate them again.
@@ -131,11 +173,12 @@ func _() {
 	_ = x[TextAlignLeft-0]
 	_ = x[TextAlignCenter-1]
 	_ = x[TextAlignRight-2]
+	_ = x[TextAlignJustify-3]
 }
 
-const _TextAlignment_name = "text align lefttext align centertext align right"
+const _TextAlignment_name = "text align lefttext align centertext align righttext align justify"
 
-var _TextAlignment_index = [...]uint8{0, 15, 32, 48}
+var _TextAlignment_index = [...]uint8{0, 15, 32, 48, 66}
 
 func (i TextAlignment) String() string {
 	if i >= TextAlignment(len(_TextAlignment_index)-1) {
@@ -143,6 +186,25 @@ func (i TextAlignment) String() string {
 	}
 	return _TextAlignment_name[_TextAlignment_index[i]:_TextAlignment_index[i+1]]
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[TextOverflowClip-0]
+	_ = x[TextOverflowEllipsis-1]
+	_ = x[TextOverflowEllipsisMiddle-2]
+}
+
+const _TextOverflow_name = "text overflow cliptext overflow ellipsistext overflow ellipsis middle"
+
+var _TextOverflow_index = [...]uint8{0, 18, 40, 69}
+
+func (i TextOverflow) String() string {
+	if i >= TextOverflow(len(_TextOverflow_index)-1) {
+		return "TextOverflow(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _TextOverflow_name[_TextOverflow_index[i]:_TextOverflow_index[i+1]]
+}
 func _() {
 	// An "invalid array index" compiler error signifies that the constant values have changed.
 	// Re-run the stringer command to generate them again.
@@ -238,11 +300,12 @@ func _() {
 	_ = x[ErrPercentageOver1-1]
 	_ = x[ErrFloatingContainerParentNotFound-2]
 	_ = x[ErrElementsCapacityExceeded-3]
+	_ = x[ErrTextMeasurementCapacityExceeded-4]
 }
 
-const _Error_name = "a text measurement function wasn't provided using Clay_SetMeasureTextFunction(), or the provided function was nullan element was configured with CLAY_SIZING_PERCENT, but the provided percentage value was over 1.0. Clay expects a value between 0 and 1, i.e. 20% is 0.2a floating element was declared with a parentId, but no element with that ID was foundClay ran out of capacity while attempting to create render commands. This is usually caused by a large amount of wrapping text elements while close to the max element capacity. Try using Clay_SetMaxElementCount() with a higher value"
+const _Error_name = "a text measurement function wasn't provided using Clay_SetMeasureTextFunction(), or the provided function was nullan element was configured with CLAY_SIZING_PERCENT, but the provided percentage value was over 1.0. Clay expects a value between 0 and 1, i.e. 20% is 0.2a floating element was declared with a parentId, but no element with that ID was foundClay ran out of capacity while attempting to create render commands. This is usually caused by a large amount of wrapping text elements while close to the max element capacity. Try using Clay_SetMaxElementCount() with a higher valueClay ran out of capacity in its internal text measurement cache. Try using Clay_SetMaxMeasureTextCacheWordCount() with a higher value"
 
-var _Error_index = [...]uint16{0, 114, 267, 353, 585}
+var _Error_index = [...]uint16{0, 114, 267, 353, 585, 718}
 
 func (i Error) String() string {
 	if i >= Error(len(_Error_index)-1) {
@@ -250,3 +313,25 @@ func (i Error) String() string {
 	}
 	return _Error_name[_Error_index[i]:_Error_index[i+1]]
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[WarningDuplicateID-0]
+	_ = x[WarningFloatingParentNotFound-1]
+	_ = x[WarningPercentageOver1-2]
+	_ = x[WarningElementsCapacityExceeded-3]
+	_ = x[WarningTextMeasurementCacheExhausted-4]
+	_ = x[WarningBidiTextTooLong-5]
+}
+
+const _WarningKind_name = "an element with this ID was already declared in the current layouta floating element was declared with a parentId, but no element with that ID was foundan element was configured with SizingPercent, but the provided percentage value was over 1.0ran out of element capacity, try increasing MaxElementCountran out of text measurement cache capacity, try increasing MaxMeasureTextCacheWordCounttext is too long to be reordered for display and is displayed in logical order"
+
+var _WarningKind_index = [...]uint16{0, 66, 152, 244, 303, 390, 468}
+
+func (i WarningKind) String() string {
+	if i >= WarningKind(len(_WarningKind_index)-1) {
+		return "WarningKind(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _WarningKind_name[_WarningKind_index[i]:_WarningKind_index[i+1]]
+}
@@ -1,60 +1,99 @@
 package glay
 
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+)
+
 // Text adds a text element as a child of the currently open element.
-// A MeasureTextFunction must be set on the Context before calling Text.
+// A text measurement function or BatchTextMeasurer must be set on the Context before calling Text.
 func (context *Context) Text(text string, config *TextElementConfig) error {
 	return context.openTextElement(text, config)
 }
 
 func (context *Context) openTextElement(text string, config *TextElementConfig) error {
-	if arrlen(context.LayoutElements) == arrcap(context.LayoutElements)-1 || context.warnMaxElementsExceeded() {
+	if arrfree(context.LayoutElements) == 0 || arrfree(context.ElementConfigs) == 0 || arrfree(context.TextElementConfigs) == 0 || context.warnMaxElementsExceeded() {
+		context.elementsCapacityExceeded()
 		return ErrElementsCapacityExceeded
 	}
+	// Store a copy of the configuration so that the caller's value does not escape.
+	context.TextElementConfigs = arradd(context.TextElementConfigs, *config)
+	textConfig := arrlast(context.TextElementConfigs)
+	textMeasured := context.measureTextCached(text, textConfig)
+	if textMeasured == nil {
+		context.TextElementConfigs = context.TextElementConfigs[:arrlen(context.TextElementConfigs)-1]
+		return ErrTextMeasurementCapacityExceeded
+	}
+	var textHeight floatn
+	if textConfig.LineHeight > 0 {
+		textHeight = floatn(textConfig.LineHeight)
+	} else {
+		textHeight = textMeasured.unwrappedDimensions.Height
+	}
+	minDimensions := Dimensions{Width: textMeasured.minWidth, Height: textHeight}
+	if textConfig.Overflow != TextOverflowClip {
+		minDimensions.Width = 0 // Shortened with an ellipsis instead.
+	}
+	return context.addTextElement(textConfig, TextElementData{
+		Text:                text,
+		PreferredDimensions: textMeasured.unwrappedDimensions,
+		// Lines are centered within the line height.
+		baseline:    (textHeight-textMeasured.unwrappedDimensions.Height)/2 + textMeasured.ascent,
+		rightToLeft: textMeasured.rightToLeft,
+	}, Dimensions{Width: textMeasured.unwrappedDimensions.Width, Height: textHeight}, minDimensions)
+}
+
+// addTextElement adds a measured text element as a child of the open element.
+func (context *Context) addTextElement(textConfig *TextElementConfig, data TextElementData, dimensions, minDimensions Dimensions) error {
 	parentElement := context.openLayoutElement()
 
 	context.LayoutElements = arradd(context.LayoutElements, LayoutElement{})
 	textElement := arrlast(context.LayoutElements)
 	if arrlen(context.openClipElementStack) > 0 {
-		context.LayoutElementClipElementIDs[len(context.LayoutElements)-1] = context.openClipElementStack[arrlen(context.openClipElementStack)-1]
+		context.LayoutElementClipElementIDs = arradd(context.LayoutElementClipElementIDs, context.openClipElementStack[arrlen(context.openClipElementStack)-1])
 	} else {
-		context.LayoutElementClipElementIDs[len(context.LayoutElements)-1] = 0
+		context.LayoutElementClipElementIDs = arradd(context.LayoutElementClipElementIDs, 0)
 	}
 	context.LayoutElementChildrenBuffer = arradd(context.LayoutElementChildrenBuffer, arrlen(context.LayoutElements)-1)
-	textMeasured := context.measureTextCached(text, config)
 
-	elementID := hashNumber(uintn(len(parentElement.Children())), parentElement.ID)
+	elementID := hashNumber(uintn(parentElement.childCount), parentElement.ID)
 	textElement.ID = elementID.ID
 	context.AddHashMapItem(elementID, textElement, 0)
 	context.LayoutElementIDStrings = arradd(context.LayoutElementIDStrings, elementID.StringID)
 
-	var textHeight floatn
-	if config.LineHeight > 0 {
-		textHeight = floatn(config.LineHeight)
-	} else {
-		textHeight = textMeasured.unwrappedDimensions.Height
-	}
-	textElement.Dimensions = Dimensions{Width: textMeasured.unwrappedDimensions.Width, Height: textHeight}
-	textElement.MinDimensions = Dimensions{Width: textMeasured.minWidth, Height: textHeight}
+	textElement.Dimensions = dimensions
+	textElement.MinDimensions = minDimensions
 
-	context.TextElementData = arradd(context.TextElementData, TextElementData{
-		Text:                text,
-		PreferredDimensions: textMeasured.unwrappedDimensions,
-		ElementIndex:        arrlen(context.LayoutElements) - 1,
-	})
-	textElement.ChildrenOrTextContent = arrlast(context.TextElementData)
+	data.ElementIndex = arrlen(context.LayoutElements) - 1
+	context.TextElementData = arradd(context.TextElementData, data)
+	textElement.textElementData = arrlast(context.TextElementData)
+	textElement.ChildrenOrTextContent = textElement.textElementData
 
 	context.ElementConfigs = arradd(context.ElementConfigs, ElementConfig{
 		Type:   ElementConfigTypeText,
-		Config: config,
+		Config: textConfig,
 	})
 	textElement.ElementConfigs = context.ElementConfigs[len(context.ElementConfigs)-1:]
 	textElement.LayoutConfig = &defaultLayoutConfig
 
-	children := parentElement.Children()
-	parentElement.ChildrenOrTextContent = append(children, -1)
+	parentElement.childCount++
 	return nil
 }
 
+// lookupTextCached is measureTextCached for text already measured when its element was
+// declared, whose lookup is not counted again in the cache statistics.
+func (context *Context) lookupTextCached(text string, config *TextElementConfig) *measureTextCacheItem {
+	stats := context.textCacheStats
+	mtci := context.measureTextCached(text, config)
+	context.textCacheStats = stats
+	return mtci
+}
+
+// measureTextCached returns the cached measurement of text, measuring it if needed.
+// It returns nil if the measurement cache has no capacity left.
 func (context *Context) measureTextCached(text string, config *TextElementConfig) *measureTextCacheItem {
 	id := hashTextWithConfig(text, config)
 	hashbucket := id % (uint32(context.MaxMeasureTextCacheWordCount) / 32)
@@ -64,33 +103,28 @@ func (context *Context) measureTextCached(text string, config *TextElementConfig
 		hashEntry := &context.measureTextHashMapInternal[elementIndex]
 		if hashEntry.ID == uintn(id) {
 			hashEntry.generation = context.Generation
+			context.textCacheStats.Hits++
 			return hashEntry
 		}
 		// This element hasn't been seen in a few frames, delete the hash map item.
-		if context.Generation-hashEntry.generation > 2 {
-			// Add all the measured words that were included in this measurement to the freelist
-			nextWordIndex := hashEntry.measureWordsStartIndex
-			for nextWordIndex != -1 {
-				measured := &context.measuredWords[nextWordIndex]
-				context.measuredWordsFreeList = arradd(context.measuredWordsFreeList, nextWordIndex)
-				nextWordIndex = measured.Next
-			}
-
-			nextIndex := hashEntry.nextIndex
-			context.measureTextHashMapInternal[elementIndex] = measureTextCacheItem{measureWordsStartIndex: -1}
-			context.MeasureTextHashMapInternalFreelist = arradd(context.MeasureTextHashMapInternalFreelist, elementIndex)
-			if elementIndexPrevious == 0 {
-				context.measureTextHashMap[hashbucket] = nextIndex
-			} else {
-				previousHashEntry := &context.measureTextHashMapInternal[elementIndexPrevious]
-				previousHashEntry.nextIndex = nextIndex
-			}
-			elementIndex = nextIndex
+		if context.measureTextCacheItemExpired(hashEntry) {
+			elementIndex = context.evictMeasureTextCacheItem(hashbucket, elementIndexPrevious, elementIndex)
 		} else {
 			elementIndexPrevious = elementIndex
 			elementIndex = hashEntry.nextIndex
 		}
 	}
+	context.textCacheStats.Misses++
+	if context.sweptGeneration != context.Generation &&
+		((len(context.MeasureTextHashMapInternalFreelist) == 0 && arrfree(context.measureTextHashMapInternal) == 0) ||
+			arrfree(context.measuredWords)+arrlen(context.measuredWordsFreeList) < 2*intn(len(text)+1)) {
+		// Running out of capacity, evict expired items of all buckets rather than only the probed one.
+		context.sweepMeasureTextCache()
+		elementIndexPrevious = 0
+		for elementIndex := context.measureTextHashMap[hashbucket]; elementIndex != 0; elementIndex = context.measureTextHashMapInternal[elementIndex].nextIndex {
+			elementIndexPrevious = elementIndex
+		}
+	}
 
 	var newItemIndex intn
 	newCacheItem := measureTextCacheItem{measureWordsStartIndex: -1, ID: id, generation: context.Generation}
@@ -101,63 +135,67 @@ func (context *Context) measureTextCached(text string, config *TextElementConfig
 		measured = &context.measureTextHashMapInternal[newItemIndex]
 	} else {
 		if arrfree(context.measureTextHashMapInternal) == 0 {
-			panic("clay ran out of capacity during text element measurement")
+			context.textMeasureCacheExceeded()
+			return nil
 		}
 		context.measureTextHashMapInternal = arradd(context.measureTextHashMapInternal, newCacheItem)
 		newItemIndex = arrlen(context.measureTextHashMapInternal) - 1
 		measured = &context.measureTextHashMapInternal[newItemIndex]
 	}
-	var start, end intn
+	if context.BatchTextMeasurer != nil {
+		context.queueTextBatch(text, config)
+	}
 	var lineWidth, measuredWidth, measuredHeight floatn
-	spaceWidth := context.measureSpaceWidth(config)
+	spaceWidth := context.measureTextPart(" ", config).Width
 	tempWord := measuredWord{Next: -1}
 	prevWord := &tempWord
-	for end < intn(len(text)) {
-		if arrfree(context.measuredWords) == 0 {
-			panic("clay run out of space in internal text measurement cache")
-		}
-		current := text[end]
-		if current == ' ' || current == '\n' {
-			length := end - start
-			var dimensions Dimensions
-			if length > 0 {
-				dimensions = context.measureTextRaw(text[start:], config)
-			}
-			measured.minWidth = max(dimensions.Width, measured.minWidth)
-			measuredHeight = max(measuredHeight, dimensions.Height)
-			if current == ' ' {
-				dimensions.Width += spaceWidth
-				word := measuredWord{StartOffset: start, Length: length + 1, Width: dimensions.Width, Next: -1}
-				prevWord = context.addMeasuredWord(word, prevWord)
-				lineWidth += dimensions.Width
-			}
-			if current == '\n' {
-				if length > 0 {
-					word := measuredWord{StartOffset: start, Length: length, Width: dimensions.Width, Next: -1}
-					prevWord = context.addMeasuredWord(word, prevWord)
-				}
-				word := measuredWord{StartOffset: end + 1, Next: -1}
-				prevWord = context.addMeasuredWord(word, prevWord)
-				lineWidth += dimensions.Width
-				measuredWidth = max(lineWidth, measuredWidth)
-				measured.containsNewlines = true
-				lineWidth = 0
-			}
-			start = end + 1
+	// Words end at the line break opportunities of the Unicode Line Breaking Algorithm.
+	lb := lineBreaker{text: text}
+	for start := 0; start < len(text); {
+		// A segment adds up to two words: its text and a forced line break.
+		if arrfree(context.measuredWords)+arrlen(context.measuredWordsFreeList) < 2 {
+			// Release the partial measurement, it was not yet linked into its hash bucket.
+			context.freeMeasuredWords(tempWord.Next)
+			context.measureTextHashMapInternal[newItemIndex] = measureTextCacheItem{measureWordsStartIndex: -1}
+			context.MeasureTextHashMapInternalFreelist = arradd(context.MeasureTextHashMapInternalFreelist, newItemIndex)
+			context.textMeasureCacheExceeded()
+			return nil
+		}
+		segment := nextTextSegment(&lb, start)
+		var dimensions Dimensions
+		if segment.wordEnd > start {
+			metrics := context.measureTextPart(text[start:segment.wordEnd], config)
+			dimensions = metrics.Dimensions
+			measured.ascent = max(measured.ascent, metrics.Ascent)
+			measured.descent = max(measured.descent, metrics.Descent)
 		}
-		end++
-	}
-	if end-start > 0 {
-		dimensions := context.measureTextRaw(text[start:end], config)
-		word := measuredWord{StartOffset: start, Length: end - start, Width: dimensions.Width, Next: -1}
-		_ = context.addMeasuredWord(word, prevWord)
-		lineWidth += dimensions.Width
-		measuredHeight = max(measuredHeight, dimensions.Height)
 		measured.minWidth = max(dimensions.Width, measured.minWidth)
+		measuredHeight = max(measuredHeight, dimensions.Height)
+		if segment.spaceEnd > start {
+			var breakWidth floatn
+			if segment.onlySpaces {
+				breakWidth = spaceWidth * floatn(segment.spaceEnd-segment.wordEnd)
+			} else {
+				breakWidth = context.measureTextPart(text[segment.wordEnd:segment.spaceEnd], config).Width
+			}
+			dimensions.Width += breakWidth
+			word := measuredWord{StartOffset: intn(start), Length: intn(segment.spaceEnd - start), Width: dimensions.Width, SpaceLength: intn(segment.spaceEnd - segment.wordEnd), SpaceWidth: breakWidth, Next: -1}
+			prevWord = context.addMeasuredWord(word, prevWord)
+			lineWidth += dimensions.Width
+		}
+		if segment.mandatory {
+			word := measuredWord{StartOffset: intn(segment.end), Next: -1}
+			prevWord = context.addMeasuredWord(word, prevWord)
+			measuredWidth = max(lineWidth, measuredWidth)
+			measured.containsNewlines = true
+			lineWidth = 0
+		}
+		start = segment.end
 	}
 	measuredWidth = max(lineWidth, measuredWidth)
 
 	measured.measureWordsStartIndex = tempWord.Next
+	measured.rightToLeft = hasRightToLeft(text)
 	measured.unwrappedDimensions.Width = measuredWidth
 	measured.unwrappedDimensions.Height = measuredHeight
 
@@ -169,12 +207,190 @@ func (context *Context) measureTextCached(text string, config *TextElementConfig
 	return measured
 }
 
-func (context *Context) measureSpaceWidth(textconfig *TextElementConfig) floatn {
-	return context.measureTextRaw(" ", textconfig).Width
+// measureTextCacheItemExpired reports whether the item was not used by the last MeasureTextCacheMaxAge layouts.
+func (context *Context) measureTextCacheItemExpired(item *measureTextCacheItem) bool {
+	maxAge := context.MeasureTextCacheMaxAge
+	if maxAge == 0 {
+		maxAge = defaultMeasureTextCacheMaxAge
+	}
+	return context.Generation-item.generation > maxAge
+}
+
+// evictMeasureTextCacheItem removes the item at elementIndex, which follows elementIndexPrevious in its
+// hash bucket, from the measurement cache and returns the index of the next item in the bucket.
+func (context *Context) evictMeasureTextCacheItem(hashbucket uint32, elementIndexPrevious, elementIndex intn) intn {
+	hashEntry := &context.measureTextHashMapInternal[elementIndex]
+	// Add all the measured words that were included in this measurement to the freelist
+	context.freeMeasuredWords(hashEntry.measureWordsStartIndex)
+
+	nextIndex := hashEntry.nextIndex
+	context.measureTextHashMapInternal[elementIndex] = measureTextCacheItem{measureWordsStartIndex: -1}
+	context.MeasureTextHashMapInternalFreelist = arradd(context.MeasureTextHashMapInternalFreelist, elementIndex)
+	if elementIndexPrevious == 0 {
+		context.measureTextHashMap[hashbucket] = nextIndex
+	} else {
+		context.measureTextHashMapInternal[elementIndexPrevious].nextIndex = nextIndex
+	}
+	context.textCacheStats.Evictions++
+	return nextIndex
+}
+
+// sweepMeasureTextCache evicts the expired items of every hash bucket. It runs at most once per layout.
+func (context *Context) sweepMeasureTextCache() {
+	context.sweptGeneration = context.Generation
+	for hashbucket := range context.measureTextHashMap {
+		var elementIndexPrevious intn
+		elementIndex := context.measureTextHashMap[hashbucket]
+		for elementIndex != 0 {
+			if context.measureTextCacheItemExpired(&context.measureTextHashMapInternal[elementIndex]) {
+				elementIndex = context.evictMeasureTextCacheItem(uint32(hashbucket), elementIndexPrevious, elementIndex)
+			} else {
+				elementIndexPrevious = elementIndex
+				elementIndex = context.measureTextHashMapInternal[elementIndex].nextIndex
+			}
+		}
+	}
+}
+
+// ResetMeasureTextCache discards all cached text measurements and resets the statistics
+// returned by [Context.TextCacheStats]. Call it when fonts change so that text is measured
+// again by the MeasureTextFunction. It must be called between EndLayout and the next BeginLayout,
+// text elements declared before it during a layout refer to the measurements it discards.
+func (context *Context) ResetMeasureTextCache() {
+	arrmemset(context.measureTextHashMap, 0)
+	context.measureTextHashMapInternal = context.measureTextHashMapInternal[:1] // Reserve the 0 value to mean "no next element"
+	context.MeasureTextHashMapInternalFreelist = context.MeasureTextHashMapInternalFreelist[:0]
+	context.measuredWords = context.measuredWords[:0]
+	context.measuredWordsFreeList = context.measuredWordsFreeList[:0]
+	arrmemset(context.textRunWidths, textRunWidth{})
+	context.textCacheStats = TextCacheStats{}
+}
+
+// TextCacheStats returns the usage of the text measurement cache since the Context was
+// initialized or the cache was last reset.
+func (context *Context) TextCacheStats() TextCacheStats {
+	stats := context.textCacheStats
+	stats.Entries = arrlen(context.measureTextHashMapInternal) - 1 - arrlen(context.MeasureTextHashMapInternalFreelist)
+	stats.WordSlotsUsed = arrlen(context.measuredWords) - arrlen(context.measuredWordsFreeList)
+	stats.WordSlotsCapacity = arrcap(context.measuredWords)
+	stats.FreeListLength = arrlen(context.measuredWordsFreeList)
+	return stats
+}
+
+// textSegment is the text between two line break opportunities, measured as a word.
+type textSegment struct {
+	end        int  // Offset of the next line break opportunity.
+	spaceEnd   int  // End of the segment before its mandatory line break, if any.
+	wordEnd    int  // End of the segment before its trailing breaking spaces.
+	mandatory  bool // The segment ends with a mandatory line break.
+	onlySpaces bool // The trailing breaking spaces are all U+0020 SPACE.
+}
+
+// nextTextSegment returns the segment of the text of lb starting at start.
+func nextTextSegment(lb *lineBreaker, start int) textSegment {
+	text := lb.text
+	end, mandatory := lb.next()
+	spaceEnd := end
+	if mandatory {
+		_, size := utf8.DecodeLastRuneInString(text[start:end])
+		if strings.HasSuffix(text[start:end], "\r\n") {
+			size = 2
+		}
+		spaceEnd -= size
+	}
+	// Trailing spaces are not rendered at the end of a wrapped line.
+	wordEnd, onlySpaces := spaceEnd, true
+	for wordEnd > start {
+		r, size := utf8.DecodeLastRuneInString(text[start:wordEnd])
+		if !isBreakingSpace(r) {
+			break
+		}
+		onlySpaces = onlySpaces && r == ' '
+		wordEnd -= size
+	}
+	return textSegment{end: end, spaceEnd: spaceEnd, wordEnd: wordEnd, mandatory: mandatory, onlySpaces: onlySpaces}
+}
+
+// queueTextBatch measures all the parts of text that measureTextCached measures with one call to
+// the BatchTextMeasurer. The results are returned by measureTextPart in the same order.
+func (context *Context) queueTextBatch(text string, config *TextElementConfig) {
+	batch := &context.textBatch
+	batch.texts = append(batch.texts[:0], " ")
+	lb := lineBreaker{text: text}
+	for start := 0; start < len(text); {
+		segment := nextTextSegment(&lb, start)
+		if segment.wordEnd > start {
+			batch.texts = append(batch.texts, text[start:segment.wordEnd])
+		}
+		if segment.spaceEnd > segment.wordEnd && !segment.onlySpaces {
+			batch.texts = append(batch.texts, text[segment.wordEnd:segment.spaceEnd])
+		}
+		start = segment.end
+	}
+	batch.metrics = slices.Grow(batch.metrics[:0], len(batch.texts))[:len(batch.texts)]
+	clear(batch.metrics)
+	if measurer, ok := context.BatchTextMeasurer.(BatchTextMetricsMeasurer); ok {
+		measurer.MeasureTextMetricsBatch(batch.texts, config, batch.metrics)
+	} else {
+		batch.dimensions = slices.Grow(batch.dimensions[:0], len(batch.texts))[:len(batch.texts)]
+		clear(batch.dimensions)
+		context.BatchTextMeasurer.MeasureTextBatch(batch.texts, config, batch.dimensions)
+		for i, dimensions := range batch.dimensions {
+			batch.metrics[i] = TextMetrics{Dimensions: dimensions, Ascent: dimensions.Height}
+		}
+	}
+	clear(batch.texts) // Do not retain the text of the caller.
+	batch.next = 0
+}
+
+// measureTextPart returns the metrics of part of a text being measured by measureTextCached,
+// taken from the results of the BatchTextMeasurer if one is set.
+func (context *Context) measureTextPart(text string, config *TextElementConfig) TextMetrics {
+	if context.BatchTextMeasurer == nil {
+		return context.measureTextMetrics(text, config)
+	}
+	batch := &context.textBatch
+	batch.next++
+	return batch.metrics[batch.next-1]
+}
+
+// measureTextMetrics measures text with the MeasureTextMetricsFunction if set. Otherwise the
+// baseline of the text is at its bottom.
+func (context *Context) measureTextMetrics(text string, config *TextElementConfig) TextMetrics {
+	if context.MeasureTextMetricsFunction != nil {
+		return context.MeasureTextMetricsFunction(text, config, context.MeasureTextUserData)
+	}
+	dimensions := context.measureTextRaw(text, config)
+	return TextMetrics{Dimensions: dimensions, Ascent: dimensions.Height}
+}
+
+// freeMeasuredWords adds the linked list of measured words starting at wordIndex to the freelist.
+func (context *Context) freeMeasuredWords(wordIndex intn) {
+	for wordIndex != -1 {
+		context.measuredWordsFreeList = arradd(context.measuredWordsFreeList, wordIndex)
+		wordIndex = context.measuredWords[wordIndex].Next
+	}
+}
+
+func (context *Context) textMeasureCacheExceeded() {
+	if !context.booleanWarnings.maxTextMeasureCacheExceeded {
+		context.addWarning(WarningTextMeasurementCacheExhausted, "")
+	}
+	context.booleanWarnings.maxTextMeasureCacheExceeded = true
 }
 
 func (context *Context) measureTextRaw(text string, textconfig *TextElementConfig) Dimensions {
 	if context.MeasureTextFunction == nil {
+		if context.MeasureTextMetricsFunction != nil {
+			return context.MeasureTextMetricsFunction(text, textconfig, context.MeasureTextUserData).Dimensions
+		}
+		if context.BatchTextMeasurer != nil {
+			batch := &context.textBatch
+			batch.single[0] = text
+			context.BatchTextMeasurer.MeasureTextBatch(batch.single[:], textconfig, batch.singleDimensions[:])
+			batch.single[0] = ""
+			return batch.singleDimensions[0]
+		}
 		context.logerr("measuretextfunc==nil")
 		return Dimensions{}
 	}
@@ -213,8 +429,169 @@ func hashTextWithConfig(text string, config *TextElementConfig) (hash uint32) {
 	hash += (hash << 10)
 	hash ^= (hash >> 6)
 
+	hash += uint32(config.LineHeight)
+	hash += (hash << 10)
+	hash ^= (hash >> 6)
+
+	hash += uint32(config.WrapMode)
+	hash += (hash << 10)
+	hash ^= (hash >> 6)
+
 	hash += (hash << 3)
 	hash ^= (hash >> 11)
 	hash += (hash << 15)
 	return hash + 1
 }
+
+// isBreakingSpace reports whether r is whitespace after which text may wrap.
+// Non-breaking spaces such as U+00A0 NO-BREAK SPACE are measured as part of their word.
+func isBreakingSpace(r rune) bool {
+	switch r {
+	case '\u00a0', '\u2007', '\u202f':
+		return false
+	}
+	return !isLineSeparator(r) && unicode.IsSpace(r)
+}
+
+// isLineSeparator reports whether r forces a line break, as '\n' and U+2028 LINE SEPARATOR do.
+func isLineSeparator(r rune) bool {
+	switch r {
+	case '\n', '\r', '\v', '\f', '\u0085', '\u2028', '\u2029':
+		return true
+	}
+	return false
+}
+
+// truncateWrappedLines limits the wrapped lines of a text element to config.MaxLines and
+// shortens its final line to width according to config.Overflow.
+func (context *Context) truncateWrappedLines(textElementData *TextElementData, config *TextElementConfig, width floatn) {
+	lines := textElementData.WrappedLines
+	truncated := config.MaxLines > 0 && len(lines) > int(config.MaxLines)
+	if truncated {
+		// The lines of this element are the last ones added.
+		context.WrappedTextLines = context.WrappedTextLines[:len(context.WrappedTextLines)-len(lines)+int(config.MaxLines)]
+		lines = lines[:config.MaxLines]
+		textElementData.WrappedLines = lines
+	}
+	if config.Overflow == TextOverflowClip || len(lines) == 0 || len(textElementData.spans) > 0 {
+		return
+	}
+	last := &lines[len(lines)-1]
+	if !truncated && last.Dimensions.Width <= width {
+		return
+	}
+	last.WordSpacing = 0 // The shortened line is not justified.
+	ellipsisWidth := context.measureTextRaw(ellipsis, config).Width
+	available := width - ellipsisWidth
+	var prefix, suffix string
+	switch config.Overflow {
+	case TextOverflowEllipsis:
+		prefix = last.Line[:context.fitPrefix(last.Line, config, available)]
+	case TextOverflowEllipsisMiddle:
+		// Keep the end of the paragraph, which may be on the lines that were cut.
+		rest := textElementData.Text[last.offset:]
+		if end := strings.IndexFunc(rest, func(r rune) bool { return lineBreakClass(r).isHardBreak() }); end >= 0 {
+			rest = rest[:end]
+		}
+		rest = strings.TrimRightFunc(rest, unicode.IsSpace)
+		if measured := context.measureTextRaw(rest, config); measured.Width <= width {
+			last.Line, last.Dimensions.Width = rest, measured.Width
+			return
+		}
+		prefix = rest[:context.fitPrefix(rest, config, available/2)]
+		available -= context.measureTextRaw(prefix, config).Width
+		suffix = rest[context.fitSuffix(rest[len(prefix):], config, available)+len(prefix):]
+	}
+	prefix = strings.TrimRightFunc(prefix, isBreakingSpace)
+	suffix = strings.TrimLeftFunc(suffix, isBreakingSpace)
+	// The parts of the shortened line are kept apart so that no string outlives the text it is cut from.
+	last.Line, last.suffix, last.ellipsis = prefix, suffix, true
+	last.ellipsisX = context.measureTextRaw(prefix, config).Width
+	last.suffixX = last.ellipsisX + ellipsisWidth
+	last.Dimensions.Width = last.suffixX + context.measureTextRaw(suffix, config).Width
+}
+
+// ellipsis is placed where text shortened by TextElementConfig.Overflow was cut.
+const ellipsis = "…"
+
+// fitPrefix returns the length of the longest prefix of text no wider than width.
+// Characters are not separated from their combining marks.
+func (context *Context) fitPrefix(text string, config *TextElementConfig, width floatn) int {
+	if context.measureTextRaw(text, config).Width <= width {
+		return len(text)
+	}
+	// Binary search with lo fitting and hi not.
+	lo, hi := 0, len(text)
+	for {
+		mid := (lo + hi) / 2
+		for mid > lo && !isCharacterBoundary(text, mid) {
+			mid--
+		}
+		if mid == lo {
+			mid = lo + 1
+			for mid < hi && !isCharacterBoundary(text, mid) {
+				mid++
+			}
+		}
+		if mid >= hi {
+			return lo
+		}
+		if context.measureTextRaw(text[:mid], config).Width <= width {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+}
+
+// fitSuffix returns the offset of the longest suffix of text no wider than width.
+// Characters are not separated from their combining marks.
+func (context *Context) fitSuffix(text string, config *TextElementConfig, width floatn) int {
+	if context.measureTextRaw(text, config).Width <= width {
+		return 0
+	}
+	// Binary search with the suffix at hi fitting and the one at lo not.
+	lo, hi := 0, len(text)
+	for {
+		mid := (lo + hi + 1) / 2
+		for mid < hi && !isCharacterBoundary(text, mid) {
+			mid++
+		}
+		if mid == hi {
+			mid = hi - 1
+			for mid > lo && !isCharacterBoundary(text, mid) {
+				mid--
+			}
+		}
+		if mid <= lo {
+			return hi
+		}
+		if context.measureTextRaw(text[mid:], config).Width <= width {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+}
+
+// isCharacterBoundary reports whether text may be cut at offset i without splitting a rune
+// or separating a combining mark from its base character.
+func isCharacterBoundary(text string, i int) bool {
+	if i <= 0 || i >= len(text) {
+		return true
+	}
+	if !utf8.RuneStart(text[i]) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(text[i:])
+	class := lineBreakClass(r)
+	return class != lbCM && class != lbZWJ
+}
+
+// stringOffset returns the offset of sub in s, which must be a substring of s.
+func stringOffset(s, sub string) int {
+	if len(sub) == 0 {
+		return len(s)
+	}
+	return int(uintptr(unsafe.Pointer(unsafe.StringData(sub))) - uintptr(unsafe.Pointer(unsafe.StringData(s))))
+}
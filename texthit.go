@@ -0,0 +1,427 @@
+package glay
+
+// TextHitTest returns the byte offset of the character boundary closest to position in the text
+// of the element with the given ID, or of its first text child. Positions above or below the text
+// hit the first or last line. The text of a rich text element is the concatenation of its spans.
+// The element must have been laid out by the last call to EndLayout, ok is false otherwise,
+// so TextHitTest must be called between EndLayout and the next BeginLayout: while a layout is
+// being declared no element is found.
+func (context *Context) TextHitTest(id ElementID, position Vector2) (byteOffset int, ok bool) {
+	tl, ok := context.textLayout(id)
+	if !ok {
+		return 0, false
+	}
+	lines := tl.data.WrappedLines
+	lineIndex, y := 0, tl.boundingBox.Y
+	for lineIndex < len(lines)-1 && position.Y >= y+lines[lineIndex].Dimensions.Height {
+		y += lines[lineIndex].Dimensions.Height
+		lineIndex++
+	}
+	line := &lines[lineIndex]
+	runs := context.textLineRuns(&tl, line)
+	if len(runs) == 0 {
+		return int(line.offset), true
+	}
+	x := context.lineX(&tl, line)
+	// Hit the last run starting left of the position, or the first run.
+	run := &runs[0]
+	for i := 1; i < len(runs) && position.X >= x+runs[i].x; i++ {
+		run = &runs[i]
+	}
+	runX := position.X - x - run.x
+	if run.rightToLeft {
+		runX = run.width - runX
+	}
+	return run.offset + context.textRunHit(run, runX), true
+}
+
+// TextCaretRect returns the zero width rectangle of a caret placed before the byte offset
+// in the text of the element with the given ID, or of its first text child. A caret at the
+// offset where a line wraps is placed at the start of the next line.
+// The element must have been laid out by the last call to EndLayout, so TextCaretRect must be
+// called between EndLayout and the next BeginLayout. The rectangle is empty otherwise.
+func (context *Context) TextCaretRect(id ElementID, byteOffset int) BoundingBox {
+	tl, ok := context.textLayout(id)
+	if !ok {
+		return BoundingBox{}
+	}
+	lineIndex, y := tl.lineAt(byteOffset)
+	line := &tl.data.WrappedLines[lineIndex]
+	return BoundingBox{
+		Vector2:    Vector2{X: context.textCaretX(&tl, line, byteOffset), Y: y},
+		Dimensions: Dimensions{Height: line.Dimensions.Height},
+	}
+}
+
+// TextSelectionRects appends to rects the highlight rectangles of the text between the byte offsets
+// start and end, one for each wrapped line of the selection, and returns the extended slice.
+// The element must have been laid out by the last call to EndLayout, so TextSelectionRects must
+// be called between EndLayout and the next BeginLayout. No rectangles are appended otherwise.
+func (context *Context) TextSelectionRects(id ElementID, start, end int, rects []BoundingBox) []BoundingBox {
+	tl, ok := context.textLayout(id)
+	if start > end {
+		start, end = end, start
+	}
+	if !ok || start == end {
+		return rects
+	}
+	lines := tl.data.WrappedLines
+	y := tl.boundingBox.Y
+	for i := range lines {
+		line := &lines[i]
+		lineStart, lineEnd := int(line.offset), tl.textLength()
+		if i+1 < len(lines) {
+			lineEnd = int(lines[i+1].offset)
+		}
+		if end <= lineStart && i > 0 {
+			break
+		}
+		if !tl.bidi && (start < lineEnd || i == len(lines)-1) {
+			x0 := context.textCaretX(&tl, line, max(start, lineStart))
+			x1 := context.textCaretX(&tl, line, min(end, lineEnd))
+			rects = append(rects, BoundingBox{
+				Vector2:    Vector2{X: x0, Y: y},
+				Dimensions: Dimensions{Width: x1 - x0, Height: line.Dimensions.Height},
+			})
+		} else if tl.bidi && start < lineEnd {
+			rects = context.appendRunSelectionRects(&tl, line, y, max(start, lineStart), min(end, lineEnd), rects)
+		}
+		y += line.Dimensions.Height
+	}
+	return rects
+}
+
+// appendRunSelectionRects appends the rectangles of the selection between the byte offsets start and end
+// on a line of bidirectional text, which may be split across runs of either direction.
+func (context *Context) appendRunSelectionRects(tl *textLayout, line *WrappedTextLine, y floatn, start, end int, rects []BoundingBox) []BoundingBox {
+	x := context.lineX(tl, line)
+	lineRects := len(rects)
+	runs := context.textLineRuns(tl, line)
+	for i := range runs {
+		run := &runs[i]
+		text := run.hitText()
+		runStart, runEnd := max(start-run.offset, 0), min(end-run.offset, len(text))
+		if runStart >= runEnd {
+			continue
+		}
+		x0, x1 := context.textRunWidth(run, runStart), context.textRunWidth(run, runEnd)
+		if run.rightToLeft {
+			x0, x1 = run.width-x1, run.width-x0
+		}
+		rect := BoundingBox{
+			Vector2:    Vector2{X: x + run.x + x0, Y: y},
+			Dimensions: Dimensions{Width: x1 - x0, Height: line.Dimensions.Height},
+		}
+		if len(rects) > lineRects {
+			// Merge with the selection of the previous run if they touch.
+			last := &rects[len(rects)-1]
+			if abs(last.X+last.Width-rect.X) < 0.01 {
+				last.Width += rect.Width
+				continue
+			}
+		}
+		rects = append(rects, rect)
+	}
+	return rects
+}
+
+// textLayout is the geometry of a text element computed by the last layout.
+type textLayout struct {
+	data        *TextElementData
+	config      *TextElementConfig
+	boundingBox BoundingBox
+	bidi        bool // Embedding levels of the text are resolved in Context.bidi.
+}
+
+// textRun is text on a wrapped line rendered with a single configuration and direction.
+type textRun struct {
+	text        string
+	offset      int    // Byte offset of text in the text of the element.
+	x           floatn // Offset from the start of the line.
+	width       floatn
+	config      *TextElementConfig
+	span        intn // Index of the rich text span, -1 for plain text.
+	wordSpacing floatn
+	rightToLeft bool
+	shortened   bool // Ellipsis of a shortened line, which does not map to the text.
+}
+
+// hitText returns the text of the run that maps to the text of the element.
+func (run *textRun) hitText() string {
+	if run.shortened {
+		return ""
+	}
+	return run.text
+}
+
+// textLayout returns the geometry of the text element with the given ID or of its first text child.
+// Only elements laid out by the last call to EndLayout are found, not those of a layout being declared.
+func (context *Context) textLayout(id ElementID) (tl textLayout, ok bool) {
+	item := context.declaredHashMapItem(id.ID)
+	if item == nil {
+		return tl, false
+	}
+	element := item.LayoutElement
+	for i := 0; element.textElementData == nil; i++ {
+		if i >= len(item.LayoutElement.children) {
+			return tl, false
+		}
+		element = &context.LayoutElements[item.LayoutElement.children[i]]
+	}
+	if element != item.LayoutElement {
+		item = context.declaredHashMapItem(element.ID)
+		if item == nil {
+			return tl, false
+		}
+	}
+	tl.data = element.textElementData
+	tl.config = element.GetConfig(ElementConfigTypeText).(*TextElementConfig)
+	tl.boundingBox = item.BoundingBox
+	if len(tl.data.WrappedLines) == 0 {
+		return tl, false
+	}
+	tl.bidi = context.resolveBidi(tl.data)
+	return tl, true
+}
+
+// textLength returns the length in bytes of the text of the element.
+func (tl *textLayout) textLength() int {
+	if spans := tl.data.spans; len(spans) > 0 {
+		last := &spans[len(spans)-1]
+		return int(last.offset) + len(last.Text)
+	}
+	return len(tl.data.Text)
+}
+
+// lineAt returns the index and top of the line containing byteOffset.
+func (tl *textLayout) lineAt(byteOffset int) (lineIndex int, y floatn) {
+	lines := tl.data.WrappedLines
+	y = tl.boundingBox.Y
+	for lineIndex < len(lines)-1 && int(lines[lineIndex+1].offset) <= byteOffset {
+		y += lines[lineIndex].Dimensions.Height
+		lineIndex++
+	}
+	return lineIndex, y
+}
+
+// lineX returns the position of the start of the line, as placed by the text alignment.
+func (context *Context) lineX(tl *textLayout, line *WrappedTextLine) floatn {
+	rightToLeft := tl.bidi && context.bidi.paragraphLevel(line.offset)%2 == 1
+	return tl.boundingBox.X + textAlignOffset(tl.config.TextAlignment, rightToLeft, tl.boundingBox.Width, line.Dimensions.Width)
+}
+
+// textLineRuns returns the runs of text on the line in visual order, left to right.
+// The returned slice is reused by the next call.
+func (context *Context) textLineRuns(tl *textLayout, line *WrappedTextLine) []textRun {
+	runs := context.textRuns[:0]
+	if line.ellipsis {
+		// Shortened lines are not reordered.
+		for _, run := range [...]textRun{
+			{text: line.Line, offset: int(line.offset), width: line.ellipsisX},
+			{text: ellipsis, offset: int(line.offset) + len(line.Line), x: line.ellipsisX, width: line.suffixX - line.ellipsisX, shortened: true},
+			{text: line.suffix, offset: stringOffset(tl.data.Text, line.suffix), x: line.suffixX, width: line.Dimensions.Width - line.suffixX},
+		} {
+			if len(run.text) > 0 {
+				run.config, run.span = tl.config, -1
+				runs = append(runs, run)
+			}
+		}
+		context.textRuns = runs
+		return runs
+	}
+	sources := len(line.fragments)
+	if sources == 0 {
+		if len(line.Line) > 0 {
+			sources = 1
+		}
+		runs = append(runs, textRun{
+			text:        line.Line,
+			offset:      int(line.offset),
+			width:       line.Dimensions.Width,
+			config:      tl.config,
+			span:        -1,
+			wordSpacing: line.WordSpacing,
+		})
+		if !tl.bidi || sources == 0 {
+			context.textRuns = runs
+			return runs
+		}
+		runs = runs[:0]
+	}
+	// source returns the i'th run of the line in logical order.
+	source := func(i int) textRun {
+		if len(line.fragments) == 0 {
+			return textRun{text: line.Line, offset: int(line.offset), width: line.Dimensions.Width, config: tl.config, span: -1, wordSpacing: line.WordSpacing}
+		}
+		fragment := &line.fragments[i]
+		return textRun{
+			text:        fragment.Line,
+			offset:      int(fragment.offset),
+			x:           fragment.X,
+			width:       fragment.Width,
+			config:      tl.data.spans[fragment.Span].Config,
+			span:        fragment.Span,
+			wordSpacing: line.WordSpacing,
+		}
+	}
+	if !tl.bidi {
+		for i := 0; i < sources; i++ {
+			runs = append(runs, source(i))
+		}
+		context.textRuns = runs
+		return runs
+	}
+	// Split the runs at direction changes and lay them out in visual order.
+	first, last := source(0), source(sources-1)
+	var x floatn
+	for _, level := range context.bidi.lineRuns(intn(first.offset), intn(last.offset+len(last.text))) {
+		for k := 0; k < sources; k++ {
+			i := k
+			if level.level%2 == 1 {
+				i = sources - 1 - k // Parts of a right-to-left run are laid out right to left.
+			}
+			run := source(i)
+			start, end := max(int(level.start), run.offset), min(int(level.end), run.offset+len(run.text))
+			if start >= end {
+				continue
+			}
+			run.text = run.text[start-run.offset : end-run.offset]
+			run.offset = start
+			run.x = x
+			run.rightToLeft = level.level%2 == 1
+			run.width = context.textRunWidthCached(&run)
+			x += run.width
+			runs = append(runs, run)
+		}
+	}
+	context.textRuns = runs
+	return runs
+}
+
+// textCaretX returns the position of a caret before byteOffset on the line.
+func (context *Context) textCaretX(tl *textLayout, line *WrappedTextLine, byteOffset int) floatn {
+	x := context.lineX(tl, line)
+	runs := context.textLineRuns(tl, line)
+	// The caret is placed in the run of the character at byteOffset, or at the end of the run before it.
+	var caretRun *textRun
+	for i := range runs {
+		run := &runs[i]
+		if run.offset <= byteOffset && (caretRun == nil || run.offset > caretRun.offset) {
+			caretRun = run
+		}
+	}
+	for i := range runs {
+		if caretRun == nil || (caretRun.offset > byteOffset && runs[i].offset < caretRun.offset) {
+			caretRun = &runs[i]
+		}
+	}
+	if caretRun == nil {
+		return x
+	}
+	text := caretRun.hitText()
+	n := min(max(byteOffset-caretRun.offset, 0), len(text))
+	for !isCharacterBoundary(text, n) {
+		n--
+	}
+	caret := context.textRunWidth(caretRun, n)
+	if caretRun.rightToLeft {
+		caret = caretRun.width - caret
+	}
+	return x + caretRun.x + caret
+}
+
+// textRunHit returns the character boundary in the run closest to x, measured from the start of the run.
+func (context *Context) textRunHit(run *textRun, x floatn) int {
+	// Binary search with the prefix at lo fitting in x and the one at hi not.
+	text := run.hitText()
+	lo, hi := 0, len(text)+1
+	for {
+		mid := (lo + hi) / 2
+		for mid > lo && !isCharacterBoundary(text, mid) {
+			mid--
+		}
+		if mid == lo {
+			mid = lo + 1
+			for mid < hi && !isCharacterBoundary(text, mid) {
+				mid++
+			}
+		}
+		if mid >= hi {
+			break
+		}
+		if context.textRunWidth(run, mid) <= x {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if hi <= len(text) && context.textRunWidth(run, hi)-x < x-context.textRunWidth(run, lo) {
+		return hi
+	}
+	return lo
+}
+
+// textRunWidth returns the width of the first n bytes of the run, including the word spacing of justified text.
+func (context *Context) textRunWidth(run *textRun, n int) floatn {
+	if n == 0 {
+		return 0
+	}
+	text := run.text[:n]
+	return context.measureTextRaw(text, run.config).Width + run.spacingWidth(text)
+}
+
+// spacingWidth returns the word spacing added to text of the run by justification.
+func (run *textRun) spacingWidth(text string) floatn {
+	if run.wordSpacing == 0 {
+		return 0
+	}
+	var spaces int
+	inSpace := false
+	for _, r := range text {
+		if isBreakingSpace(r) && !inSpace {
+			spaces++
+		}
+		inSpace = isBreakingSpace(r)
+	}
+	return run.wordSpacing * floatn(spaces)
+}
+
+// textRunWidth is a cached width of a run of bidirectional text, keyed by the hash of its text and configuration.
+type textRunWidth struct {
+	id    uint32
+	width floatn
+}
+
+// textRunWidthCached returns textRunWidth of the whole run. Runs are split at direction changes
+// and so are not in the text measurement cache, their widths are kept in a cache of their own
+// where a run replaces the one of the same slot.
+func (context *Context) textRunWidthCached(run *textRun) floatn {
+	id := hashTextWithConfig(run.text, run.config)
+	if id == 0 || len(context.textRunWidths) == 0 {
+		return context.textRunWidth(run, len(run.text))
+	}
+	cached := &context.textRunWidths[id&uint32(len(context.textRunWidths)-1)]
+	if cached.id != id {
+		*cached = textRunWidth{id: id, width: context.measureTextRaw(run.text, run.config).Width}
+	}
+	return cached.width + run.spacingWidth(run.text)
+}
+
+// textAlignOffset returns the offset of a line of the given width within an element of width available.
+// Left and right alignment are relative to the start and end of the line, which are swapped in
+// right-to-left paragraphs. Lines of justified text that are not stretched are aligned to the start.
+func textAlignOffset(alignment TextAlignment, rightToLeft bool, available, width floatn) floatn {
+	switch alignment {
+	case TextAlignCenter:
+		return (available - width) / 2
+	case TextAlignRight:
+		if rightToLeft {
+			return 0
+		}
+		return available - width
+	}
+	if rightToLeft {
+		return available - width
+	}
+	return 0
+}